@@ -22,6 +22,8 @@ limitations under the License.
 package v1beta2
 
 import (
+	unsafe "unsafe"
+
 	config "gitee.com/openeuler/paws/scheduler/apis/config"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	conversion "k8s.io/apimachinery/pkg/conversion"
@@ -35,6 +37,16 @@ func init() {
 // RegisterConversions adds conversion functions to the given scheme.
 // Public to allow building arbitrary schemes.
 func RegisterConversions(s *runtime.Scheme) error {
+	if err := s.AddGeneratedConversionFunc((*NodePoolPolicy)(nil), (*config.NodePoolPolicy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_NodePoolPolicy_To_config_NodePoolPolicy(a.(*NodePoolPolicy), b.(*config.NodePoolPolicy), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.NodePoolPolicy)(nil), (*NodePoolPolicy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_NodePoolPolicy_To_v1beta2_NodePoolPolicy(a.(*config.NodePoolPolicy), b.(*NodePoolPolicy), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*TemporalUtilizationArgs)(nil), (*config.TemporalUtilizationArgs)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta2_TemporalUtilizationArgs_To_config_TemporalUtilizationArgs(a.(*TemporalUtilizationArgs), b.(*config.TemporalUtilizationArgs), scope)
 	}); err != nil {
@@ -48,6 +60,40 @@ func RegisterConversions(s *runtime.Scheme) error {
 	return nil
 }
 
+func autoConvert_v1beta2_NodePoolPolicy_To_config_NodePoolPolicy(in *NodePoolPolicy, out *config.NodePoolPolicy, s conversion.Scope) error {
+	out.NodeSelector = (*v1.LabelSelector)(unsafe.Pointer(in.NodeSelector))
+	if err := v1.Convert_Pointer_int32_To_int32(&in.HotSpotThreshold, &out.HotSpotThreshold, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_bool_To_bool(&in.HardThreshold, &out.HardThreshold, s); err != nil {
+		return err
+	}
+	out.MaxOvercommitPercentage = (*int32)(unsafe.Pointer(in.MaxOvercommitPercentage))
+	return nil
+}
+
+// Convert_v1beta2_NodePoolPolicy_To_config_NodePoolPolicy is an autogenerated conversion function.
+func Convert_v1beta2_NodePoolPolicy_To_config_NodePoolPolicy(in *NodePoolPolicy, out *config.NodePoolPolicy, s conversion.Scope) error {
+	return autoConvert_v1beta2_NodePoolPolicy_To_config_NodePoolPolicy(in, out, s)
+}
+
+func autoConvert_config_NodePoolPolicy_To_v1beta2_NodePoolPolicy(in *config.NodePoolPolicy, out *NodePoolPolicy, s conversion.Scope) error {
+	out.NodeSelector = (*v1.LabelSelector)(unsafe.Pointer(in.NodeSelector))
+	if err := v1.Convert_int32_To_Pointer_int32(&in.HotSpotThreshold, &out.HotSpotThreshold, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_bool_To_Pointer_bool(&in.HardThreshold, &out.HardThreshold, s); err != nil {
+		return err
+	}
+	out.MaxOvercommitPercentage = (*int32)(unsafe.Pointer(in.MaxOvercommitPercentage))
+	return nil
+}
+
+// Convert_config_NodePoolPolicy_To_v1beta2_NodePoolPolicy is an autogenerated conversion function.
+func Convert_config_NodePoolPolicy_To_v1beta2_NodePoolPolicy(in *config.NodePoolPolicy, out *NodePoolPolicy, s conversion.Scope) error {
+	return autoConvert_config_NodePoolPolicy_To_v1beta2_NodePoolPolicy(in, out, s)
+}
+
 func autoConvert_v1beta2_TemporalUtilizationArgs_To_config_TemporalUtilizationArgs(in *TemporalUtilizationArgs, out *config.TemporalUtilizationArgs, s conversion.Scope) error {
 	if err := v1.Convert_Pointer_int32_To_int32(&in.HotSpotThreshold, &out.HotSpotThreshold, s); err != nil {
 		return err
@@ -61,6 +107,65 @@ func autoConvert_v1beta2_TemporalUtilizationArgs_To_config_TemporalUtilizationAr
 	if err := v1.Convert_Pointer_bool_To_bool(&in.FilterByTemporalUsages, &out.FilterByTemporalUsages, s); err != nil {
 		return err
 	}
+	if err := v1.Convert_Pointer_bool_To_bool(&in.EnableTemporalPreemption, &out.EnableTemporalPreemption, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_int32_To_int32(&in.MinConfidence, &out.MinConfidence, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_int32_To_int32(&in.MinSamplesPerHour, &out.MinSamplesPerHour, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_string_To_string(&in.FallbackPolicy, &out.FallbackPolicy, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_int32_To_int32(&in.FallbackLimitsPercentage, &out.FallbackLimitsPercentage, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_bool_To_bool(&in.FilterPodsWithoutTemplateByRequests, &out.FilterPodsWithoutTemplateByRequests, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_int32_To_int32(&in.ScoringHorizonHours, &out.ScoringHorizonHours, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_string_To_string(&in.ScoringDecay, &out.ScoringDecay, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_int32_To_int32(&in.ScoringDecayHalfLifeHours, &out.ScoringDecayHalfLifeHours, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_bool_To_bool(&in.InferHorizonFromTemplate, &out.InferHorizonFromTemplate, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_int32_To_int32(&in.MemoryOOMMarginPercentage, &out.MemoryOOMMarginPercentage, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_int32_To_int32(&in.LiveUtilizationWeight, &out.LiveUtilizationWeight, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_string_To_string(&in.LiveUtilizationPrometheusAddress, &out.LiveUtilizationPrometheusAddress, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_string_To_string(&in.LiveUtilizationNodeMetricLabel, &out.LiveUtilizationNodeMetricLabel, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_int32_To_int32(&in.LiveUtilizationRefreshSeconds, &out.LiveUtilizationRefreshSeconds, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_Pointer_int32_To_int32(&in.AntiCorrelationWeight, &out.AntiCorrelationWeight, s); err != nil {
+		return err
+	}
+	if in.NodePoolPolicies != nil {
+		in, out := &in.NodePoolPolicies, &out.NodePoolPolicies
+		*out = make([]config.NodePoolPolicy, len(*in))
+		for i := range *in {
+			if err := Convert_v1beta2_NodePoolPolicy_To_config_NodePoolPolicy(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.NodePoolPolicies = nil
+	}
 	return nil
 }
 
@@ -82,6 +187,65 @@ func autoConvert_config_TemporalUtilizationArgs_To_v1beta2_TemporalUtilizationAr
 	if err := v1.Convert_bool_To_Pointer_bool(&in.FilterByTemporalUsages, &out.FilterByTemporalUsages, s); err != nil {
 		return err
 	}
+	if err := v1.Convert_bool_To_Pointer_bool(&in.EnableTemporalPreemption, &out.EnableTemporalPreemption, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_int32_To_Pointer_int32(&in.MinConfidence, &out.MinConfidence, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_int32_To_Pointer_int32(&in.MinSamplesPerHour, &out.MinSamplesPerHour, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_string_To_Pointer_string(&in.FallbackPolicy, &out.FallbackPolicy, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_int32_To_Pointer_int32(&in.FallbackLimitsPercentage, &out.FallbackLimitsPercentage, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_bool_To_Pointer_bool(&in.FilterPodsWithoutTemplateByRequests, &out.FilterPodsWithoutTemplateByRequests, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_int32_To_Pointer_int32(&in.ScoringHorizonHours, &out.ScoringHorizonHours, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_string_To_Pointer_string(&in.ScoringDecay, &out.ScoringDecay, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_int32_To_Pointer_int32(&in.ScoringDecayHalfLifeHours, &out.ScoringDecayHalfLifeHours, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_bool_To_Pointer_bool(&in.InferHorizonFromTemplate, &out.InferHorizonFromTemplate, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_int32_To_Pointer_int32(&in.MemoryOOMMarginPercentage, &out.MemoryOOMMarginPercentage, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_int32_To_Pointer_int32(&in.LiveUtilizationWeight, &out.LiveUtilizationWeight, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_string_To_Pointer_string(&in.LiveUtilizationPrometheusAddress, &out.LiveUtilizationPrometheusAddress, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_string_To_Pointer_string(&in.LiveUtilizationNodeMetricLabel, &out.LiveUtilizationNodeMetricLabel, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_int32_To_Pointer_int32(&in.LiveUtilizationRefreshSeconds, &out.LiveUtilizationRefreshSeconds, s); err != nil {
+		return err
+	}
+	if err := v1.Convert_int32_To_Pointer_int32(&in.AntiCorrelationWeight, &out.AntiCorrelationWeight, s); err != nil {
+		return err
+	}
+	if in.NodePoolPolicies != nil {
+		in, out := &in.NodePoolPolicies, &out.NodePoolPolicies
+		*out = make([]NodePoolPolicy, len(*in))
+		for i := range *in {
+			if err := Convert_config_NodePoolPolicy_To_v1beta2_NodePoolPolicy(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.NodePoolPolicies = nil
+	}
 	return nil
 }
 
@@ -22,9 +22,46 @@ limitations under the License.
 package v1beta2
 
 import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolPolicy) DeepCopyInto(out *NodePoolPolicy) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HotSpotThreshold != nil {
+		in, out := &in.HotSpotThreshold, &out.HotSpotThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HardThreshold != nil {
+		in, out := &in.HardThreshold, &out.HardThreshold
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MaxOvercommitPercentage != nil {
+		in, out := &in.MaxOvercommitPercentage, &out.MaxOvercommitPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolPolicy.
+func (in *NodePoolPolicy) DeepCopy() *NodePoolPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TemporalUtilizationArgs) DeepCopyInto(out *TemporalUtilizationArgs) {
 	*out = *in
@@ -49,6 +86,93 @@ func (in *TemporalUtilizationArgs) DeepCopyInto(out *TemporalUtilizationArgs) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.EnableTemporalPreemption != nil {
+		in, out := &in.EnableTemporalPreemption, &out.EnableTemporalPreemption
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MinConfidence != nil {
+		in, out := &in.MinConfidence, &out.MinConfidence
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinSamplesPerHour != nil {
+		in, out := &in.MinSamplesPerHour, &out.MinSamplesPerHour
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FallbackPolicy != nil {
+		in, out := &in.FallbackPolicy, &out.FallbackPolicy
+		*out = new(string)
+		**out = **in
+	}
+	if in.FallbackLimitsPercentage != nil {
+		in, out := &in.FallbackLimitsPercentage, &out.FallbackLimitsPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FilterPodsWithoutTemplateByRequests != nil {
+		in, out := &in.FilterPodsWithoutTemplateByRequests, &out.FilterPodsWithoutTemplateByRequests
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ScoringHorizonHours != nil {
+		in, out := &in.ScoringHorizonHours, &out.ScoringHorizonHours
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ScoringDecay != nil {
+		in, out := &in.ScoringDecay, &out.ScoringDecay
+		*out = new(string)
+		**out = **in
+	}
+	if in.ScoringDecayHalfLifeHours != nil {
+		in, out := &in.ScoringDecayHalfLifeHours, &out.ScoringDecayHalfLifeHours
+		*out = new(int32)
+		**out = **in
+	}
+	if in.InferHorizonFromTemplate != nil {
+		in, out := &in.InferHorizonFromTemplate, &out.InferHorizonFromTemplate
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MemoryOOMMarginPercentage != nil {
+		in, out := &in.MemoryOOMMarginPercentage, &out.MemoryOOMMarginPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.LiveUtilizationWeight != nil {
+		in, out := &in.LiveUtilizationWeight, &out.LiveUtilizationWeight
+		*out = new(int32)
+		**out = **in
+	}
+	if in.LiveUtilizationPrometheusAddress != nil {
+		in, out := &in.LiveUtilizationPrometheusAddress, &out.LiveUtilizationPrometheusAddress
+		*out = new(string)
+		**out = **in
+	}
+	if in.LiveUtilizationNodeMetricLabel != nil {
+		in, out := &in.LiveUtilizationNodeMetricLabel, &out.LiveUtilizationNodeMetricLabel
+		*out = new(string)
+		**out = **in
+	}
+	if in.LiveUtilizationRefreshSeconds != nil {
+		in, out := &in.LiveUtilizationRefreshSeconds, &out.LiveUtilizationRefreshSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AntiCorrelationWeight != nil {
+		in, out := &in.AntiCorrelationWeight, &out.AntiCorrelationWeight
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NodePoolPolicies != nil {
+		in, out := &in.NodePoolPolicies, &out.NodePoolPolicies
+		*out = make([]NodePoolPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
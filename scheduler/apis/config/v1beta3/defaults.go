@@ -19,6 +19,28 @@ var (
 	DefaultHardThresholdValue = false
 	// DefaultEnableOvercommitValue is the default value for EnableOvercommit
 	DefaultEnableOvercommitValue = true
+	// DefaultFallbackPolicy is the default value for FallbackPolicy
+	DefaultFallbackPolicy = "ByClass"
+	// DefaultFallbackLimitsPercentage is the default value for FallbackLimitsPercentage
+	DefaultFallbackLimitsPercentage = 100
+	// DefaultScoringDecay is the default value for ScoringDecay
+	DefaultScoringDecay = "None"
+	// DefaultScoringDecayHalfLifeHours is the default value for ScoringDecayHalfLifeHours
+	DefaultScoringDecayHalfLifeHours = 6
+	// DefaultInferHorizonFromTemplateValue is the default value for InferHorizonFromTemplate
+	DefaultInferHorizonFromTemplateValue = true
+	// DefaultMemoryOOMMarginPercentage is the default value for MemoryOOMMarginPercentage
+	DefaultMemoryOOMMarginPercentage = 10
+	// DefaultLiveUtilizationWeight is the default value for LiveUtilizationWeight
+	DefaultLiveUtilizationWeight = 0
+	// DefaultLiveUtilizationPrometheusAddress is the default value for LiveUtilizationPrometheusAddress
+	DefaultLiveUtilizationPrometheusAddress = "http://prometheus:9090"
+	// DefaultLiveUtilizationNodeMetricLabel is the default value for LiveUtilizationNodeMetricLabel
+	DefaultLiveUtilizationNodeMetricLabel = "node"
+	// DefaultLiveUtilizationRefreshSeconds is the default value for LiveUtilizationRefreshSeconds
+	DefaultLiveUtilizationRefreshSeconds = 30
+	// DefaultAntiCorrelationWeight is the default value for AntiCorrelationWeight
+	DefaultAntiCorrelationWeight = 0
 )
 
 // SetDefaults_TemporalUtilizationArgs
@@ -37,4 +59,72 @@ func SetDefaults_TemporalUtilizationArgs(args *TemporalUtilizationArgs) {
 		args.EnableOvercommit = new(bool)
 		*args.EnableOvercommit = DefaultEnableOvercommitValue
 	}
+
+	if args.FallbackPolicy == nil {
+		args.FallbackPolicy = new(string)
+		*args.FallbackPolicy = DefaultFallbackPolicy
+	}
+
+	if args.FallbackLimitsPercentage == nil {
+		args.FallbackLimitsPercentage = new(int32)
+		*args.FallbackLimitsPercentage = int32(DefaultFallbackLimitsPercentage)
+	}
+
+	if args.ScoringDecay == nil {
+		args.ScoringDecay = new(string)
+		*args.ScoringDecay = DefaultScoringDecay
+	}
+
+	if args.ScoringDecayHalfLifeHours == nil {
+		args.ScoringDecayHalfLifeHours = new(int32)
+		*args.ScoringDecayHalfLifeHours = int32(DefaultScoringDecayHalfLifeHours)
+	}
+
+	if args.InferHorizonFromTemplate == nil {
+		args.InferHorizonFromTemplate = new(bool)
+		*args.InferHorizonFromTemplate = DefaultInferHorizonFromTemplateValue
+	}
+
+	if args.MemoryOOMMarginPercentage == nil {
+		args.MemoryOOMMarginPercentage = new(int32)
+		*args.MemoryOOMMarginPercentage = int32(DefaultMemoryOOMMarginPercentage)
+	}
+
+	if args.LiveUtilizationWeight == nil {
+		args.LiveUtilizationWeight = new(int32)
+		*args.LiveUtilizationWeight = int32(DefaultLiveUtilizationWeight)
+	}
+
+	if args.LiveUtilizationPrometheusAddress == nil {
+		args.LiveUtilizationPrometheusAddress = new(string)
+		*args.LiveUtilizationPrometheusAddress = DefaultLiveUtilizationPrometheusAddress
+	}
+
+	if args.LiveUtilizationNodeMetricLabel == nil {
+		args.LiveUtilizationNodeMetricLabel = new(string)
+		*args.LiveUtilizationNodeMetricLabel = DefaultLiveUtilizationNodeMetricLabel
+	}
+
+	if args.LiveUtilizationRefreshSeconds == nil {
+		args.LiveUtilizationRefreshSeconds = new(int32)
+		*args.LiveUtilizationRefreshSeconds = int32(DefaultLiveUtilizationRefreshSeconds)
+	}
+
+	if args.AntiCorrelationWeight == nil {
+		args.AntiCorrelationWeight = new(int32)
+		*args.AntiCorrelationWeight = int32(DefaultAntiCorrelationWeight)
+	}
+
+	for i := range args.NodePoolPolicies {
+		policy := &args.NodePoolPolicies[i]
+		if policy.HotSpotThreshold == nil {
+			policy.HotSpotThreshold = new(int32)
+			*policy.HotSpotThreshold = *args.HotSpotThreshold
+		}
+
+		if policy.HardThreshold == nil {
+			policy.HardThreshold = new(bool)
+			*policy.HardThreshold = *args.HardThreshold
+		}
+	}
 }
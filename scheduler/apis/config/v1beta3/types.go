@@ -43,4 +43,85 @@ type TemporalUtilizationArgs struct {
 
 	// FilterByTemporalUsage is a flag to indicate whether the plugin conducts filtering stage by temporal usages if present
 	FilterByTemporalUsages *bool `json:"filterByTemporalUsages,omitempty"`
+
+	// EnableTemporalPreemption is a flag to indicate whether the plugin preempts the lower priority or BestEffort pods
+	// contributing the most to the forecast peaks when a pod does not fit by temporal usages
+	EnableTemporalPreemption *bool `json:"enableTemporalPreemption,omitempty"`
+
+	// MinConfidence is the minimum confidence (0-100) of a usage template evaluation to be used,
+	// otherwise the usages are assumed by the pod requests. 0 disables the check
+	MinConfidence *int32 `json:"minConfidence,omitempty"`
+
+	// MinSamplesPerHour is the minimum number of samples every hourly usage of a usage template
+	// must be evaluated from to be used, otherwise the usages are assumed by the pod requests. 0 disables the check
+	MinSamplesPerHour *int32 `json:"minSamplesPerHour,omitempty"`
+
+	// FallbackPolicy is the usage assumed for pods without a usable usage template, one of
+	// ByClass (default), Requests, Limits, LimitsPercentage or NamespaceAggregate.
+	// It can be overridden per namespace by the scheduling.x-k8s.io/usage-fallback-policy annotation
+	FallbackPolicy *string `json:"fallbackPolicy,omitempty"`
+
+	// FallbackLimitsPercentage is the percentage (1-100) of the limits assumed by the LimitsPercentage fallback policy
+	FallbackLimitsPercentage *int32 `json:"fallbackLimitsPercentage,omitempty"`
+
+	// FilterPodsWithoutTemplateByRequests is a flag to indicate whether the incoming pods without a usable usage template
+	// are filtered by their requests instead of by temporal usages
+	FilterPodsWithoutTemplateByRequests *bool `json:"filterPodsWithoutTemplateByRequests,omitempty"`
+
+	// ScoringHorizonHours is the number of upcoming hours (0-168) considered in scoring, 0 considers all the hours
+	ScoringHorizonHours *int32 `json:"scoringHorizonHours,omitempty"`
+
+	// ScoringDecay is how the upcoming hours are weighed in scoring, one of None (default), Linear or Exponential
+	ScoringDecay *string `json:"scoringDecay,omitempty"`
+
+	// ScoringDecayHalfLifeHours is the number of hours after which an hour weighs half in the Exponential scoring decay
+	ScoringDecayHalfLifeHours *int32 `json:"scoringDecayHalfLifeHours,omitempty"`
+
+	// InferHorizonFromTemplate is a flag to indicate whether the filtering and scoring only consider the hours
+	// a pod is expected to live, inferred from its usage template when it is not long running
+	InferHorizonFromTemplate *bool `json:"inferHorizonFromTemplate,omitempty"`
+
+	// MemoryOOMMarginPercentage is the percentage (0-100) of the node allocatable memory kept free of the forecast
+	// peak working set, the memory overcommit is capped so that the forecast peak working set of the node
+	// plus the margin stays within the allocatable memory
+	MemoryOOMMarginPercentage *int32 `json:"memoryOOMMarginPercentage,omitempty"`
+
+	// LiveUtilizationWeight is the weight percentage (0-100) of the live CPU usage of a node blended with the forecast
+	// of the current hour in scoring, 0 disables the live utilization
+	LiveUtilizationWeight *int32 `json:"liveUtilizationWeight,omitempty"`
+
+	// LiveUtilizationPrometheusAddress is the address of the Prometheus the live CPU usage of the nodes is queried from
+	LiveUtilizationPrometheusAddress *string `json:"liveUtilizationPrometheusAddress,omitempty"`
+
+	// LiveUtilizationNodeMetricLabel is the label of the CPU usage metrics with the node name
+	LiveUtilizationNodeMetricLabel *string `json:"liveUtilizationNodeMetricLabel,omitempty"`
+
+	// LiveUtilizationRefreshSeconds is the interval between two queries of the live CPU usage of the nodes
+	LiveUtilizationRefreshSeconds *int32 `json:"liveUtilizationRefreshSeconds,omitempty"`
+
+	// AntiCorrelationWeight is the weight percentage (0-100) of the anti-correlation of the hourly usages of a pod and a node
+	// blended with the hotspot score, the nodes whose usages peak when the pod does not are preferred. 0 disables it
+	AntiCorrelationWeight *int32 `json:"antiCorrelationWeight,omitempty"`
+
+	// NodePoolPolicies override the hotspot threshold and the overcommit of the nodes matching their node selector,
+	// the first matching policy applies
+	NodePoolPolicies []NodePoolPolicy `json:"nodePoolPolicies,omitempty"`
+}
+
+// NodePoolPolicy is the hotspot threshold and the overcommit ceiling of a pool of nodes
+type NodePoolPolicy struct {
+	// NodeSelector selects the nodes of the pool by their labels
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+
+	// HotSpotThreshold is the hotspot threshold (1-100) of the nodes of the pool, defaults to the plugin hotSpotThreshold
+	HotSpotThreshold *int32 `json:"hotSpotThreshold,omitempty"`
+
+	// HardThreshold is a flag to indicate whether the threshold of the nodes of the pool is hard or soft,
+	// defaults to the plugin hardThreshold
+	HardThreshold *bool `json:"hardThreshold,omitempty"`
+
+	// MaxOvercommitPercentage caps the overcommit ratio annotations of the nodes of the pool,
+	// as a percentage of the allocatable, e.g. 50 allows overcommitting up to 1.5 times the allocatable.
+	// The overcommit is not capped when not set
+	MaxOvercommitPercentage *int32 `json:"maxOvercommitPercentage,omitempty"`
 }
@@ -49,7 +49,8 @@ func init() {
 // Adds the list of known types to Scheme.
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
-		&UsageTemplate{}, &UsageTemplateList{})
+		&UsageTemplate{}, &UsageTemplateList{},
+		&NodeUsageForecast{}, &NodeUsageForecastList{})
 	// AddToGroupVersion allows the serialization of client types like ListOptions.
 	v1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -16,6 +16,51 @@ const (
 	// it is an annotation because it is not for filtering
 	NodeCPUOvercommitRatioAnnotation = scheduling.GroupName + "/cpu-overcommit-ratio"
 
+	// NodeCPUOvercommitTunedAtAnnotation is the time in RFC3339 the controller last changed the cpu-overcommit-ratio
+	// of a node, the ratio is not tuned again before the tuning interval has passed
+	NodeCPUOvercommitTunedAtAnnotation = scheduling.GroupName + "/cpu-overcommit-tuned-at"
+
+	// NodeMemoryOvercommitRatioAnnotation is the memory overcommit ratio of a node, the memory overcommit
+	// is further capped by the forecast peak working set of the node
+	NodeMemoryOvercommitRatioAnnotation = scheduling.GroupName + "/memory-overcommit-ratio"
+
+	// NodeEphemeralStorageOvercommitRatioAnnotation is the ephemeral storage overcommit ratio of a node
+	NodeEphemeralStorageOvercommitRatioAnnotation = scheduling.GroupName + "/ephemeral-storage-overcommit-ratio"
+
+	// NodeCPUOvercommitScheduleAnnotation sets the overcommit ratio by hour of the week in UTC,
+	// e.g. "0-23=0.5;weekday:8-18=0.1" overcommits 50% except 10% during the weekday business hours.
+	// The hours not in the schedule use the cpu-overcommit-ratio annotation
+	NodeCPUOvercommitScheduleAnnotation = scheduling.GroupName + "/cpu-overcommit-schedule"
+
+	// NodeCPUPerformanceFactorAnnotation is the CPU performance of a node relative to a reference core,
+	// e.g. 1.25 means a core of the node does 25% more work than a reference core, it defaults to 1.
+	// The usages of the usage templates are normalized to reference cores by the node they were measured on
+	NodeCPUPerformanceFactorAnnotation = scheduling.GroupName + "/cpu-performance-factor"
+
+	// NodeCPUBaselineUsageAnnotation is the CPU usage of a node not attributed to any pod container, e.g. the kernel,
+	// the kubelet, the container runtime and the host daemons, in millicores of the node by hour of the week in UTC.
+	// It is maintained by the controller as "weekday=<24 comma separated values>;weekend=<24 comma separated values>",
+	// an hour without samples is left empty
+	NodeCPUBaselineUsageAnnotation = scheduling.GroupName + "/cpu-baseline-usage"
+
+	// DefaultNodeMetricLabel is the label of the usage timeseries with the name of the node the usage was measured on
+	DefaultNodeMetricLabel = "node"
+
+	// NamespaceUsageFallbackPolicyAnnotation overrides the scheduler fallback policy for the pods
+	// in the namespace without a usable usage template, e.g. Limits
+	NamespaceUsageFallbackPolicyAnnotation = scheduling.GroupName + "/usage-fallback-policy"
+	// NamespaceUsageFallbackLimitsPercentageAnnotation overrides the percentage of the limits
+	// assumed by the LimitsPercentage fallback policy, e.g. "50"
+	NamespaceUsageFallbackLimitsPercentageAnnotation = scheduling.GroupName + "/usage-fallback-limits-percentage"
+
+	// PodDeferrableDeadlineAnnotation marks a pod as deferrable, it waits at Permit until the forecasts of its node
+	// enter a valley, at the latest until the deadline, either a RFC3339 time or a duration from the creation of the pod, e.g. "6h"
+	PodDeferrableDeadlineAnnotation = scheduling.GroupName + "/deferrable-deadline"
+
+	// PodUsageTemplateWaitTimeoutAnnotation opts a pod in to wait out of the scheduling queue until its usage template is evaluated,
+	// at the latest until the timeout from the creation of the pod, e.g. "30m"
+	PodUsageTemplateWaitTimeoutAnnotation = scheduling.GroupName + "/usage-template-wait-timeout"
+
 	// TODO: To evaluate how often
 	DefaultEvaluationPeriodHours = 6
 	DefaultEvaluationWindowDays  = 14
@@ -24,6 +69,8 @@ const (
 var (
 	SupportedResourcesMetricLabel = map[string]string{
 		v1.ResourceCPU.String(): "container_cpu_usage_seconds_total",
+		// the working set is what the kubelet evicts and the kernel OOM kills on
+		v1.ResourceMemory.String(): "container_memory_working_set_bytes",
 	}
 
 	// For the supported resources, if they are a counter
@@ -39,21 +86,39 @@ var (
 	SupportedMetricLabelFilters = map[string][]string{
 		// https://stackoverflow.com/questions/69281327/why-container-memory-usage-is-doubled-in-cadvisor-metrics/69282328#69282328
 		// To ignore empty cgroup hierarchy
-		"container_cpu_usage_seconds_total": {"container!=\"\""},
+		"container_cpu_usage_seconds_total":  {"container!=\"\""},
+		"container_memory_working_set_bytes": {"container!=\"\""},
 	}
 
 	SupportedResourceMetricUnit = map[string]string{
-		v1.ResourceCPU.String(): "millicore",
+		v1.ResourceCPU.String():    "millicore",
+		v1.ResourceMemory.String(): "byte",
 	}
 
 	SupportedResourceMetricScalingFactor = map[string]float64{
 		// container_cpu_usage_seconds_total returns core seconds.
 		// i.e. 1 = 1000 millicore
 		v1.ResourceCPU.String(): 1000.0,
+		// container_memory_working_set_bytes returns bytes
+		v1.ResourceMemory.String(): 1.0,
 	}
 
 	SupportedOvercommitResourceAnnotation = map[string]string{
-		v1.ResourceCPU.String(): NodeCPUOvercommitRatioAnnotation,
+		v1.ResourceCPU.String():              NodeCPUOvercommitRatioAnnotation,
+		v1.ResourceMemory.String():           NodeMemoryOvercommitRatioAnnotation,
+		v1.ResourceEphemeralStorage.String(): NodeEphemeralStorageOvercommitRatioAnnotation,
+	}
+
+	SupportedOvercommitScheduleAnnotation = map[string]string{
+		v1.ResourceCPU.String(): NodeCPUOvercommitScheduleAnnotation,
+	}
+
+	SupportedPerformanceFactorAnnotation = map[string]string{
+		v1.ResourceCPU.String(): NodeCPUPerformanceFactorAnnotation,
+	}
+
+	SupportedBaselineUsageAnnotation = map[string]string{
+		v1.ResourceCPU.String(): NodeCPUBaselineUsageAnnotation,
 	}
 )
 
@@ -86,7 +151,7 @@ type UsageTemplateSpec struct {
 	EvaluatePeriodHours *int32 `json:"evaluatePeriodHours,omitempty" protobuf:"bytes,2,name=evaluatePeriodHours"`
 	// EvaluationWindow specify the desire time window in days for this specific UT, default to 14 days
 	EvaluationWindowDays *int16 `json:"evaluationWindowDays,omitempty" protobuf:"bytes,3,name=evaluationWindowDays"`
-	// Resources specify the desire resource to evaluate for, currently supports CPU
+	// Resources specify the desire resource to evaluate for, currently supports CPU and memory
 	Resources []string `json:"resources,omitempty" protobuf:"bytes,3,rep,name=resources"`
 	// Filters to specify how to look for an application pods, i.e. "k=v,k!=v,k~=v"
 	// we are not using the k8s labelSelector because a few labelExpression are not supported in prometheus
@@ -104,6 +169,38 @@ type UsageTemplateSpec struct {
 	// PriorityClass specify whether the priority of the application
 	// follow the kubernetes convention. i.e. Guaranteed, Burstable, BestEffort
 	QualityOfServiceClass string `json:"qualityOfServiceClass,omitempty" protobuf:"bytes,8,name=qualityOfServiceClass"`
+	// JoinMode specify how the application pods are identified, default to CAdvisorLabels.
+	// KubeStateMetrics resolves the pods through kube_pod_labels and kube_pod_owner instead of
+	// relying on cAdvisor whitelisted container labels
+	// +optional
+	JoinMode UsageTemplateJoinMode `json:"joinMode,omitempty" protobuf:"bytes,9,opt,name=joinMode"`
+	// PodSelector selects the application pods by their kubernetes labels,
+	// only used when JoinMode is KubeStateMetrics
+	// +optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty" protobuf:"bytes,10,opt,name=podSelector"`
+	// Owner selects the application pods by the workload owning them,
+	// only used when JoinMode is KubeStateMetrics
+	// +optional
+	Owner *WorkloadReference `json:"owner,omitempty" protobuf:"bytes,11,opt,name=owner"`
+}
+
+// UsageTemplateJoinMode describes how the evaluator identifies the application pods
+type UsageTemplateJoinMode string
+
+const (
+	// CAdvisorLabelsJoinMode identifies the pods through the container labels exposed by cAdvisor,
+	// i.e. Filters, JoinLabels and JoinFilters
+	CAdvisorLabelsJoinMode UsageTemplateJoinMode = "CAdvisorLabels"
+	// KubeStateMetricsJoinMode identifies the pods through kube_pod_labels and kube_pod_owner from kube-state-metrics
+	KubeStateMetricsJoinMode UsageTemplateJoinMode = "KubeStateMetrics"
+)
+
+// WorkloadReference refers to the workload owning the application pods
+type WorkloadReference struct {
+	// Kind of the workload, i.e. Deployment, ReplicaSet, StatefulSet, DaemonSet, Job, CronJob
+	Kind string `json:"kind" protobuf:"bytes,1,name=kind"`
+	// Name of the workload, in the same namespace as the UsageTemplate
+	Name string `json:"name" protobuf:"bytes,2,name=name"`
 }
 
 // Sample contains the actual usage for the particular hour
@@ -117,6 +214,9 @@ type Sample struct {
 	Unit string `json:"unit" protobuf:"bytes,4,name=unit"`
 	// whether this is a weekday value
 	IsWeekday bool `json:"isWeekday,omitempty" protobuf:"bytes,5,opt,name=isWeekday"`
+	// how many datapoints the value was calculated from
+	// +optional
+	Count int32 `json:"count,omitempty" protobuf:"bytes,6,opt,name=count"`
 }
 
 // ResourceUsage is the historical usage of a resource
@@ -125,6 +225,9 @@ type ResourceUsage struct {
 	Resource string `json:"name" protobuf:"bytes,1,name=resource"`
 	// Usages contains the samples for the resource
 	Usages []Sample `json:"usages" protobuf:"bytes,2,rep,name=usages"`
+	// Coverage describes how much data the most recent evaluation of the resource was based on
+	// +optional
+	Coverage *EvaluationCoverage `json:"coverage,omitempty" protobuf:"bytes,3,opt,name=coverage"`
 }
 
 // ResourceUsages is the evaluated historical usage per resource
@@ -149,6 +252,23 @@ type UsageTemplateStatus struct {
 	// IsLongRunning indicates whether this application is long running, defined as longer than 24 hours
 	// +optional
 	IsLongRunning bool `json:"isLongRunning,omitempty" protobuf:"bytes,3,name=isLongRunning"`
+	// Coverage describes how much data the most recent evaluation was based on,
+	// it is the coverage of the resource with the lowest confidence
+	// +optional
+	Coverage *EvaluationCoverage `json:"coverage,omitempty" protobuf:"bytes,4,opt,name=coverage"`
+}
+
+// EvaluationCoverage describes how much data an evaluation was based on
+type EvaluationCoverage struct {
+	// WindowHours is the evaluation window actually used, it is shorter than EvaluationWindowDays
+	// when the prometheus retention is shorter
+	WindowHours int32 `json:"windowHours" protobuf:"bytes,1,name=windowHours"`
+	// ObservedHours is the number of hours between the oldest and the newest datapoint
+	ObservedHours int32 `json:"observedHours" protobuf:"bytes,2,name=observedHours"`
+	// CoveredHours is the number of weekday and weekend hours (out of 48) that have datapoints
+	CoveredHours int32 `json:"coveredHours" protobuf:"bytes,3,name=coveredHours"`
+	// Confidence is the overall score between 0 and 100 of how much the evaluated usages can be trusted
+	Confidence int32 `json:"confidence" protobuf:"bytes,4,name=confidence"`
 }
 
 // Conditions maintains a list of condition
@@ -285,3 +405,79 @@ type UsageTemplateList struct {
 	// Items is the list of UsageTemplate
 	Items []UsageTemplate `json:"items"`
 }
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName={nuf,nufs}
+// +kubebuilder:subresource:status
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// NodeUsageForecast is the hourly usage forecast of a node aggregated from the usage templates of its pods,
+// it is named after the node and maintained by the controller
+type NodeUsageForecast struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	Status            NodeUsageForecastStatus `json:"status,omitempty" protobuf:"bytes,2,opt,name=status"`
+}
+
+// NodeUsageForecastStatus is the most recent forecast of the node
+type NodeUsageForecastStatus struct {
+	// Resources contains the forecast per resource
+	// +optional
+	Resources []NodeResourceForecast `json:"resources,omitempty" protobuf:"bytes,1,rep,name=resources"`
+	// Templates are the usage templates of the pods on the node contributing to the forecast
+	// +optional
+	Templates []ContributingTemplate `json:"templates,omitempty" protobuf:"bytes,2,rep,name=templates"`
+	// PodsWithoutTemplate is the number of pods on the node without a usable usage template,
+	// their usage is assumed by the fallback policy of the scheduler
+	// +optional
+	PodsWithoutTemplate int32 `json:"podsWithoutTemplate,omitempty" protobuf:"bytes,3,opt,name=podsWithoutTemplate"`
+	// LastUpdateTime is the time the forecast last changed
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty" protobuf:"bytes,4,opt,name=lastUpdateTime"`
+}
+
+// NodeResourceForecast is the hourly forecast of a resource of the node
+type NodeResourceForecast struct {
+	// Name of the resource
+	Resource string `json:"name" protobuf:"bytes,1,name=resource"`
+	// what unit, e.g. millicore, the CPU is in reference millicores as the usage templates,
+	// i.e. scaled by the performance factor of the node
+	Unit string `json:"unit" protobuf:"bytes,2,name=unit"`
+	// Allocatable is the allocatable of the node, in the unit of the forecast
+	Allocatable string `json:"allocatable" protobuf:"bytes,3,name=allocatable"`
+	// Hours contains the forecast of every weekday and weekend hour in UTC
+	Hours []NodeHourlyForecast `json:"hours" protobuf:"bytes,4,rep,name=hours"`
+}
+
+// NodeHourlyForecast is the forecast of a resource of the node for the particular hour
+type NodeHourlyForecast struct {
+	Hour int32 `json:"hour" protobuf:"bytes,1,name=hour"`
+	// whether this is a weekday value
+	IsWeekday bool `json:"isWeekday,omitempty" protobuf:"bytes,2,opt,name=isWeekday"`
+	// Usage is the summed usage of the pods on the node and of the node baseline
+	Usage string `json:"usage" protobuf:"bytes,3,name=usage"`
+	// Headroom is the allocatable less the usage, negative when the node is forecast to be overloaded
+	Headroom string `json:"headroom" protobuf:"bytes,4,name=headroom"`
+}
+
+// ContributingTemplate refers to a usage template of the pods on the node
+type ContributingTemplate struct {
+	Namespace string `json:"namespace" protobuf:"bytes,1,name=namespace"`
+	Name      string `json:"name" protobuf:"bytes,2,name=name"`
+	// Pods is the number of pods on the node with the usage template
+	Pods int32 `json:"pods" protobuf:"bytes,3,name=pods"`
+}
+
+// +kubebuilder:object:root=true
+
+// NodeUsageForecastList is a collection of NodeUsageForecasts.
+type NodeUsageForecastList struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard list metadata
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// Items is the list of NodeUsageForecast
+	Items []NodeUsageForecast `json:"items"`
+}
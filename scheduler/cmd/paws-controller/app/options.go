@@ -17,6 +17,11 @@ limitations under the License.
 package app
 
 import (
+	"time"
+
+	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	tu "gitee.com/openeuler/paws/scheduler/pkg/temporalutilization"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/controllers"
 	"github.com/spf13/pflag"
 )
 
@@ -31,6 +36,31 @@ type ServerRunOptions struct {
 	TimeoutMinutes              int
 	EvaluationResolutionSeconds int
 	PrometheusAddress           string
+	PromQueryCacheTTLSeconds    int
+	PromQueryCacheSize          int
+	NodeMetricLabel             string
+
+	OvercommitTuning                  bool
+	OvercommitTuningDryRun            bool
+	OvercommitTuningNodeSelector      string
+	OvercommitTuningIntervalMinutes   int
+	OvercommitTuningWindowHours       int
+	OvercommitTuningTargetUtilization float64
+	OvercommitTuningMinRatio          float64
+	OvercommitTuningMaxRatio          float64
+	OvercommitTuningMaxStep           float64
+
+	NodeBaseline                bool
+	NodeBaselineIntervalMinutes int
+	NodeBaselineWindowDays      int
+	NodeBaselinePercentile      float64
+
+	NodeUsageForecast                bool
+	NodeUsageForecastIntervalMinutes int
+	FallbackPolicy                   string
+	FallbackLimitsPercentage         int
+	MinConfidence                    int
+	MinSamplesPerHour                int
 }
 
 func NewServerRunOptions() *ServerRunOptions {
@@ -50,5 +80,30 @@ func (s *ServerRunOptions) addAllFlags() {
 	pflag.IntVar(&s.TimeoutMinutes, "timeoutMinutes", 1, "timeout for reconciling and pulling metrics.")
 	pflag.IntVar(&s.EvaluationResolutionSeconds, "evaluationResolutionSeconds", 300, "evaluation resolution seconds for prometheus, default to 5 mins resolution.")
 	pflag.StringVar(&s.PrometheusAddress, "prometheusAddress", "http://prometheus:9090", "Prometheus API address.")
+	pflag.IntVar(&s.PromQueryCacheTTLSeconds, "promQueryCacheTTLSeconds", 300, "how long identical prometheus range query results are reused across usage templates, 0 disables the cache.")
+	pflag.IntVar(&s.PromQueryCacheSize, "promQueryCacheSize", 128, "maximum number of prometheus range query results kept in the cache.")
+	pflag.StringVar(&s.NodeMetricLabel, "nodeMetricLabel", v1alpha1.DefaultNodeMetricLabel, "label of the usage metrics with the node name, used to normalize the usages by the node performance factor, empty disables the normalization.")
+
+	pflag.BoolVar(&s.OvercommitTuning, "overcommitTuning", false, "If tune the CPU overcommit ratio annotation of the nodes from their observed headroom.")
+	pflag.BoolVar(&s.OvercommitTuningDryRun, "overcommitTuningDryRun", false, "only log and record an event of the tuned overcommit ratios without annotating the nodes.")
+	pflag.StringVar(&s.OvercommitTuningNodeSelector, "overcommitTuningNodeSelector", "", "label selector of the nodes whose overcommit ratio is tuned, empty selects every node.")
+	pflag.IntVar(&s.OvercommitTuningIntervalMinutes, "overcommitTuningIntervalMinutes", int(controllers.DefaultOvercommitTuningInterval/time.Minute), "minutes between two tunings of the overcommit ratio of a node.")
+	pflag.IntVar(&s.OvercommitTuningWindowHours, "overcommitTuningWindowHours", int(controllers.DefaultOvercommitTuningWindow/time.Hour), "hours of history the actual peak usage of a node is taken over.")
+	pflag.Float64Var(&s.OvercommitTuningTargetUtilization, "overcommitTuningTargetUtilization", controllers.DefaultOvercommitTuningTargetUtilization, "fraction of the allocatable CPU a node is expected to peak at once overcommitted.")
+	pflag.Float64Var(&s.OvercommitTuningMinRatio, "overcommitTuningMinRatio", controllers.DefaultOvercommitTuningMinRatio, "minimum tuned overcommit ratio.")
+	pflag.Float64Var(&s.OvercommitTuningMaxRatio, "overcommitTuningMaxRatio", controllers.DefaultOvercommitTuningMaxRatio, "maximum tuned overcommit ratio.")
+	pflag.Float64Var(&s.OvercommitTuningMaxStep, "overcommitTuningMaxStep", controllers.DefaultOvercommitTuningMaxStep, "maximum change of the overcommit ratio of a node in a single tuning, 0 disables the limit.")
+
+	pflag.BoolVar(&s.NodeBaseline, "nodeBaseline", false, "If annotate the nodes with their hourly CPU usage not attributed to any pod container, added to the node forecasts by the scheduler.")
+	pflag.IntVar(&s.NodeBaselineIntervalMinutes, "nodeBaselineIntervalMinutes", int(controllers.DefaultNodeBaselineInterval/time.Minute), "minutes between two estimations of the baseline of a node.")
+	pflag.IntVar(&s.NodeBaselineWindowDays, "nodeBaselineWindowDays", v1alpha1.DefaultEvaluationWindowDays, "days of history the baseline of a node is estimated from.")
+	pflag.Float64Var(&s.NodeBaselinePercentile, "nodeBaselinePercentile", controllers.DefaultNodeBaselinePercentile, "percentile of the hourly usages taken as the baseline of a node.")
+
+	pflag.BoolVar(&s.NodeUsageForecast, "nodeUsageForecast", false, "If publish the hourly usage forecast of every node as a NodeUsageForecast.")
+	pflag.IntVar(&s.NodeUsageForecastIntervalMinutes, "nodeUsageForecastIntervalMinutes", int(controllers.DefaultNodeUsageForecastInterval/time.Minute), "minutes between two forecasts of a node.")
+	pflag.StringVar(&s.FallbackPolicy, "fallbackPolicy", string(tu.ByClassFallback), "usage assumed in the node forecasts for the pods without a usable usage template, the fallbackPolicy of the scheduler.")
+	pflag.IntVar(&s.FallbackLimitsPercentage, "fallbackLimitsPercentage", 0, "percentage of the limits assumed by the LimitsPercentage fallback policy, the fallbackLimitsPercentage of the scheduler.")
+	pflag.IntVar(&s.MinConfidence, "minConfidence", 0, "minimum confidence of a usage template to be used in the node forecasts, the minConfidence of the scheduler.")
+	pflag.IntVar(&s.MinSamplesPerHour, "minSamplesPerHour", 0, "minimum samples per hour of a usage template to be used in the node forecasts, the minSamplesPerHour of the scheduler.")
 
 }
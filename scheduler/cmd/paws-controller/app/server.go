@@ -18,10 +18,13 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	tu "gitee.com/openeuler/paws/scheduler/pkg/temporalutilization"
 	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/controllers"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -76,6 +79,10 @@ func Run(s *ServerRunOptions) error {
 		Client:   mgr.GetClient(),
 		Scheme:   mgr.GetScheme(),
 		Recorder: mgr.GetEventRecorderFor(controllerName),
+
+		PromQueryCacheTTL:  time.Duration(s.PromQueryCacheTTLSeconds) * time.Second,
+		PromQueryCacheSize: s.PromQueryCacheSize,
+		NodeMetricLabel:    s.NodeMetricLabel,
 	}).SetupWithManager(mgr, controller.Options{
 		MaxConcurrentReconciles: s.Workers}, time.Duration(s.TimeoutMinutes)*time.Minute,
 		time.Second*time.Duration(s.EvaluationResolutionSeconds), s.PrometheusAddress, runCtx); err != nil {
@@ -83,6 +90,45 @@ func Run(s *ServerRunOptions) error {
 		return err
 	}
 
+	if s.OvercommitTuning || s.NodeUsageForecast {
+		if err := controllers.IndexPodsByNodeName(mgr); err != nil {
+			setupLog.Error(err, "unable to index the pods by node name")
+			return err
+		}
+	}
+
+	if s.OvercommitTuning {
+		if err := setupOvercommitTuning(mgr, s, controllerName); err != nil {
+			setupLog.Error(err, "unable to create reconciler", "controller", "NodeOvercommit")
+			return err
+		}
+	}
+
+	if s.NodeBaseline {
+		if err := (&controllers.NodeBaselineReconciler{
+			Log:      ctrl.Log.WithName("node-baseline"),
+			Client:   mgr.GetClient(),
+			Recorder: mgr.GetEventRecorderFor(controllerName),
+
+			Interval:        time.Duration(s.NodeBaselineIntervalMinutes) * time.Minute,
+			Window:          time.Duration(s.NodeBaselineWindowDays) * 24 * time.Hour,
+			Resolution:      time.Second * time.Duration(s.EvaluationResolutionSeconds),
+			Timeout:         time.Duration(s.TimeoutMinutes) * time.Minute,
+			NodeMetricLabel: s.NodeMetricLabel,
+			Percentile:      s.NodeBaselinePercentile,
+		}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: s.Workers}, s.PrometheusAddress); err != nil {
+			setupLog.Error(err, "unable to create reconciler", "controller", "NodeBaseline")
+			return err
+		}
+	}
+
+	if s.NodeUsageForecast {
+		if err := setupNodeUsageForecast(mgr, s, controllerName); err != nil {
+			setupLog.Error(err, "unable to create reconciler", "controller", "NodeUsageForecast")
+			return err
+		}
+	}
+
 	setupLog.Info("Controller", "Options", s)
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -103,3 +149,65 @@ func Run(s *ServerRunOptions) error {
 
 	return nil
 }
+
+func setupOvercommitTuning(mgr ctrl.Manager, s *ServerRunOptions, controllerName string) error {
+	selector, err := labels.Parse(s.OvercommitTuningNodeSelector)
+	if err != nil {
+		return err
+	}
+
+	tuning := controllers.OvercommitTuning{
+		TargetUtilization: s.OvercommitTuningTargetUtilization,
+		MinRatio:          s.OvercommitTuningMinRatio,
+		MaxRatio:          s.OvercommitTuningMaxRatio,
+		MaxStep:           s.OvercommitTuningMaxStep,
+	}
+	if err := controllers.ValidateOvercommitTuning(tuning); err != nil {
+		return err
+	}
+
+	return (&controllers.NodeOvercommitReconciler{
+		Log:      ctrl.Log.WithName("overcommit-tuning"),
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor(controllerName),
+
+		NodeSelector:    selector,
+		Interval:        time.Duration(s.OvercommitTuningIntervalMinutes) * time.Minute,
+		Window:          time.Duration(s.OvercommitTuningWindowHours) * time.Hour,
+		Resolution:      time.Second * time.Duration(s.EvaluationResolutionSeconds),
+		Timeout:         time.Duration(s.TimeoutMinutes) * time.Minute,
+		NodeMetricLabel: s.NodeMetricLabel,
+		DryRun:          s.OvercommitTuningDryRun,
+		Tuning:          tuning,
+	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: s.Workers}, s.PrometheusAddress)
+}
+
+func setupNodeUsageForecast(mgr ctrl.Manager, s *ServerRunOptions, controllerName string) error {
+	fallback := tu.FallbackPolicy{
+		Mode:             tu.FallbackMode(s.FallbackPolicy),
+		LimitsPercentage: int32(s.FallbackLimitsPercentage),
+	}
+	if err := tu.ValidateFallbackPolicy(fallback.Mode, fallback.LimitsPercentage); err != nil {
+		return err
+	}
+
+	if s.MinConfidence < 0 || s.MinConfidence > 100 {
+		return fmt.Errorf("minimum confidence must be between zero and a hundred, got %d", s.MinConfidence)
+	}
+	if s.MinSamplesPerHour < 0 {
+		return fmt.Errorf("minimum samples per hour must not be negative, got %d", s.MinSamplesPerHour)
+	}
+
+	return (&controllers.NodeUsageForecastReconciler{
+		Log:      ctrl.Log.WithName("node-usage-forecast"),
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor(controllerName),
+
+		Interval: time.Duration(s.NodeUsageForecastIntervalMinutes) * time.Minute,
+		MinCoverage: tu.CoverageThresholds{
+			MinConfidence:     int32(s.MinConfidence),
+			MinSamplesPerHour: int32(s.MinSamplesPerHour),
+		},
+		Fallback: fallback,
+	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: s.Workers})
+}
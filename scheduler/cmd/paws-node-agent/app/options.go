@@ -0,0 +1,56 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"os"
+
+	"gitee.com/openeuler/paws/scheduler/pkg/nodeagent"
+	"github.com/spf13/pflag"
+)
+
+type ServerRunOptions struct {
+	MetricsAddr string
+	Kubeconfig  string
+	NodeName    string
+
+	ReferenceScore           float64
+	BenchmarkIntervalMinutes int
+	BenchmarkIterations      int64
+	BenchmarkRounds          int
+	BenchmarkPauseSeconds    int
+	Tolerance                float64
+}
+
+func NewServerRunOptions() *ServerRunOptions {
+	options := &ServerRunOptions{}
+	options.addAllFlags()
+	return options
+}
+
+func (s *ServerRunOptions) addAllFlags() {
+	pflag.StringVar(&s.MetricsAddr, "metricsAddr", ":8080", "Metrics server bind listen address.")
+	pflag.StringVar(&s.Kubeconfig, "kubeconfig", "", "Path to a kubeconfig, the in cluster config is used when empty.")
+	pflag.StringVar(&s.NodeName, "nodeName", os.Getenv("NODE_NAME"), "Name of the node the agent runs on, defaults to the NODE_NAME environment variable.")
+
+	pflag.Float64Var(&s.ReferenceScore, "referenceScore", 0, "benchmark score of a reference core, the node is only annotated with the performance factor when it is set.")
+	pflag.IntVar(&s.BenchmarkIntervalMinutes, "benchmarkIntervalMinutes", int(nodeagent.DefaultBenchmarkInterval.Minutes()), "minutes between two benchmarks, at least 10 minutes.")
+	pflag.Int64Var(&s.BenchmarkIterations, "benchmarkIterations", nodeagent.DefaultBenchmarkIterations, "iterations of a benchmark round, the same on every node.")
+	pflag.IntVar(&s.BenchmarkRounds, "benchmarkRounds", nodeagent.DefaultBenchmarkRounds, "rounds of a benchmark, the median round is kept.")
+	pflag.IntVar(&s.BenchmarkPauseSeconds, "benchmarkPauseSeconds", int(nodeagent.DefaultBenchmarkPause.Seconds()), "seconds to pause between two benchmark rounds.")
+	pflag.Float64Var(&s.Tolerance, "tolerance", nodeagent.DefaultTolerance, "relative change of the performance factor under which the node annotation is not updated.")
+}
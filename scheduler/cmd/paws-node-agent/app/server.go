@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gitee.com/openeuler/paws/scheduler/pkg/nodeagent"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func Run(s *ServerRunOptions) error {
+	if s.NodeName == "" {
+		return fmt.Errorf("expect the node name to be set with --nodeName or NODE_NAME")
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", s.Kubeconfig)
+	if err != nil {
+		klog.ErrorS(err, "unable to build the kubernetes client config")
+		return err
+	}
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		klog.ErrorS(err, "unable to create the kubernetes client")
+		return err
+	}
+
+	ctx := ctrl.SetupSignalHandler()
+
+	nodeagent.RegisterMetrics()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	server := &http.Server{Addr: s.MetricsAddr, Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			klog.ErrorS(err, "metrics server stopped")
+		}
+	}()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	agent := &nodeagent.Agent{
+		Client:         client,
+		NodeName:       s.NodeName,
+		ReferenceScore: s.ReferenceScore,
+		Interval:       time.Duration(s.BenchmarkIntervalMinutes) * time.Minute,
+		Tolerance:      s.Tolerance,
+		Benchmark: &nodeagent.Benchmark{
+			Iterations: s.BenchmarkIterations,
+			Rounds:     s.BenchmarkRounds,
+			Pause:      time.Duration(s.BenchmarkPauseSeconds) * time.Second,
+		},
+	}
+
+	klog.InfoS("Node agent", "Options", s)
+	agent.Run(ctx)
+
+	return nil
+}
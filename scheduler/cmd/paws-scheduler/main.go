@@ -22,6 +22,8 @@ import (
 
 	// Ensure scheme package is initialized.
 	_ "gitee.com/openeuler/paws/scheduler/apis/config/scheme"
+	// Register the usage templates in the client-go scheme, so the scheduler event recorder can reference them.
+	_ "gitee.com/openeuler/paws/scheduler/apis/scheduling/scheme"
 	tu "gitee.com/openeuler/paws/scheduler/pkg/temporalutilization"
 )
 
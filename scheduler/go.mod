@@ -6,6 +6,7 @@ require (
 	github.com/kedacore/keda/v2 v2.10.1
 	github.com/montanaflynn/stats v0.7.1
 	github.com/stretchr/testify v1.8.2
+	golang.org/x/sync v0.1.0
 	k8s.io/api v0.26.2
 	k8s.io/apimachinery v0.26.2
 	k8s.io/client-go v0.26.2
@@ -103,8 +104,7 @@ require (
 	golang.org/x/mod v0.8.0 // indirect
 	golang.org/x/net v0.8.0 // indirect
 	golang.org/x/oauth2 v0.6.0 // indirect
-	golang.org/x/sync v0.1.0 // indirect
-	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/sys v0.6.0
 	golang.org/x/term v0.6.0 // indirect
 	golang.org/x/text v0.8.0 // indirect
 	golang.org/x/time v0.3.0 // indirect
@@ -120,7 +120,7 @@ require (
 	k8s.io/apiserver v0.26.2 // indirect
 	k8s.io/autoscaler/vertical-pod-autoscaler v0.13.0
 	k8s.io/cloud-provider v0.26.2 // indirect
-	k8s.io/component-helpers v0.26.2 // indirect
+	k8s.io/component-helpers v0.26.2
 	k8s.io/csi-translation-lib v0.26.2 // indirect
 	k8s.io/dynamic-resource-allocation v0.26.2 // indirect
 	k8s.io/gengo v0.0.0-20221011193443-fad74ee6edd9 // indirect
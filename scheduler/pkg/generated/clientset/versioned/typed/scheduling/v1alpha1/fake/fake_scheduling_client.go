@@ -28,6 +28,10 @@ type FakeSchedulingV1alpha1 struct {
 	*testing.Fake
 }
 
+func (c *FakeSchedulingV1alpha1) NodeUsageForecasts() v1alpha1.NodeUsageForecastInterface {
+	return &FakeNodeUsageForecasts{c}
+}
+
 func (c *FakeSchedulingV1alpha1) UsageTemplates(namespace string) v1alpha1.UsageTemplateInterface {
 	return &FakeUsageTemplates{c, namespace}
 }
@@ -28,6 +28,7 @@ import (
 
 type SchedulingV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	NodeUsageForecastsGetter
 	UsageTemplatesGetter
 }
 
@@ -36,6 +37,10 @@ type SchedulingV1alpha1Client struct {
 	restClient rest.Interface
 }
 
+func (c *SchedulingV1alpha1Client) NodeUsageForecasts() NodeUsageForecastInterface {
+	return newNodeUsageForecasts(c)
+}
+
 func (c *SchedulingV1alpha1Client) UsageTemplates(namespace string) UsageTemplateInterface {
 	return newUsageTemplates(c, namespace)
 }
@@ -24,6 +24,8 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// NodeUsageForecasts returns a NodeUsageForecastInformer.
+	NodeUsageForecasts() NodeUsageForecastInformer
 	// UsageTemplates returns a UsageTemplateInformer.
 	UsageTemplates() UsageTemplateInformer
 }
@@ -39,6 +41,11 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// NodeUsageForecasts returns a NodeUsageForecastInformer.
+func (v *version) NodeUsageForecasts() NodeUsageForecastInformer {
+	return &nodeUsageForecastInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // UsageTemplates returns a UsageTemplateInformer.
 func (v *version) UsageTemplates() UsageTemplateInformer {
 	return &usageTemplateInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
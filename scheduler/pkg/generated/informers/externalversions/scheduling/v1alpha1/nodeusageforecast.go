@@ -0,0 +1,89 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	time "time"
+
+	schedulingv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	versioned "gitee.com/openeuler/paws/scheduler/pkg/generated/clientset/versioned"
+	internalinterfaces "gitee.com/openeuler/paws/scheduler/pkg/generated/informers/externalversions/internalinterfaces"
+	v1alpha1 "gitee.com/openeuler/paws/scheduler/pkg/generated/listers/scheduling/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// NodeUsageForecastInformer provides access to a shared informer and lister for
+// NodeUsageForecasts.
+type NodeUsageForecastInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.NodeUsageForecastLister
+}
+
+type nodeUsageForecastInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewNodeUsageForecastInformer constructs a new informer for NodeUsageForecast type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewNodeUsageForecastInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredNodeUsageForecastInformer(client, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredNodeUsageForecastInformer constructs a new informer for NodeUsageForecast type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredNodeUsageForecastInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.SchedulingV1alpha1().NodeUsageForecasts().List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.SchedulingV1alpha1().NodeUsageForecasts().Watch(context.TODO(), options)
+			},
+		},
+		&schedulingv1alpha1.NodeUsageForecast{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *nodeUsageForecastInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredNodeUsageForecastInformer(client, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *nodeUsageForecastInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&schedulingv1alpha1.NodeUsageForecast{}, f.defaultInformer)
+}
+
+func (f *nodeUsageForecastInformer) Lister() v1alpha1.NodeUsageForecastLister {
+	return v1alpha1.NewNodeUsageForecastLister(f.Informer().GetIndexer())
+}
@@ -18,6 +18,10 @@ limitations under the License.
 
 package v1alpha1
 
+// NodeUsageForecastListerExpansion allows custom methods to be added to
+// NodeUsageForecastLister.
+type NodeUsageForecastListerExpansion interface{}
+
 // UsageTemplateListerExpansion allows custom methods to be added to
 // UsageTemplateLister.
 type UsageTemplateListerExpansion interface{}
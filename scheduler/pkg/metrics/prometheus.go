@@ -54,17 +54,46 @@ var (
 	crdTotalsGaugeVec = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: DefaultControllerNamespace,
-			Subsystem: "resource",
+			Subsystem: "crd",
 			Name:      "totals",
 			Help:      "Total number of CRDs by type and namespace", // 加入 Help 信息
 		},
 		[]string{"type", "namespace"},
 	)
+
+	promQueryCacheHitsCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: DefaultControllerNamespace,
+			Subsystem: "prometheus_query_cache",
+			Name:      "hits_total",
+			Help:      "Total number of Prometheus range queries served from the query cache or coalesced with an in-flight query",
+		},
+	)
+
+	promQueryCacheMissesCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: DefaultControllerNamespace,
+			Subsystem: "prometheus_query_cache",
+			Name:      "misses_total",
+			Help:      "Total number of Prometheus range queries sent to Prometheus",
+		},
+	)
+
+	tunedOvercommitRatioGaugeVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: DefaultControllerNamespace,
+			Subsystem: "node",
+			Name:      "tuned_cpu_overcommit_ratio",
+			Help:      "CPU overcommit ratio computed for the node by the overcommit tuning, whether or not it was applied",
+		},
+		[]string{"node"},
+	)
 )
 
 // init executes all the metrics registration when the package is loaded
 func init() {
-	metrics.Registry.MustRegister(crdTotalsGaugeVec, resourceTotalsGaugeVec) // 注册所有 metrics
+	metrics.Registry.MustRegister(crdTotalsGaugeVec, resourceTotalsGaugeVec,
+		promQueryCacheHitsCounter, promQueryCacheMissesCounter, tunedOvercommitRatioGaugeVec) // 注册所有 metrics
 	log.Info("Prometheus metrics registered")
 }
 
@@ -110,3 +139,18 @@ func IncrementResourceTotal(resourceType string) {
 		log.Info("Incremented resource total", "type", resourceType)
 	}
 }
+
+// IncrementPromQueryCacheHit increments the number of Prometheus range queries answered without querying Prometheus
+func IncrementPromQueryCacheHit() {
+	promQueryCacheHitsCounter.Inc()
+}
+
+// IncrementPromQueryCacheMiss increments the number of Prometheus range queries sent to Prometheus
+func IncrementPromQueryCacheMiss() {
+	promQueryCacheMissesCounter.Inc()
+}
+
+// SetTunedOvercommitRatio records the CPU overcommit ratio computed for the node
+func SetTunedOvercommitRatio(node string, ratio float64) {
+	tunedOvercommitRatioGaugeVec.WithLabelValues(node).Set(ratio)
+}
@@ -0,0 +1,121 @@
+package nodeagent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/utils"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	DefaultBenchmarkInterval = 6 * time.Hour
+	// MinBenchmarkInterval bounds how often the benchmark runs whatever the configured interval
+	MinBenchmarkInterval = 10 * time.Minute
+	DefaultTolerance     = 0.05
+
+	benchmarkJitterFactor = 0.1
+)
+
+// Agent benchmarks the CPU of the node it runs on, on startup and periodically,
+// and publishes the performance factor of the node in the node annotations
+type Agent struct {
+	Client   kubernetes.Interface
+	NodeName string
+	// ReferenceScore is the benchmark score of a reference core,
+	// only the raw score is published when it is not set
+	ReferenceScore float64
+	// Interval is the period between two benchmarks
+	Interval time.Duration
+	// Tolerance is the relative change of the factor under which the node annotation is left unchanged
+	Tolerance float64
+	Benchmark *Benchmark
+}
+
+// Run benchmarks the node until the context is done
+func (a *Agent) Run(ctx context.Context) {
+	interval := a.Interval
+	if interval < MinBenchmarkInterval {
+		klog.InfoS("Benchmark interval too short, using the minimum interval", "interval", interval, "minInterval", MinBenchmarkInterval)
+		interval = MinBenchmarkInterval
+	}
+
+	klog.InfoS("Starting the node CPU benchmark", "node", a.NodeName, "interval", interval, "referenceScore", a.ReferenceScore)
+	wait.JitterUntilWithContext(ctx, a.runOnce, interval, benchmarkJitterFactor, true)
+}
+
+func (a *Agent) runOnce(ctx context.Context) {
+	score, err := a.Benchmark.Run(ctx)
+	if err != nil {
+		klog.ErrorS(err, "Unable to benchmark the node", "node", a.NodeName)
+		benchmarkFailures.WithLabelValues(a.NodeName).Inc()
+		return
+	}
+	benchmarkScore.WithLabelValues(a.NodeName).Set(score)
+	klog.V(3).InfoS("Benchmarked the node", "node", a.NodeName, "score", score)
+
+	if a.ReferenceScore <= 0 {
+		return
+	}
+
+	factor := PerformanceFactor(score, a.ReferenceScore)
+	performanceFactor.WithLabelValues(a.NodeName).Set(factor)
+	if err := a.annotate(ctx, factor); err != nil {
+		klog.ErrorS(err, "Unable to annotate the node performance factor", "node", a.NodeName, "factor", factor)
+		benchmarkFailures.WithLabelValues(a.NodeName).Inc()
+	}
+}
+
+// PerformanceFactor is the score relative to the reference score, rounded to two decimals
+// so that the noise of the benchmark does not show up in the annotation
+func PerformanceFactor(score, referenceScore float64) float64 {
+	return math.Round(score/referenceScore*100) / 100
+}
+
+// annotate sets the performance factor annotation of the node,
+// the annotation is not updated when the factor is within the tolerance of the current one
+func (a *Agent) annotate(ctx context.Context, factor float64) error {
+	if factor <= 0 {
+		return fmt.Errorf("expect a performance factor greater than zero, got %v", factor)
+	}
+
+	node, err := a.Client.CoreV1().Nodes().Get(ctx, a.NodeName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if _, ok := node.Annotations[v1alpha1.NodeCPUPerformanceFactorAnnotation]; ok {
+		current, err := utils.GetNodePerformanceFactor(node, v1.ResourceCPU.String())
+		if err == nil && math.Abs(factor-current) <= current*a.Tolerance {
+			klog.V(4).InfoS("Performance factor within tolerance, not updating the node", "node", a.NodeName, "factor", factor, "current", current)
+			return nil
+		}
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				v1alpha1.NodeCPUPerformanceFactorAnnotation: strconv.FormatFloat(factor, 'f', -1, 64),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = a.Client.CoreV1().Nodes().Patch(ctx, a.NodeName, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err == nil {
+		klog.InfoS("Annotated the node performance factor", "node", a.NodeName, "factor", factor)
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package nodeagent
+
+import (
+	"context"
+	"testing"
+
+	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+)
+
+func TestAgentAnnotate(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(st.MakeNode().Name("node-1").Obj())
+	agent := &Agent{
+		Client:    client,
+		NodeName:  "node-1",
+		Tolerance: DefaultTolerance,
+	}
+
+	annotation := func() string {
+		node, err := client.CoreV1().Nodes().Get(ctx, "node-1", metav1.GetOptions{})
+		assert.Nil(t, err)
+		return node.Annotations[v1alpha1.NodeCPUPerformanceFactorAnnotation]
+	}
+
+	assert.Equal(t, 1.25, PerformanceFactor(125_000_000.4, 100_000_000))
+
+	assert.Nil(t, agent.annotate(ctx, 1.25))
+	assert.Equal(t, "1.25", annotation())
+
+	// within the tolerance, the node is left unchanged
+	assert.Nil(t, agent.annotate(ctx, 1.29))
+	assert.Equal(t, "1.25", annotation())
+
+	assert.Nil(t, agent.annotate(ctx, 1.4))
+	assert.Equal(t, "1.4", annotation())
+
+	assert.NotNil(t, agent.annotate(ctx, 0))
+	assert.NotNil(t, (&Agent{Client: client, NodeName: "node-2"}).annotate(ctx, 1))
+}
+
+func TestBenchmarkRun(t *testing.T) {
+	b := &Benchmark{Iterations: 5_000_000, Rounds: 3}
+	score, err := b.Run(context.Background())
+	assert.Nil(t, err)
+	assert.Greater(t, score, float64(0))
+
+	_, err = (&Benchmark{}).Run(context.Background())
+	assert.NotNil(t, err)
+
+	// the workload is deterministic
+	assert.Equal(t, workload(1000), workload(1000))
+}
@@ -0,0 +1,113 @@
+package nodeagent
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"time"
+)
+
+const (
+	DefaultBenchmarkIterations = 50_000_000
+	DefaultBenchmarkRounds     = 5
+	DefaultBenchmarkPause      = 2 * time.Second
+)
+
+// sink keeps the result of the workload so that the compiler does not drop it
+var sink uint64
+
+// Benchmark is a short single threaded CPU micro-benchmark, every round runs the same fixed amount of work.
+// The rounds are timed in CPU time of the benchmark thread instead of wall time, so the score is not
+// affected by the CPU limit of the agent nor by the workloads preempting the benchmark
+type Benchmark struct {
+	// Iterations is the amount of work of a round
+	Iterations int64
+	// Rounds is the number of rounds, the median round is kept
+	Rounds int
+	// Pause is the sleep between two rounds to keep the benchmark from hogging a core
+	Pause time.Duration
+}
+
+func NewBenchmark() *Benchmark {
+	return &Benchmark{
+		Iterations: DefaultBenchmarkIterations,
+		Rounds:     DefaultBenchmarkRounds,
+		Pause:      DefaultBenchmarkPause,
+	}
+}
+
+type benchmarkResult struct {
+	score float64
+	err   error
+}
+
+// Run returns the score of the node, the number of iterations per CPU second of the median round
+func (b *Benchmark) Run(ctx context.Context) (float64, error) {
+	if b.Iterations <= 0 || b.Rounds <= 0 {
+		return 0, fmt.Errorf("expect iterations and rounds greater than zero, got %d and %d", b.Iterations, b.Rounds)
+	}
+
+	result := make(chan benchmarkResult, 1)
+	go func() {
+		// the CPU time is measured per thread, the thread is not unlocked so that
+		// it exits with the goroutine instead of running other goroutines at the lowered priority
+		runtime.LockOSThread()
+		lowerThreadPriority()
+		score, err := b.run(ctx)
+		result <- benchmarkResult{score: score, err: err}
+	}()
+
+	r := <-result
+	return r.score, r.err
+}
+
+func (b *Benchmark) run(ctx context.Context) (float64, error) {
+	scores := make([]float64, 0, b.Rounds)
+	for i := 0; i < b.Rounds; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			case <-time.After(b.Pause):
+			}
+		}
+
+		start, err := threadCPUTime()
+		if err != nil {
+			return 0, err
+		}
+		sink = workload(b.Iterations)
+		end, err := threadCPUTime()
+		if err != nil {
+			return 0, err
+		}
+
+		elapsed := end - start
+		if elapsed <= 0 {
+			return 0, fmt.Errorf("round %d took no measurable CPU time, increase the iterations", i)
+		}
+		scores = append(scores, float64(b.Iterations)/elapsed.Seconds())
+	}
+
+	sort.Float64s(scores)
+	return scores[len(scores)/2], nil
+}
+
+// workload is a deterministic mix of integer multiplications, shifts and branches
+// that fits in the registers, so it measures the core rather than the memory
+func workload(iterations int64) uint64 {
+	x := uint64(0x9E3779B97F4A7C15)
+	acc := uint64(0)
+	for i := int64(0); i < iterations; i++ {
+		x ^= x << 13
+		x ^= x >> 7
+		x ^= x << 17
+		if x&1 == 0 {
+			acc += x * 0xBF58476D1CE4E5B9
+		} else {
+			acc ^= x >> 3
+		}
+	}
+	return acc
+}
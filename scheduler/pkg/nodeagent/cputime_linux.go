@@ -0,0 +1,24 @@
+package nodeagent
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+)
+
+// threadCPUTime returns the CPU time consumed by the calling thread
+func threadCPUTime() (time.Duration, error) {
+	var usage unix.Rusage
+	if err := unix.Getrusage(unix.RUSAGE_THREAD, &usage); err != nil {
+		return 0, err
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), nil
+}
+
+// lowerThreadPriority runs the calling thread with the lowest priority so that the workloads preempt the benchmark
+func lowerThreadPriority() {
+	if err := unix.Setpriority(unix.PRIO_PROCESS, 0, 19); err != nil {
+		klog.V(3).InfoS("Unable to lower the benchmark priority", "err", err)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package nodeagent
+
+import (
+	"time"
+)
+
+var processStart = time.Now()
+
+// threadCPUTime falls back to the wall time on the platforms without a per thread CPU time
+func threadCPUTime() (time.Duration, error) {
+	return time.Since(processStart), nil
+}
+
+func lowerThreadPriority() {}
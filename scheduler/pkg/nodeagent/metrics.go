@@ -0,0 +1,47 @@
+package nodeagent
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "paws_node_agent"
+
+var (
+	benchmarkScore = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "cpu_benchmark_score",
+			Help:      "Raw CPU benchmark score of the node, in benchmark iterations per CPU second of a core",
+		},
+		[]string{"node"},
+	)
+
+	performanceFactor = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "cpu_performance_factor",
+			Help:      "CPU performance factor of the node relative to the reference score",
+		},
+		[]string{"node"},
+	)
+
+	benchmarkFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cpu_benchmark_failures_total",
+			Help:      "Number of CPU benchmarks or node annotations that failed",
+		},
+		[]string{"node"},
+	)
+
+	registerMetrics sync.Once
+)
+
+// RegisterMetrics registers the node agent metrics with the prometheus default registry
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		prometheus.MustRegister(benchmarkScore, performanceFactor, benchmarkFailures)
+	})
+}
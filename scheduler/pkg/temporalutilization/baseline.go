@@ -0,0 +1,92 @@
+package temporalutilization
+
+import (
+	"sync"
+
+	schedv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/utils"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// nodeBaseline is the parsed baseline usage annotations of a node
+type nodeBaseline struct {
+	// annotations are the baseline usage and performance factor annotations the usages were parsed from
+	annotations map[string]string
+	usages      map[string]*UsageTemplate
+}
+
+// nodeBaselines caches the baseline usages of the nodes parsed from their annotations by node name,
+// a node is parsed again when its annotations change
+type nodeBaselines struct {
+	sync.Map
+}
+
+// get returns the baseline usages of the node in reference millicores, the usages maintained by the controller
+// are in millicores of the node and are scaled by the performance factor of the node like the usage templates
+func (b *nodeBaselines) get(node *v1.Node, targetResources []string) map[string]*UsageTemplate {
+	// the performance factors are kept along so that the usages are scaled again when they change
+	annotations := make(map[string]string)
+	for _, resourceName := range targetResources {
+		if annotation, ok := schedv1alpha1.SupportedBaselineUsageAnnotation[resourceName]; ok {
+			if value, ok := node.Annotations[annotation]; ok {
+				annotations[annotation] = value
+				factorAnnotation := schedv1alpha1.SupportedPerformanceFactorAnnotation[resourceName]
+				annotations[factorAnnotation] = node.Annotations[factorAnnotation]
+			}
+		}
+	}
+
+	if len(annotations) == 0 {
+		b.Delete(node.Name)
+		return nil
+	}
+
+	if cached, ok := b.Load(node.Name); ok && sameAnnotations(cached.(*nodeBaseline).annotations, annotations) {
+		return cached.(*nodeBaseline).usages
+	}
+
+	baseline := &nodeBaseline{annotations: annotations, usages: make(map[string]*UsageTemplate)}
+	for _, resourceName := range targetResources {
+		value, ok := annotations[schedv1alpha1.SupportedBaselineUsageAnnotation[resourceName]]
+		if !ok {
+			continue
+		}
+
+		usage, err := utils.ParseHourlyUsage(value)
+		if err != nil {
+			klog.ErrorS(err, "Ignoring node baseline usage", "node", klog.KObj(node), "resource", resourceName)
+			continue
+		}
+
+		factor, _ := utils.GetNodePerformanceFactor(node, resourceName)
+		template := &UsageTemplate{
+			resource:    resourceName,
+			unit:        DefaultResourceUnitMap[resourceName],
+			weekDayHour: make(map[int16]float32, len(usage.Weekday)),
+			weekendHour: make(map[int16]float32, len(usage.Weekend)),
+		}
+		for hour, v := range usage.Weekday {
+			template.weekDayHour[hour] = float32(v * factor)
+		}
+		for hour, v := range usage.Weekend {
+			template.weekendHour[hour] = float32(v * factor)
+		}
+		baseline.usages[resourceName] = template
+	}
+
+	b.Store(node.Name, baseline)
+	return baseline.usages
+}
+
+func sameAnnotations(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package temporalutilization
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gitee.com/openeuler/paws/scheduler/apis/config/v1beta3"
+	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	testutils "gitee.com/openeuler/paws/scheduler/pkg/test/util"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	testClientSet "k8s.io/client-go/kubernetes/fake"
+	clientcache "k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+)
+
+func TestTemporalUtilizationScoringWithNodeBaseline(t *testing.T) {
+	nodeResources := map[v1.ResourceName]string{
+		v1.ResourceCPU: "1000m",
+	}
+	nodes := []*v1.Node{
+		st.MakeNode().Name("plain-1").Capacity(nodeResources).Obj(),
+		st.MakeNode().Name("logging-1").Capacity(nodeResources).Obj(),
+	}
+	// a host logging agent using 400m at every hour
+	sameBaseline := "weekday=" + strings.TrimSuffix(strings.Repeat("400,", 24), ",") +
+		";weekend=" + strings.TrimSuffix(strings.Repeat("400,", 24), ",")
+	nodes[1].Annotations = map[string]string{v1alpha1.NodeCPUBaselineUsageAnnotation: sameBaseline}
+
+	pod := st.MakePod().Name("pod-1").Namespace("default").Obj()
+	var scheduledPods []*v1.Pod
+	for _, node := range nodes {
+		scheduledPods = append(scheduledPods, st.MakePod().Namespace("default").Name("pod-"+node.Name).Node(node.Name).Labels(map[string]string{
+			v1alpha1.UsageTemplateLabelIdentifier: "test-crd-1",
+		}).Obj())
+	}
+	usageTemplates := []*v1alpha1.UsageTemplate{
+		testutils.MakeUsageTemplate("test-crd-1", "default", true, "BestEffort",
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(200)},
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(200)}, true),
+	}
+
+	mgr := newTestUsageEvaluationManager(nodes, pod, scheduledPods, usageTemplates)
+	for _, sp := range scheduledPods {
+		mgr.OnAdd(sp)
+	}
+
+	pl := &TemporalUtilization{
+		HotSpotThreshold: int32(v1beta3.DefaultHotSpotThreshold),
+		utMgr:            mgr,
+	}
+
+	state := framework.NewCycleState()
+	scores := make(map[string]int64)
+	for _, node := range nodes {
+		score, status := pl.Score(context.Background(), state, pod, node.Name)
+		assert.True(t, status.IsSuccess())
+		scores[node.Name] = score
+	}
+	assert.Greater(t, scores["plain-1"], scores["logging-1"])
+
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(nodes[1])
+	forecasts, _, err := obtainForecasts(mgr, nodeInfo, nodes[1].Name, nil, pl.SupportedTargetResources())
+	assert.NoError(t, err)
+	assert.Equal(t, float32(600), forecasts[v1.ResourceCPU.String()].weekDayHour[0])
+
+	// the baseline is in millicores of the node, scaled to reference millicores like the usage templates
+	nodes[1].Annotations[v1alpha1.NodeCPUPerformanceFactorAnnotation] = "1.5"
+	forecasts, _, err = obtainForecasts(mgr, nodeInfo, nodes[1].Name, nil, pl.SupportedTargetResources())
+	assert.NoError(t, err)
+	assert.Equal(t, float32(800), forecasts[v1.ResourceCPU.String()].weekendHour[23])
+
+	// an invalid baseline is ignored
+	nodes[1].Annotations[v1alpha1.NodeCPUBaselineUsageAnnotation] = "weekday=1,2"
+	forecasts, _, err = obtainForecasts(mgr, nodeInfo, nodes[1].Name, nil, pl.SupportedTargetResources())
+	assert.NoError(t, err)
+	assert.Equal(t, float32(200), forecasts[v1.ResourceCPU.String()].weekDayHour[0])
+}
+
+func TestNodeBaselinesEvictedOnNodeDelete(t *testing.T) {
+	baseline := "weekday=" + strings.TrimSuffix(strings.Repeat("400,", 24), ",") +
+		";weekend=" + strings.TrimSuffix(strings.Repeat("400,", 24), ",")
+	nodes := []*v1.Node{
+		st.MakeNode().Name("node-1").Obj(),
+		st.MakeNode().Name("node-2").Obj(),
+	}
+	for _, node := range nodes {
+		node.Annotations = map[string]string{v1alpha1.NodeCPUBaselineUsageAnnotation: baseline}
+	}
+
+	mgr := newTestUsageEvaluationManager(nodes, st.MakePod().Name("pod-1").Namespace("default").Obj(), nil, nil)
+	for _, node := range nodes {
+		assert.NotNil(t, mgr.baselines.get(node, supportedTargetResources()))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cs := testClientSet.NewSimpleClientset(nodes[0], nodes[1])
+	informerFactory := informers.NewSharedInformerFactory(cs, 0)
+	mgr.AddNodeEventHandler(informerFactory.Core().V1().Nodes().Informer())
+	informerFactory.Start(ctx.Done())
+	informerFactory.WaitForCacheSync(ctx.Done())
+
+	assert.NoError(t, cs.CoreV1().Nodes().Delete(ctx, "node-1", metav1.DeleteOptions{}))
+	assert.Eventually(t, func() bool {
+		_, ok := mgr.baselines.Load("node-1")
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+	_, ok := mgr.baselines.Load("node-2")
+	assert.True(t, ok)
+
+	// the deletions missed by the informer
+	mgr.onNodeDelete(clientcache.DeletedFinalStateUnknown{Key: "node-2", Obj: nodes[1]})
+	_, ok = mgr.baselines.Load("node-2")
+	assert.False(t, ok)
+}
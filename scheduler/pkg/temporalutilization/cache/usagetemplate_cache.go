@@ -1,12 +1,125 @@
 package cache
 
 import (
+	"fmt"
+	"strconv"
+	"sync"
+
 	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
-	"k8s.io/client-go/tools/record"
+	utevents "gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/events"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/klog/v2"
 )
 
+// Usage is a parsed hourly usage of a usage template
+type Usage struct {
+	Hour      int32
+	IsWeekday bool
+	Value     float32
+}
+
+// UsageTemplateCache holds the historical usages of a usage template parsed from its status
 type UsageTemplateCache struct {
 	Object           *v1alpha1.UsageTemplate
 	ObjectGeneration int64
-	Recorder         record.EventRecorder
+	// ObjectResourceVersion is the resource version the usages were parsed from,
+	// the historical usages are in the status which does not change the generation
+	ObjectResourceVersion string
+	// Usages are the parsed historical usages by resource
+	Usages map[string][]Usage
+}
+
+// UsageTemplateStore caches the parsed usage templates by namespace/name
+type UsageTemplateStore struct {
+	sync.RWMutex
+	templates map[string]*UsageTemplateCache
+	// Recorder reports the usage template parse errors, it is optional
+	Recorder events.EventRecorder
+}
+
+func NewUsageTemplateStore() *UsageTemplateStore {
+	return &UsageTemplateStore{
+		templates: make(map[string]*UsageTemplateCache),
+	}
+}
+
+// Get returns the parsed usage template, the usage template is parsed when it is not cached
+// or cached at another resource version
+func (s *UsageTemplateStore) Get(ut *v1alpha1.UsageTemplate) *UsageTemplateCache {
+	if ut == nil {
+		return nil
+	}
+
+	s.RLock()
+	cached, ok := s.templates[key(ut)]
+	s.RUnlock()
+	if ok && cached.ObjectResourceVersion == ut.ResourceVersion {
+		return cached
+	}
+
+	return s.Set(ut)
+}
+
+// Set parses and caches the usage template
+func (s *UsageTemplateStore) Set(ut *v1alpha1.UsageTemplate) *UsageTemplateCache {
+	cached := &UsageTemplateCache{
+		Object:                ut,
+		ObjectGeneration:      ut.Generation,
+		ObjectResourceVersion: ut.ResourceVersion,
+		Usages:                s.parseUsages(ut),
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.templates[key(ut)] = cached
+	return cached
+}
+
+// Delete drops the usage template from the cache
+func (s *UsageTemplateStore) Delete(ut *v1alpha1.UsageTemplate) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.templates, key(ut))
+}
+
+// parseUsages parses the historical usage values, the values that cannot be parsed are skipped
+func (s *UsageTemplateStore) parseUsages(ut *v1alpha1.UsageTemplate) map[string][]Usage {
+	results := make(map[string][]Usage)
+	if ut.Status.HistoricalUsage == nil {
+		return results
+	}
+
+	invalid := 0
+	var lastErr error
+	for _, item := range ut.Status.HistoricalUsage.Items {
+		for _, sample := range item.Usages {
+			v, err := strconv.ParseFloat(sample.Value, 64)
+			if err != nil {
+				invalid++
+				lastErr = err
+				continue
+			}
+
+			results[item.Resource] = append(results[item.Resource], Usage{
+				Hour:      sample.Hour,
+				IsWeekday: sample.IsWeekday,
+				Value:     float32(v),
+			})
+		}
+	}
+
+	if invalid > 0 {
+		msg := fmt.Sprintf("skipped %d historical usages that cannot be parsed: %v", invalid, lastErr)
+		klog.ErrorS(lastErr, "cannot parse historical usages", "usageTemplate", klog.KObj(ut), "invalid", invalid)
+		if s.Recorder != nil {
+			s.Recorder.Eventf(ut, nil, corev1.EventTypeWarning, utevents.ParseFailed, "Parse", msg)
+		}
+	}
+
+	return results
+}
+
+func key(ut *v1alpha1.UsageTemplate) string {
+	return fmt.Sprintf("%v/%v", ut.Namespace, ut.Name)
 }
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	// registers the usage templates in the client-go scheme, as the scheduler does
+	_ "gitee.com/openeuler/paws/scheduler/apis/scheduling/scheme"
+	testutils "gitee.com/openeuler/paws/scheduler/pkg/test/util"
+	"github.com/stretchr/testify/assert"
+	eventsv1 "k8s.io/api/events/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+)
+
+func TestUsageTemplateStore(t *testing.T) {
+	ut := testutils.MakeUsageTemplate("test-crd-1", "default", true, "BestEffort",
+		map[string]map[int]float32{"cpu": {0: 100, 1: 200}},
+		map[string]map[int]float32{}, true)
+	ut.ResourceVersion = "1"
+
+	recorder := events.NewFakeRecorder(10)
+	store := NewUsageTemplateStore()
+	store.Recorder = recorder
+
+	parsed := store.Get(ut)
+	assert.ElementsMatch(t, []Usage{
+		{Hour: 0, IsWeekday: true, Value: 100},
+		{Hour: 1, IsWeekday: true, Value: 200},
+	}, parsed.Usages["cpu"])
+
+	// the same resource version is not parsed again
+	assert.Same(t, parsed, store.Get(ut))
+
+	// a new resource version is parsed again, the invalid values are skipped and reported
+	updated := ut.DeepCopy()
+	updated.ResourceVersion = "2"
+	updated.Status.HistoricalUsage.Items[0].Usages[0].Value = "not-a-number"
+	reparsed := store.Get(updated)
+	assert.NotSame(t, parsed, reparsed)
+	assert.Len(t, reparsed.Usages["cpu"], 1)
+	assert.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "UsageTemplateParseFailed")
+
+	store.Delete(updated)
+	assert.NotSame(t, reparsed, store.Get(updated))
+}
+
+func TestUsageTemplateStoreEventReference(t *testing.T) {
+	ut := testutils.MakeUsageTemplate("test-crd-1", "default", true, "BestEffort",
+		map[string]map[int]float32{"cpu": {0: 100}},
+		map[string]map[int]float32{}, true)
+	ut.ResourceVersion = "1"
+	ut.Status.HistoricalUsage.Items[0].Usages[0].Value = "not-a-number"
+
+	// the scheduler recorder references the objects through the client-go scheme
+	broadcaster := events.NewBroadcaster(&events.EventSinkImpl{Interface: fake.NewSimpleClientset().EventsV1()})
+	defer broadcaster.Shutdown()
+	recorded := make(chan *eventsv1.Event, 1)
+	stop, err := broadcaster.StartEventWatcher(func(obj runtime.Object) {
+		if event, ok := obj.(*eventsv1.Event); ok {
+			recorded <- event
+		}
+	})
+	assert.NoError(t, err)
+	defer stop()
+
+	store := NewUsageTemplateStore()
+	store.Recorder = broadcaster.NewRecorder(scheme.Scheme, "paws-scheduler")
+	store.Get(ut)
+
+	select {
+	case event := <-recorded:
+		assert.Equal(t, "UsageTemplateParseFailed", event.Reason)
+		assert.Equal(t, "UsageTemplate", event.Regarding.Kind)
+		assert.Equal(t, "scheduling.x-k8s.io/v1alpha1", event.Regarding.APIVersion)
+		assert.Equal(t, "test-crd-1", event.Regarding.Name)
+		assert.Equal(t, "default", event.Namespace)
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("no event recorded for the usage template")
+	}
+}
@@ -46,6 +46,14 @@ type UsageTemplateReconciler struct {
 	Scheme  *runtime.Scheme
 	Workers int
 
+	// PromQueryCacheTTL and PromQueryCacheSize bound the Prometheus range query cache shared across UsageTemplates
+	PromQueryCacheTTL  time.Duration
+	PromQueryCacheSize int
+
+	// NodeMetricLabel is the label of the usage timeseries with the node name, used to normalize
+	// the usages by the node performance factor
+	NodeMetricLabel string
+
 	UsageEvaluator            *evaluation.UsageEvaluator
 	usageTemplatesGenerations *sync.Map
 }
@@ -56,7 +64,7 @@ func (r *UsageTemplateReconciler) SetupWithManager(mgr ctrl.Manager, options con
 
 	r.usageTemplatesGenerations = &sync.Map{}
 
-	r.UsageEvaluator, err = evaluation.NewUsageEvaluator(mgr.GetClient(), mgr.GetScheme(), evaluationResolution, globalHTTPTimeout, r.Recorder, prometheusAddress)
+	r.UsageEvaluator, err = evaluation.NewUsageEvaluator(mgr.GetClient(), mgr.GetScheme(), evaluationResolution, globalHTTPTimeout, r.Recorder, prometheusAddress, r.PromQueryCacheTTL, r.PromQueryCacheSize, r.NodeMetricLabel)
 	if err != nil {
 		r.Log.Error(err, "Unable to create UsageEvaluator")
 		return err
@@ -152,6 +160,10 @@ func (r *UsageTemplateReconciler) reconcileUsageTemplate(ctx context.Context, ut
 		return msg, err
 	}
 
+	if msg, err := r.validateJoinMode(ut); err != nil {
+		return msg, err
+	}
+
 	// Check object generation
 	specChanged, err := r.usageTemplateGenerationChanged(ut)
 	if err != nil {
@@ -197,6 +209,21 @@ func (r *UsageTemplateReconciler) validateEvaluationPeriods(ut *schedv1alpha1.Us
 	return "", nil
 }
 
+func (r *UsageTemplateReconciler) validateJoinMode(ut *schedv1alpha1.UsageTemplate) (string, error) {
+	switch ut.Spec.JoinMode {
+	case "", schedv1alpha1.CAdvisorLabelsJoinMode:
+		return "", nil
+	case schedv1alpha1.KubeStateMetricsJoinMode:
+		if err := evaluation.ValidateKubeStateMetricsJoin(&ut.Spec); err != nil {
+			return "KubeStateMetrics join misconfigured", err
+		}
+		return "", nil
+	default:
+		return "JoinMode not supported", fmt.Errorf("join mode %s not supported, expect one of [%s, %s]", ut.Spec.JoinMode,
+			schedv1alpha1.CAdvisorLabelsJoinMode, schedv1alpha1.KubeStateMetricsJoinMode)
+	}
+}
+
 func (r *UsageTemplateReconciler) usageTemplateGenerationChanged(ut *schedv1alpha1.UsageTemplate) (bool, error) {
 	key, err := cache.MetaNamespaceKeyFunc(ut)
 	if err != nil {
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	schedv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/evaluation"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/events"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/utils"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	DefaultNodeBaselineInterval   = 6 * time.Hour
+	DefaultNodeBaselinePercentile = 0.95
+)
+
+// NodeBaselineReconciler periodically estimates the hourly CPU usage of each node not attributed to any
+// pod container, i.e. the usage of the root cgroup less the usage of the containers, and annotates the node with it
+type NodeBaselineReconciler struct {
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	client.Client
+
+	// Interval is the time between two estimations of the baseline of a node
+	Interval time.Duration
+	// Window is the history the baseline is estimated from
+	Window time.Duration
+	// Resolution is the step of the usage query
+	Resolution time.Duration
+	// Timeout bounds the Prometheus queries
+	Timeout time.Duration
+	// NodeMetricLabel is the label of the usage timeseries with the node name
+	NodeMetricLabel string
+	// Percentile of the hourly usages taken as the baseline
+	Percentile float64
+
+	promClient *evaluation.PromClient
+	// estimatedAt is the time of the last estimation by node name, the nodes are updated in between
+	// by the kubelet heartbeats and the baseline patches
+	estimatedAt sync.Map
+}
+
+// SetupWithManager starts a new controller estimating the baseline of the nodes managed by the passed Manager instance.
+func (r *NodeBaselineReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options, prometheusAddress string) error {
+	if r.NodeMetricLabel == "" {
+		return fmt.Errorf("node metric label is required to estimate the node baselines")
+	}
+
+	if r.Percentile <= 0 || r.Percentile > 1 {
+		return fmt.Errorf("node baseline percentile must be in (0, 1], got %v", r.Percentile)
+	}
+
+	var err error
+	r.promClient, err = evaluation.NewPromClient(prometheusAddress, 0, 0)
+	if err != nil {
+		r.Log.Error(err, "Unable to create prometheus client", "PromAddress", prometheusAddress)
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("node-baseline").
+		WithOptions(options).
+		// the node status is updated by the kubelet heartbeats, only the changes of the spec and annotations matter
+		For(&v1.Node{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;patch
+
+func (r *NodeBaselineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	node := &v1.Node{}
+	if err := r.Get(ctx, req.NamespacedName, node); err != nil {
+		if apierrs.IsNotFound(err) {
+			log.V(5).Info("Node not found")
+			r.estimatedAt.Delete(req.Name)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if node.DeletionTimestamp != nil {
+		r.estimatedAt.Delete(node.Name)
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+	if estimatedAt, ok := r.estimatedAt.Load(node.Name); ok {
+		if wait := estimatedAt.(time.Time).Add(r.Interval).Sub(now); wait > 0 {
+			log.V(5).Info("Node baseline estimated recently", "node", node.Name, "requeueAfter", wait)
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	if err := r.estimateBaseline(ctx, log, node); err != nil {
+		log.Error(err, "Unable to estimate the node baseline", "node", node.Name)
+		r.Recorder.Event(node, v1.EventTypeWarning, events.NodeBaselineFailed, err.Error())
+	} else {
+		r.estimatedAt.Store(node.Name, now)
+	}
+
+	return ctrl.Result{RequeueAfter: r.Interval}, nil
+}
+
+func (r *NodeBaselineReconciler) estimateBaseline(ctx context.Context, log logr.Logger, node *v1.Node) error {
+	now := time.Now().UTC()
+	values, err := r.promClient.FetchQueryRange(ctx, nodeBaselineQuery(r.NodeMetricLabel, node.Name),
+		r.Timeout, now.Add(-r.Window), now, r.Resolution, log)
+	if err != nil {
+		return err
+	}
+
+	resourceName := v1.ResourceCPU.String()
+	baseline, err := evaluation.EstimateHourlyUsage(values, r.Percentile,
+		schedv1alpha1.SupportedResourceMetricScalingFactor[resourceName], now)
+	if err != nil {
+		return err
+	}
+
+	if len(baseline.Weekday) == 0 && len(baseline.Weekend) == 0 {
+		log.V(4).Info("No usage to estimate the node baseline from", "node", node.Name)
+		return nil
+	}
+
+	annotation := schedv1alpha1.SupportedBaselineUsageAnnotation[resourceName]
+	value := utils.FormatHourlyUsage(baseline)
+	if node.Annotations[annotation] == value {
+		return nil
+	}
+
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, annotation, value))
+	if err := r.Patch(ctx, node, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return err
+	}
+
+	log.V(3).Info("Updated the node baseline", "node", node.Name, "baseline", value)
+	return nil
+}
+
+// nodeBaselineQuery builds the query of the CPU usage of the node not attributed to any container, e.g.
+// clamp_min(sum(rate(container_cpu_usage_seconds_total{id="/",node="node-1"}[2m]))
+// - (sum(rate(container_cpu_usage_seconds_total{container!="",node="node-1"}[2m])) or vector(0)), 0)
+func nodeBaselineQuery(nodeLabel, nodeName string) string {
+	resourceName := v1.ResourceCPU.String()
+	metricLabel := schedv1alpha1.SupportedResourcesMetricLabel[resourceName]
+	nodeFilter := fmt.Sprintf("%s=%q", nodeLabel, nodeName)
+
+	usage := func(filters ...string) string {
+		return fmt.Sprintf("sum(%s(%s{%s}[%s]))", schedv1alpha1.SupportedResourcesRangeMethod[resourceName],
+			metricLabel, strings.Join(filters, ","), schedv1alpha1.SupportedResourcesRateTimeWindow[resourceName])
+	}
+
+	containerFilters := append([]string{}, schedv1alpha1.SupportedMetricLabelFilters[metricLabel]...)
+	containerFilters = append(containerFilters, nodeFilter)
+
+	return fmt.Sprintf("clamp_min(%s - (%s or vector(0)), 0)", usage(`id="/"`, nodeFilter), usage(containerFilters...))
+}
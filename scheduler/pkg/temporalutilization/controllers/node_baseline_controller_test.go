@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+
+	schedv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/evaluation"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/utils"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestNodeBaselineQuery(t *testing.T) {
+	assert.Equal(t, `clamp_min(sum(rate(container_cpu_usage_seconds_total{id="/",node="node-1"}[2m])) - `+
+		`(sum(rate(container_cpu_usage_seconds_total{container!="",node="node-1"}[2m])) or vector(0)), 0)`,
+		nodeBaselineQuery("node", "node-1"))
+}
+
+// newTestBaselineServer serves a sample of the given usage in cores at sampledAt, none when the usage is negative
+func newTestBaselineServer(t *testing.T, requests *int32, usage float64, sampledAt time.Time) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		result := "[]"
+		if usage >= 0 {
+			result = fmt.Sprintf(`[{"metric":{"node":"node-1"},"values":[[%d,"%v"]]}]`, sampledAt.Unix(), usage)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":%s}}`, result); err != nil {
+			t.Errorf("unable to write response: %v", err)
+		}
+	}))
+}
+
+func newTestBaselineReconciler(t *testing.T, c *testNodeClient, server *httptest.Server) *NodeBaselineReconciler {
+	promClient, err := evaluation.NewPromClient(server.URL, 0, 0)
+	assert.NoError(t, err)
+
+	return &NodeBaselineReconciler{
+		Client:          c,
+		Recorder:        record.NewFakeRecorder(10),
+		Interval:        6 * time.Hour,
+		Window:          14 * 24 * time.Hour,
+		Resolution:      5 * time.Minute,
+		Timeout:         time.Second,
+		NodeMetricLabel: "node",
+		Percentile:      DefaultNodeBaselinePercentile,
+		promClient:      promClient,
+	}
+}
+
+func TestNodeBaselineEstimate(t *testing.T) {
+	sampledAt := time.Now().UTC().Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		usage         float64
+		annotation    string
+		expectPatched bool
+	}{
+		{name: "annotates the node with the baseline", usage: 0.4, expectPatched: true},
+		{name: "no usage, the node is left as is", usage: -1},
+		{name: "no usage, the previous baseline is kept", usage: -1, annotation: "weekday=1;weekend="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := newTestBaselineServer(t, &requests, tt.usage, sampledAt)
+			defer server.Close()
+
+			node := st.MakeNode().Name("node-1").Obj()
+			if tt.annotation != "" {
+				node.Annotations = map[string]string{schedv1alpha1.NodeCPUBaselineUsageAnnotation: tt.annotation}
+			}
+			c := &testNodeClient{node: node}
+			r := newTestBaselineReconciler(t, c, server)
+
+			assert.NoError(t, r.estimateBaseline(context.Background(), logr.Discard(), node.DeepCopy()))
+			assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+			if !tt.expectPatched {
+				assert.Empty(t, c.patches)
+				assert.Equal(t, tt.annotation, c.node.Annotations[schedv1alpha1.NodeCPUBaselineUsageAnnotation])
+				return
+			}
+
+			assert.Len(t, c.patches, 1)
+			baseline, err := utils.ParseHourlyUsage(c.patches[0][schedv1alpha1.NodeCPUBaselineUsageAnnotation])
+			assert.NoError(t, err)
+			hours := baseline.Weekday
+			if sampledAt.Weekday() == time.Saturday || sampledAt.Weekday() == time.Sunday {
+				hours = baseline.Weekend
+			}
+			// 0.4 core in millicores, up to the precision of the histogram buckets
+			assert.Len(t, hours, 1)
+			assert.InDelta(t, 400, hours[int16(sampledAt.Hour())], 400*0.1)
+
+			// estimated again from the same usage, the annotation is not patched again
+			assert.NoError(t, r.estimateBaseline(context.Background(), logr.Discard(), c.node.DeepCopy()))
+			assert.Len(t, c.patches, 1)
+		})
+	}
+}
+
+func TestNodeBaselineReconcileInterval(t *testing.T) {
+	var requests int32
+	server := newTestBaselineServer(t, &requests, 0.4, time.Now().UTC().Add(-time.Hour))
+	defer server.Close()
+
+	c := &testNodeClient{node: st.MakeNode().Name("node-1").Obj()}
+	r := newTestBaselineReconciler(t, c, server)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "node-1"}}
+
+	result, err := r.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 6*time.Hour, result.RequeueAfter)
+	assert.Len(t, c.patches, 1)
+
+	// the baseline patch updates the node, it is not estimated again before the interval
+	result, err = r.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Greater(t, result.RequeueAfter, 5*time.Hour)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	r.estimatedAt.Store("node-1", time.Now().Add(-7*time.Hour))
+	_, err = r.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
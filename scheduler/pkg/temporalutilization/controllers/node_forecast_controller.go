@@ -0,0 +1,351 @@
+package controllers
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	schedv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	tu "gitee.com/openeuler/paws/scheduler/pkg/temporalutilization"
+	utcache "gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/cache"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/events"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/utils"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	DefaultNodeUsageForecastInterval = 15 * time.Minute
+
+	hoursInADay = 24
+)
+
+// NodeUsageForecastReconciler periodically materializes the hourly usage forecast of each node,
+// aggregated from the usage templates of its pods and its baseline, as a NodeUsageForecast named after the node
+type NodeUsageForecastReconciler struct {
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	client.Client
+
+	// Interval is the time between two forecasts of a node
+	Interval time.Duration
+	// MinCoverage and Fallback are the ones of the scheduler, for the pods without a usable usage template
+	// to be forecast with the usages the scheduler assumes for them
+	MinCoverage tu.CoverageThresholds
+	Fallback    tu.FallbackPolicy
+
+	templates *utcache.UsageTemplateStore
+}
+
+// SetupWithManager starts a new controller forecasting the nodes managed by the passed Manager instance,
+// the pods are expected to be indexed by IndexPodsByNodeName.
+func (r *NodeUsageForecastReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	r.templates = utcache.NewUsageTemplateStore()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("node-usage-forecast").
+		WithOptions(options).
+		// the node status is updated by the kubelet heartbeats, only the changes of the spec and annotations matter
+		For(&v1.Node{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		Owns(&schedv1alpha1.NodeUsageForecast{}).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=scheduling.x-k8s.io,resources=usagetemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=scheduling.x-k8s.io,resources=nodeusageforecasts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=scheduling.x-k8s.io,resources=nodeusageforecasts/status,verbs=get;update;patch
+
+func (r *NodeUsageForecastReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	node := &v1.Node{}
+	if err := r.Get(ctx, req.NamespacedName, node); err != nil {
+		if apierrs.IsNotFound(err) {
+			// the forecast is garbage collected with the node
+			log.V(5).Info("Node not found")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if node.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.updateForecast(ctx, log, node); err != nil {
+		log.Error(err, "Unable to update the node usage forecast", "node", node.Name)
+		r.Recorder.Event(node, v1.EventTypeWarning, events.NodeUsageForecastFailed, err.Error())
+	}
+
+	return ctrl.Result{RequeueAfter: r.Interval}, nil
+}
+
+func (r *NodeUsageForecastReconciler) updateForecast(ctx context.Context, log logr.Logger, node *v1.Node) error {
+	podList := &v1.PodList{}
+	if err := r.List(ctx, podList, client.MatchingFields{podNodeNameField: node.Name}); err != nil {
+		return err
+	}
+
+	pods := make([]podTemplate, 0, len(podList.Items))
+	fallbacks := make(map[string]namespaceFallback)
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+
+		template, err := r.usageTemplate(ctx, pod)
+		if err != nil {
+			return err
+		}
+
+		fallback, ok := fallbacks[pod.Namespace]
+		if !ok {
+			if fallback, err = r.namespaceFallback(ctx, pod.Namespace); err != nil {
+				return err
+			}
+			fallbacks[pod.Namespace] = fallback
+		}
+		pods = append(pods, podTemplate{pod: pod, template: template, fallback: fallback})
+	}
+
+	status, err := nodeUsageForecast(node, pods, r.MinCoverage, time.Now())
+	if err != nil {
+		return err
+	}
+
+	forecast := &schedv1alpha1.NodeUsageForecast{}
+	err = r.Get(ctx, types.NamespacedName{Name: node.Name}, forecast)
+	switch {
+	case apierrs.IsNotFound(err):
+		forecast = &schedv1alpha1.NodeUsageForecast{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            node.Name,
+				OwnerReferences: []metav1.OwnerReference{nodeOwnerReference(node)},
+			},
+		}
+		if err := r.Create(ctx, forecast); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case equality.Semantic.DeepEqual(forecast.Status.Resources, status.Resources) &&
+		equality.Semantic.DeepEqual(forecast.Status.Templates, status.Templates) &&
+		forecast.Status.PodsWithoutTemplate == status.PodsWithoutTemplate:
+		return nil
+	}
+
+	status.LastUpdateTime = metav1.Now()
+	forecast.Status = status
+	if err := r.Status().Update(ctx, forecast); err != nil {
+		return err
+	}
+
+	log.V(4).Info("Updated the node usage forecast", "node", node.Name, "templates", len(status.Templates),
+		"podsWithoutTemplate", status.PodsWithoutTemplate)
+	return nil
+}
+
+// usageTemplate returns the parsed usage template of the pod, nil when the pod has no enabled usage template
+func (r *NodeUsageForecastReconciler) usageTemplate(ctx context.Context, pod *v1.Pod) (*utcache.UsageTemplateCache, error) {
+	name := utils.GetUsageTemplateLabel(pod)
+	if name == "" {
+		return nil, nil
+	}
+
+	ut := &schedv1alpha1.UsageTemplate{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: name}, ut); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !ut.Spec.Enabled {
+		return nil, nil
+	}
+
+	return r.templates.Get(ut), nil
+}
+
+// namespaceFallback returns the fallback policy of the scheduler for the pods of the namespace,
+// along with the usage templates of the namespace when they are aggregated
+func (r *NodeUsageForecastReconciler) namespaceFallback(ctx context.Context, namespace string) (namespaceFallback, error) {
+	fallback := namespaceFallback{policy: r.Fallback}
+
+	ns := &v1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err == nil {
+		fallback.policy = tu.NamespaceFallbackPolicy(r.Fallback, ns)
+	} else if !apierrs.IsNotFound(err) {
+		return fallback, err
+	}
+
+	if fallback.policy.Mode != tu.NamespaceAggregateFallback {
+		return fallback, nil
+	}
+
+	uts := &schedv1alpha1.UsageTemplateList{}
+	if err := r.List(ctx, uts, client.InNamespace(namespace)); err != nil {
+		return fallback, err
+	}
+	for i := range uts.Items {
+		fallback.templates = append(fallback.templates, r.templates.Get(&uts.Items[i]))
+	}
+
+	return fallback, nil
+}
+
+// nodeOwnerReference makes the node the controller of its forecast, without blocking the deletion of the node
+func nodeOwnerReference(node *v1.Node) metav1.OwnerReference {
+	isController := true
+	return metav1.OwnerReference{
+		APIVersion: v1.SchemeGroupVersion.String(),
+		Kind:       "Node",
+		Name:       node.Name,
+		UID:        node.UID,
+		Controller: &isController,
+	}
+}
+
+// namespaceFallback is the fallback policy of a namespace and its parsed usage templates, only listed in NamespaceAggregate mode
+type namespaceFallback struct {
+	policy    tu.FallbackPolicy
+	templates []*utcache.UsageTemplateCache
+}
+
+// podTemplate is a pod on the node, its parsed usage template, nil without an enabled one,
+// and the fallback policy of its namespace
+type podTemplate struct {
+	pod      *v1.Pod
+	template *utcache.UsageTemplateCache
+	fallback namespaceFallback
+}
+
+// nodeUsageForecast sums the hourly usages of the pods on the node and the baseline of the node as the scheduler forecasts them.
+// A pod without a usable usage template describing a resource is assumed to use what the fallback policy of the scheduler assumes.
+// The CPU is in reference millicores, i.e. the baseline and the allocatable of the node are scaled by its performance factor
+func nodeUsageForecast(node *v1.Node, pods []podTemplate, thresholds tu.CoverageThresholds, now time.Time) (schedv1alpha1.NodeUsageForecastStatus, error) {
+	status := schedv1alpha1.NodeUsageForecastStatus{}
+
+	resourceNames := schedv1alpha1.GetSupportedResources()
+	sort.Strings(resourceNames)
+
+	// the weekday and the weekend usages by resource
+	weekday := make(map[string][]float64, len(resourceNames))
+	weekend := make(map[string][]float64, len(resourceNames))
+	for _, resourceName := range resourceNames {
+		weekday[resourceName], weekend[resourceName] = make([]float64, hoursInADay), make([]float64, hoursInADay)
+	}
+
+	templates := make(map[types.NamespacedName]int32)
+	for _, pt := range pods {
+		templated := false
+		for _, resourceName := range resourceNames {
+			weekdayUsage, weekendUsage, ok := tu.PodHourlyUsage(pt.template, pt.pod, resourceName, thresholds, now)
+			if ok {
+				templated = true
+			} else {
+				var err error
+				weekdayUsage, weekendUsage, err = tu.AssumedHourlyUsage(pt.fallback.policy, thresholds, pt.pod, resourceName, pt.fallback.templates)
+				if err != nil {
+					return status, err
+				}
+			}
+
+			for hour, v := range weekdayUsage {
+				weekday[resourceName][hour] += float64(v)
+			}
+			for hour, v := range weekendUsage {
+				weekend[resourceName][hour] += float64(v)
+			}
+		}
+
+		if templated {
+			templates[types.NamespacedName{Namespace: pt.pod.Namespace, Name: utils.GetUsageTemplateLabel(pt.pod)}]++
+		} else {
+			status.PodsWithoutTemplate++
+		}
+	}
+
+	for _, resourceName := range resourceNames {
+		factor, _ := utils.GetNodePerformanceFactor(node, resourceName)
+
+		// the scheduler ignores an invalid baseline too
+		if annotation, ok := schedv1alpha1.SupportedBaselineUsageAnnotation[resourceName]; ok {
+			if baseline, err := utils.ParseHourlyUsage(node.Annotations[annotation]); err == nil {
+				for hour, v := range baseline.Weekday {
+					weekday[resourceName][hour] += v * factor
+				}
+				for hour, v := range baseline.Weekend {
+					weekend[resourceName][hour] += v * factor
+				}
+			}
+		}
+
+		allocatable := math.Round(resourceValue(resourceName, node.Status.Allocatable) * factor)
+		forecast := schedv1alpha1.NodeResourceForecast{
+			Resource:    resourceName,
+			Unit:        schedv1alpha1.SupportedResourceMetricUnit[resourceName],
+			Allocatable: strconv.FormatFloat(allocatable, 'f', -1, 64),
+			Hours:       make([]schedv1alpha1.NodeHourlyForecast, 0, 2*hoursInADay),
+		}
+		for _, isWeekday := range []bool{true, false} {
+			usages := weekend[resourceName]
+			if isWeekday {
+				usages = weekday[resourceName]
+			}
+			for hour, usage := range usages {
+				usage = math.Round(usage)
+				forecast.Hours = append(forecast.Hours, schedv1alpha1.NodeHourlyForecast{
+					Hour:      int32(hour),
+					IsWeekday: isWeekday,
+					Usage:     strconv.FormatFloat(usage, 'f', -1, 64),
+					Headroom:  strconv.FormatFloat(allocatable-usage, 'f', -1, 64),
+				})
+			}
+		}
+		status.Resources = append(status.Resources, forecast)
+	}
+
+	for key, pods := range templates {
+		status.Templates = append(status.Templates, schedv1alpha1.ContributingTemplate{Namespace: key.Namespace, Name: key.Name, Pods: pods})
+	}
+	sort.Slice(status.Templates, func(i, j int) bool {
+		if status.Templates[i].Namespace != status.Templates[j].Namespace {
+			return status.Templates[i].Namespace < status.Templates[j].Namespace
+		}
+		return status.Templates[i].Name < status.Templates[j].Name
+	})
+
+	return status, nil
+}
+
+// resourceValue returns the value of the resource in the unit of the usage templates, i.e. millicores for the CPU
+func resourceValue(resourceName string, resources v1.ResourceList) float64 {
+	quantity, ok := resources[v1.ResourceName(resourceName)]
+	if !ok {
+		return 0
+	}
+
+	if resourceName == v1.ResourceCPU.String() {
+		return float64(quantity.MilliValue())
+	}
+	return float64(quantity.Value())
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	schedv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	tu "gitee.com/openeuler/paws/scheduler/pkg/temporalutilization"
+	utcache "gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/cache"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/utils"
+	testutils "gitee.com/openeuler/paws/scheduler/pkg/test/util"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+)
+
+func TestNodeUsageForecast(t *testing.T) {
+	node := st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{
+		v1.ResourceCPU:    "2",
+		v1.ResourceMemory: "4Gi",
+	}).Obj()
+
+	baseline := utils.NewHourlyUsage()
+	for hour := int16(0); hour < 24; hour++ {
+		baseline.Weekday[hour] = 100
+		baseline.Weekend[hour] = 100
+	}
+	node.Annotations = map[string]string{
+		schedv1alpha1.NodeCPUPerformanceFactorAnnotation: "1.5",
+		schedv1alpha1.NodeCPUBaselineUsageAnnotation:     utils.FormatHourlyUsage(baseline),
+	}
+
+	weekday := testutils.SameUsageADay(400)
+	weekday[10] = 1000
+	ut := testutils.MakeUsageTemplate("app", "default", true, "BestEffort",
+		map[string]map[int]float32{"cpu": weekday},
+		map[string]map[int]float32{"cpu": testutils.SameUsageADay(200)}, true)
+	template := utcache.NewUsageTemplateStore().Get(ut)
+
+	labels := map[string]string{schedv1alpha1.UsageTemplateLabelIdentifier: "app"}
+	pods := []podTemplate{
+		{pod: st.MakePod().Namespace("default").Name("app-1").Labels(labels).Req(map[v1.ResourceName]string{
+			v1.ResourceCPU: "500m", v1.ResourceMemory: "1Gi"}).Obj(), template: template},
+		{pod: st.MakePod().Namespace("default").Name("app-2").Labels(labels).Req(map[v1.ResourceName]string{
+			v1.ResourceCPU: "500m", v1.ResourceMemory: "1Gi"}).Obj(), template: template},
+		{pod: st.MakePod().Namespace("default").Name("web-1").Req(map[v1.ResourceName]string{
+			v1.ResourceCPU: "300m", v1.ResourceMemory: "512Mi"}).Obj()},
+	}
+
+	status, err := nodeUsageForecast(node, pods, tu.CoverageThresholds{}, time.Now())
+	assert.NoError(t, err)
+
+	assert.Equal(t, []schedv1alpha1.ContributingTemplate{{Namespace: "default", Name: "app", Pods: 2}}, status.Templates)
+	assert.Equal(t, int32(1), status.PodsWithoutTemplate)
+	assert.Len(t, status.Resources, 2)
+
+	cpu := status.Resources[0]
+	assert.Equal(t, v1.ResourceCPU.String(), cpu.Resource)
+	assert.Equal(t, "millicore", cpu.Unit)
+	// the allocatable and the baseline are scaled to reference millicores
+	assert.Equal(t, "3000", cpu.Allocatable)
+	assert.Len(t, cpu.Hours, 48)
+	hour := func(hours []schedv1alpha1.NodeHourlyForecast, isWeekday bool, h int32) schedv1alpha1.NodeHourlyForecast {
+		for _, f := range hours {
+			if f.IsWeekday == isWeekday && f.Hour == h {
+				return f
+			}
+		}
+		t.Fatalf("missing hour %d", h)
+		return schedv1alpha1.NodeHourlyForecast{}
+	}
+	// 2 * 400 + 300 + 100 * 1.5
+	assert.Equal(t, schedv1alpha1.NodeHourlyForecast{Hour: 9, IsWeekday: true, Usage: "1250", Headroom: "1750"}, hour(cpu.Hours, true, 9))
+	assert.Equal(t, schedv1alpha1.NodeHourlyForecast{Hour: 10, IsWeekday: true, Usage: "2450", Headroom: "550"}, hour(cpu.Hours, true, 10))
+	assert.Equal(t, schedv1alpha1.NodeHourlyForecast{Hour: 10, Usage: "850", Headroom: "2150"}, hour(cpu.Hours, false, 10))
+
+	// the usage template has no memory, the requests are assumed
+	memory := status.Resources[1]
+	assert.Equal(t, v1.ResourceMemory.String(), memory.Resource)
+	// 4Gi
+	assert.Equal(t, "4294967296", memory.Allocatable)
+	// 2 * 1Gi + 512Mi, 1.5Gi left
+	assert.Equal(t, schedv1alpha1.NodeHourlyForecast{Hour: 3, Usage: "2684354560", Headroom: "1610612736"}, hour(memory.Hours, false, 3))
+}
+
+func TestNodeUsageForecastFallback(t *testing.T) {
+	node := st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{
+		v1.ResourceCPU:    "4",
+		v1.ResourceMemory: "4Gi",
+	}).Obj()
+
+	limitedPod := func(namespace, name string, labels map[string]string) *v1.Pod {
+		pod := st.MakePod().Namespace(namespace).Name(name).Labels(labels).Req(map[v1.ResourceName]string{
+			v1.ResourceCPU: "200m"}).Obj()
+		pod.Spec.Containers[0].Resources.Limits = v1.ResourceList{v1.ResourceCPU: resource.MustParse("600m")}
+		return pod
+	}
+
+	// evaluated from too little data, the scheduler ignores it
+	ut := testutils.MakeUsageTemplate("web", "default", true, "Burstable",
+		map[string]map[int]float32{"cpu": testutils.SameUsageADay(50)},
+		map[string]map[int]float32{"cpu": testutils.SameUsageADay(50)}, true)
+	ut.Status.Coverage = &schedv1alpha1.EvaluationCoverage{Confidence: 10}
+	other := testutils.MakeUsageTemplate("api", "apps", true, "Burstable",
+		map[string]map[int]float32{"cpu": testutils.SameUsageADay(700)},
+		map[string]map[int]float32{"cpu": testutils.SameUsageADay(900)}, true)
+	other.Status.Coverage = &schedv1alpha1.EvaluationCoverage{Confidence: 80}
+	store := utcache.NewUsageTemplateStore()
+
+	thresholds := tu.CoverageThresholds{MinConfidence: 50}
+	configured := namespaceFallback{policy: tu.FallbackPolicy{Mode: tu.LimitsPercentageFallback, LimitsPercentage: 50}}
+	pods := []podTemplate{
+		{pod: limitedPod("default", "web-1", map[string]string{schedv1alpha1.UsageTemplateLabelIdentifier: "web"}),
+			template: store.Get(ut), fallback: configured},
+		// overridden by the annotations of the namespace
+		{pod: limitedPod("batch", "batch-1", nil), fallback: namespaceFallback{policy: tu.FallbackPolicy{Mode: tu.LimitsFallback}}},
+		{pod: limitedPod("apps", "worker-1", nil), fallback: namespaceFallback{
+			policy: tu.FallbackPolicy{Mode: tu.NamespaceAggregateFallback}, templates: []*utcache.UsageTemplateCache{store.Get(other)}}},
+	}
+
+	status, err := nodeUsageForecast(node, pods, thresholds, time.Now())
+	assert.NoError(t, err)
+
+	assert.Empty(t, status.Templates)
+	assert.Equal(t, int32(3), status.PodsWithoutTemplate)
+	cpu := status.Resources[0]
+	assert.Equal(t, v1.ResourceCPU.String(), cpu.Resource)
+	for _, f := range cpu.Hours {
+		// 50% of 600m + 600m + 700m of the usage templates of the namespace
+		expected := "1600"
+		if !f.IsWeekday {
+			expected = "1800"
+		}
+		assert.Equal(t, expected, f.Usage, "weekday %v hour %d", f.IsWeekday, f.Hour)
+	}
+}
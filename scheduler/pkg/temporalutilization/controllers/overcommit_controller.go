@@ -0,0 +1,386 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/common/model"
+
+	schedv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	prommetrics "gitee.com/openeuler/paws/scheduler/pkg/metrics"
+	utcache "gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/cache"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/evaluation"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/events"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/utils"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/kubernetes/pkg/api/v1/resource"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// podNodeNameField indexes the pods by the node they are bound to
+	podNodeNameField = "spec.nodeName"
+
+	DefaultOvercommitTuningInterval          = time.Hour
+	DefaultOvercommitTuningWindow            = 7 * 24 * time.Hour
+	DefaultOvercommitTuningTargetUtilization = 0.8
+	DefaultOvercommitTuningMinRatio          = 0
+	DefaultOvercommitTuningMaxRatio          = 1
+	DefaultOvercommitTuningMaxStep           = 0.1
+)
+
+// OvercommitTuning bounds the CPU overcommit ratios computed by the NodeOvercommitReconciler
+type OvercommitTuning struct {
+	// TargetUtilization is the fraction of the allocatable CPU the node is expected to peak at
+	// once the overcommitted requests are scheduled
+	TargetUtilization float64
+	// MinRatio and MaxRatio bound the overcommit ratio
+	MinRatio float64
+	MaxRatio float64
+	// MaxStep is the largest change of the ratio in a single tuning
+	MaxStep float64
+}
+
+// NodeOvercommitReconciler periodically tunes the CPU overcommit ratio annotation of the selected nodes
+// from their observed headroom, the ratio of the CPU requests of the pods on the node to their peak usage
+type NodeOvercommitReconciler struct {
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	client.Client
+
+	// NodeSelector selects the nodes whose ratio is tuned, an empty selector selects every node
+	NodeSelector labels.Selector
+	// Interval is the time between two tunings of a node
+	Interval time.Duration
+	// Window is the history the actual peak usage of the node is taken over
+	Window time.Duration
+	// Resolution is the step of the actual usage query
+	Resolution time.Duration
+	// Timeout bounds the Prometheus queries
+	Timeout time.Duration
+	// NodeMetricLabel is the label of the usage timeseries with the node name,
+	// the actual usage is not queried when it is empty
+	NodeMetricLabel string
+	// DryRun only logs and records the computed ratios without annotating the nodes
+	DryRun bool
+	Tuning OvercommitTuning
+
+	promClient *evaluation.PromClient
+	templates  *utcache.UsageTemplateStore
+}
+
+// SetupWithManager starts a new controller tuning the nodes managed by the passed Manager instance,
+// the pods are expected to be indexed by IndexPodsByNodeName.
+func (r *NodeOvercommitReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options, prometheusAddress string) error {
+	var err error
+
+	// the range queries of a node are not shared, there is nothing to cache
+	r.promClient, err = evaluation.NewPromClient(prometheusAddress, 0, 0)
+	if err != nil {
+		r.Log.Error(err, "Unable to create prometheus client", "PromAddress", prometheusAddress)
+		return err
+	}
+	r.templates = utcache.NewUsageTemplateStore()
+
+	if r.NodeSelector == nil {
+		r.NodeSelector = labels.Everything()
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("node-overcommit").
+		WithOptions(options).
+		// the node status is updated by the kubelet heartbeats and the annotations by the tunings themselves,
+		// only the changes of the spec and labels matter, the node is tuned again after the interval
+		For(&v1.Node{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			return r.NodeSelector.Matches(labels.Set(obj.GetLabels()))
+		}), predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{}))).
+		Complete(r)
+}
+
+// IndexPodsByNodeName indexes the pods by the node they are bound to, the index is shared by the node controllers
+// and is registered once per manager
+func IndexPodsByNodeName(mgr ctrl.Manager) error {
+	return mgr.GetFieldIndexer().IndexField(context.Background(), &v1.Pod{}, podNodeNameField, func(obj client.Object) []string {
+		pod := obj.(*v1.Pod)
+		if pod.Spec.NodeName == "" {
+			return nil
+		}
+		return []string{pod.Spec.NodeName}
+	})
+}
+
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+
+func (r *NodeOvercommitReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	node := &v1.Node{}
+	if err := r.Get(ctx, req.NamespacedName, node); err != nil {
+		if apierrs.IsNotFound(err) {
+			log.V(5).Info("Node not found")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !r.NodeSelector.Matches(labels.Set(node.Labels)) || node.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	if wait := r.untilNextTuning(node, time.Now()); wait > 0 {
+		log.V(5).Info("Overcommit ratio tuned recently", "node", node.Name, "requeueAfter", wait)
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	if err := r.tuneNode(ctx, log, node); err != nil {
+		log.Error(err, "Unable to tune the overcommit ratio", "node", node.Name)
+		r.Recorder.Event(node, v1.EventTypeWarning, events.OvercommitTuningFailed, err.Error())
+	}
+
+	return ctrl.Result{RequeueAfter: r.Interval}, nil
+}
+
+func (r *NodeOvercommitReconciler) tuneNode(ctx context.Context, log logr.Logger, node *v1.Node) error {
+	capacity := node.Status.Allocatable.Cpu().MilliValue()
+	if capacity <= 0 {
+		return nil
+	}
+
+	podList := &v1.PodList{}
+	if err := r.List(ctx, podList, client.MatchingFields{podNodeNameField: node.Name}); err != nil {
+		return err
+	}
+
+	requests, forecastPeak, err := r.nodeForecast(ctx, node, podList.Items)
+	if err != nil {
+		return err
+	}
+
+	actualPeak, err := r.actualPeak(ctx, log, node)
+	if err != nil {
+		return err
+	}
+
+	peak := math.Max(forecastPeak, actualPeak)
+	current, _ := currentOvercommitRatio(node)
+	ratio, ok := r.Tuning.ratio(current, requests, peak)
+	if !ok {
+		log.V(4).Info("Not enough usage to tune the overcommit ratio", "node", node.Name, "requests", requests, "peak", peak)
+		return nil
+	}
+
+	prommetrics.SetTunedOvercommitRatio(node.Name, ratio)
+	if ratio == current {
+		return nil
+	}
+
+	msg := fmt.Sprintf("CPU overcommit ratio %v -> %v, requests %.0fm, forecast peak %.0fm, actual peak %.0fm, allocatable %dm",
+		current, ratio, requests, forecastPeak, actualPeak, capacity)
+	if r.DryRun {
+		log.Info("Dry run, not annotating the node", "node", node.Name, "message", msg)
+		r.Recorder.Event(node, v1.EventTypeNormal, events.OvercommitTuningDryRun, msg)
+		return nil
+	}
+
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:%q,%q:%q}}}`,
+		schedv1alpha1.NodeCPUOvercommitRatioAnnotation, strconv.FormatFloat(ratio, 'f', -1, 64),
+		schedv1alpha1.NodeCPUOvercommitTunedAtAnnotation, time.Now().UTC().Format(time.RFC3339)))
+	if err := r.Patch(ctx, node, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return err
+	}
+
+	log.Info("Tuned the overcommit ratio", "node", node.Name, "message", msg)
+	r.Recorder.Event(node, v1.EventTypeNormal, events.OvercommitTuned, msg)
+	return nil
+}
+
+// untilNextTuning returns how long until the ratio of the node can be changed again,
+// so that it moves at most by MaxStep per interval whatever triggers the reconciliations
+func (r *NodeOvercommitReconciler) untilNextTuning(node *v1.Node, now time.Time) time.Duration {
+	value, ok := node.Annotations[schedv1alpha1.NodeCPUOvercommitTunedAtAnnotation]
+	if !ok {
+		return 0
+	}
+
+	tunedAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		// an invalid timestamp is overwritten by the next tuning
+		return 0
+	}
+
+	return tunedAt.Add(r.Interval).Sub(now)
+}
+
+// nodeForecast returns the CPU requests of the pods on the node and the peak hour of their summed usage templates,
+// in millicores of the node. A pod without an enabled usage template or an hour missing from it is assumed to use its requests
+func (r *NodeOvercommitReconciler) nodeForecast(ctx context.Context, node *v1.Node, pods []v1.Pod) (float64, float64, error) {
+	factor, _ := utils.GetNodePerformanceFactor(node, v1.ResourceCPU.String())
+
+	var requests float64
+	usages := make([]podCPUUsage, 0, len(pods))
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+
+		reqs, _ := resource.PodRequestsAndLimits(pod)
+		usage := podCPUUsage{requests: float64(reqs.Cpu().MilliValue())}
+		requests += usage.requests
+
+		name := utils.GetUsageTemplateLabel(pod)
+		if name != "" {
+			ut := &schedv1alpha1.UsageTemplate{}
+			err := r.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: name}, ut)
+			if err != nil && !apierrs.IsNotFound(err) {
+				return 0, 0, err
+			}
+			if err == nil && ut.Spec.Enabled {
+				usage.template = r.templates.Get(ut).Usages[v1.ResourceCPU.String()]
+			}
+		}
+		usages = append(usages, usage)
+	}
+
+	return requests, forecastPeak(usages, factor), nil
+}
+
+// actualPeak queries the peak CPU usage of the node over the window in millicores
+func (r *NodeOvercommitReconciler) actualPeak(ctx context.Context, log logr.Logger, node *v1.Node) (float64, error) {
+	if r.NodeMetricLabel == "" {
+		return 0, nil
+	}
+
+	end := time.Now().UTC()
+	values, err := r.promClient.FetchQueryRange(ctx, nodeCPUUsageQuery(r.NodeMetricLabel, node.Name),
+		r.Timeout, end.Add(-r.Window), end, r.Resolution, log)
+	if err != nil {
+		return 0, err
+	}
+
+	matrix, ok := values.(model.Matrix)
+	if !ok {
+		return 0, fmt.Errorf("unexpected prometheus result type %s", values.Type())
+	}
+
+	peak := float64(0)
+	for _, series := range matrix {
+		for _, sample := range series.Values {
+			peak = math.Max(peak, float64(sample.Value))
+		}
+	}
+
+	return peak * schedv1alpha1.SupportedResourceMetricScalingFactor[v1.ResourceCPU.String()], nil
+}
+
+// nodeCPUUsageQuery builds the query of the CPU usage of all the containers on the node, e.g.
+// sum(rate(container_cpu_usage_seconds_total{container!="",node="node-1"}[2m]))
+func nodeCPUUsageQuery(nodeLabel, nodeName string) string {
+	resourceName := v1.ResourceCPU.String()
+	metricLabel := schedv1alpha1.SupportedResourcesMetricLabel[resourceName]
+	filters := append([]string{}, schedv1alpha1.SupportedMetricLabelFilters[metricLabel]...)
+	filters = append(filters, fmt.Sprintf("%s=%q", nodeLabel, nodeName))
+
+	return fmt.Sprintf("sum(%s(%s{%s}[%s]))", schedv1alpha1.SupportedResourcesRangeMethod[resourceName],
+		metricLabel, strings.Join(filters, ","), schedv1alpha1.SupportedResourcesRateTimeWindow[resourceName])
+}
+
+// podCPUUsage is the CPU requests and usage template of a pod on the node
+type podCPUUsage struct {
+	requests float64
+	template []utcache.Usage
+}
+
+// forecastPeak sums the hourly usages of the pods over the week and returns the peak hour,
+// the usage templates are in reference millicores and are converted to millicores of the node by its performance factor
+func forecastPeak(usages []podCPUUsage, factor float64) float64 {
+	if factor <= 0 {
+		factor = 1
+	}
+
+	peak := float64(0)
+	for _, isWeekday := range []bool{true, false} {
+		for hour := int32(0); hour < hoursInADay; hour++ {
+			total := float64(0)
+			for _, usage := range usages {
+				total += usage.at(isWeekday, hour, factor)
+			}
+			peak = math.Max(peak, total)
+		}
+	}
+
+	return peak
+}
+
+// at returns the usage of the pod at the hour of the day, the weekend samples are evaluated as the hours 24 to 47
+func (u podCPUUsage) at(isWeekday bool, hour int32, factor float64) float64 {
+	for _, sample := range u.template {
+		if sample.IsWeekday == isWeekday && sample.Hour%hoursInADay == hour {
+			return float64(sample.Value) / factor
+		}
+	}
+	return u.requests
+}
+
+// currentOvercommitRatio returns the CPU overcommit ratio annotation of the node, zero when it is missing or invalid
+func currentOvercommitRatio(node *v1.Node) (float64, bool) {
+	value, ok := node.Annotations[schedv1alpha1.NodeCPUOvercommitRatioAnnotation]
+	if !ok {
+		return 0, false
+	}
+
+	ratio, err := strconv.ParseFloat(value, 64)
+	if err != nil || ratio < 0 || math.IsNaN(ratio) || math.IsInf(ratio, 0) {
+		return 0, false
+	}
+
+	return ratio, true
+}
+
+// ratio computes the overcommit ratio of a node with the given CPU requests peaking at the given usage.
+// Assuming the usage grows with the requests, the node peaks at the target utilization when its requests
+// reach targetUtilization * requests / peak of the allocatable, i.e. the ratio is that minus one.
+// The ratio is bounded by the min and max ratios, moves at most by the max step from the current ratio,
+// and is rounded to two decimals. It returns false when there are no requests or usage to tune from
+func (t OvercommitTuning) ratio(current, requests, peak float64) (float64, bool) {
+	if requests <= 0 || peak <= 0 {
+		return current, false
+	}
+
+	ratio := t.TargetUtilization*requests/peak - 1
+	ratio = math.Min(math.Max(ratio, t.MinRatio), t.MaxRatio)
+	if t.MaxStep > 0 {
+		ratio = math.Min(math.Max(ratio, current-t.MaxStep), current+t.MaxStep)
+	}
+
+	return math.Round(math.Max(ratio, 0)*100) / 100, true
+}
+
+// ValidateOvercommitTuning checks the bounds of the overcommit ratio tuning
+func ValidateOvercommitTuning(t OvercommitTuning) error {
+	if t.TargetUtilization <= 0 || t.TargetUtilization > 1 {
+		return fmt.Errorf("target utilization must be in (0, 1], got %v", t.TargetUtilization)
+	}
+	if t.MinRatio < 0 || t.MaxRatio < t.MinRatio {
+		return fmt.Errorf("expect 0 <= min ratio <= max ratio, got min %v and max %v", t.MinRatio, t.MaxRatio)
+	}
+	if t.MaxStep < 0 {
+		return fmt.Errorf("max step must not be negative, got %v", t.MaxStep)
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	schedv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	utcache "gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/cache"
+	testutils "gitee.com/openeuler/paws/scheduler/pkg/test/util"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestOvercommitTuningRatio(t *testing.T) {
+	tuning := OvercommitTuning{
+		TargetUtilization: 0.8,
+		MinRatio:          0,
+		MaxRatio:          1,
+		MaxStep:           0.1,
+	}
+
+	tests := []struct {
+		name     string
+		tuning   OvercommitTuning
+		current  float64
+		requests float64
+		peak     float64
+		expected float64
+		ok       bool
+	}{
+		{
+			name:     "no requests keeps the current ratio",
+			tuning:   tuning,
+			current:  0.3,
+			peak:     500,
+			expected: 0.3,
+		},
+		{
+			name:     "no usage keeps the current ratio",
+			tuning:   tuning,
+			current:  0.3,
+			requests: 1000,
+			expected: 0.3,
+		},
+		{
+			name:     "headroom raises the ratio by at most the max step",
+			tuning:   tuning,
+			current:  0.3,
+			requests: 1000,
+			peak:     400,
+			expected: 0.4,
+			ok:       true,
+		},
+		{
+			name:     "within the max step the ratio is set",
+			tuning:   tuning,
+			current:  0.3,
+			requests: 1000,
+			peak:     640,
+			// 0.8 * 1000 / 640 - 1
+			expected: 0.25,
+			ok:       true,
+		},
+		{
+			name:     "usage above the target lowers the ratio by at most the max step",
+			tuning:   tuning,
+			current:  0.3,
+			requests: 1000,
+			peak:     1000,
+			expected: 0.2,
+			ok:       true,
+		},
+		{
+			name:     "the ratio is capped by the max ratio",
+			tuning:   OvercommitTuning{TargetUtilization: 0.8, MaxRatio: 0.5},
+			requests: 1000,
+			peak:     100,
+			expected: 0.5,
+			ok:       true,
+		},
+		{
+			name:     "the ratio is never negative",
+			tuning:   OvercommitTuning{TargetUtilization: 0.8, MaxRatio: 1},
+			current:  0.5,
+			requests: 1000,
+			peak:     1000,
+			expected: 0,
+			ok:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ratio, ok := tt.tuning.ratio(tt.current, tt.requests, tt.peak)
+			assert.Equal(t, tt.ok, ok)
+			assert.InDelta(t, tt.expected, ratio, 1e-9)
+		})
+	}
+}
+
+func TestValidateOvercommitTuning(t *testing.T) {
+	assert.NoError(t, ValidateOvercommitTuning(OvercommitTuning{TargetUtilization: 0.8, MaxRatio: 1, MaxStep: 0.1}))
+	assert.Error(t, ValidateOvercommitTuning(OvercommitTuning{TargetUtilization: 0, MaxRatio: 1}))
+	assert.Error(t, ValidateOvercommitTuning(OvercommitTuning{TargetUtilization: 0.8, MinRatio: 1, MaxRatio: 0.5}))
+	assert.Error(t, ValidateOvercommitTuning(OvercommitTuning{TargetUtilization: 0.8, MaxRatio: 1, MaxStep: -1}))
+}
+
+func TestForecastPeak(t *testing.T) {
+	// a pod peaking at 800m on weekday 10:00, and a pod without a template requesting 300m,
+	// the weekend samples are the hours 24 to 47
+	usages := []podCPUUsage{
+		{
+			requests: 1000,
+			template: []utcache.Usage{
+				{Hour: 9, IsWeekday: true, Value: 200},
+				{Hour: 10, IsWeekday: true, Value: 800},
+				{Hour: 24 + 10, IsWeekday: false, Value: 100},
+			},
+		},
+		{requests: 300},
+	}
+
+	// the hours missing from the template use the requests
+	assert.InDelta(t, 1300, forecastPeak(usages, 1), 1e-9)
+
+	// once every hour is in the template, the peak is at the weekday 10:00
+	for _, isWeekday := range []bool{true, false} {
+		for hour := int32(0); hour < 24; hour++ {
+			if isWeekday && (hour == 9 || hour == 10) || !isWeekday && hour == 10 {
+				continue
+			}
+			sample := utcache.Usage{Hour: hour, IsWeekday: isWeekday, Value: 100}
+			if !isWeekday {
+				sample.Hour += 24
+			}
+			usages[0].template = append(usages[0].template, sample)
+		}
+	}
+	assert.InDelta(t, 1100, forecastPeak(usages, 1), 1e-9)
+
+	// the templates are in reference millicores, a node twice as fast needs half the millicores
+	assert.InDelta(t, 700, forecastPeak(usages, 2), 1e-9)
+}
+
+func TestCurrentOvercommitRatio(t *testing.T) {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	ratio, ok := currentOvercommitRatio(node)
+	assert.False(t, ok)
+	assert.Equal(t, float64(0), ratio)
+
+	node.Annotations = map[string]string{schedv1alpha1.NodeCPUOvercommitRatioAnnotation: "0.35"}
+	ratio, ok = currentOvercommitRatio(node)
+	assert.True(t, ok)
+	assert.Equal(t, 0.35, ratio)
+
+	node.Annotations[schedv1alpha1.NodeCPUOvercommitRatioAnnotation] = "-1"
+	_, ok = currentOvercommitRatio(node)
+	assert.False(t, ok)
+}
+
+func TestNodeCPUUsageQuery(t *testing.T) {
+	assert.Equal(t, `sum(rate(container_cpu_usage_seconds_total{container!="",node="node-1"}[2m]))`,
+		nodeCPUUsageQuery("node", "node-1"))
+}
+
+// testNodeClient serves a node, its pods and the usage templates, and applies the annotation patches to the node
+type testNodeClient struct {
+	client.Client
+	node      *v1.Node
+	pods      []v1.Pod
+	templates []*schedv1alpha1.UsageTemplate
+	patches   []map[string]string
+}
+
+func (c *testNodeClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	switch o := obj.(type) {
+	case *v1.Node:
+		if c.node != nil && c.node.Name == key.Name {
+			c.node.DeepCopyInto(o)
+			return nil
+		}
+	case *schedv1alpha1.UsageTemplate:
+		for _, ut := range c.templates {
+			if ut.Namespace == key.Namespace && ut.Name == key.Name {
+				ut.DeepCopyInto(o)
+				return nil
+			}
+		}
+	}
+	return apierrs.NewNotFound(schema.GroupResource{}, key.Name)
+}
+
+func (c *testNodeClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if podList, ok := list.(*v1.PodList); ok {
+		podList.Items = append([]v1.Pod{}, c.pods...)
+	}
+	return nil
+}
+
+func (c *testNodeClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+
+	patched := struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+	}{}
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return err
+	}
+
+	c.patches = append(c.patches, patched.Metadata.Annotations)
+	if c.node.Annotations == nil {
+		c.node.Annotations = make(map[string]string)
+	}
+	for k, v := range patched.Metadata.Annotations {
+		c.node.Annotations[k] = v
+	}
+	return nil
+}
+
+func TestNodeOvercommitReconcileInterval(t *testing.T) {
+	node := st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{v1.ResourceCPU: "4"}).Obj()
+	ut := testutils.MakeUsageTemplate("app", "default", true, "Burstable",
+		map[string]map[int]float32{"cpu": testutils.SameUsageADay(500)},
+		map[string]map[int]float32{"cpu": testutils.SameUsageADay(500)}, true)
+	pod := st.MakePod().Namespace("default").Name("app-1").Node("node-1").Labels(map[string]string{
+		schedv1alpha1.UsageTemplateLabelIdentifier: "app"}).Req(map[v1.ResourceName]string{v1.ResourceCPU: "2"}).Obj()
+
+	c := &testNodeClient{node: node, pods: []v1.Pod{*pod}, templates: []*schedv1alpha1.UsageTemplate{ut}}
+	r := &NodeOvercommitReconciler{
+		Client:       c,
+		Recorder:     record.NewFakeRecorder(10),
+		NodeSelector: labels.Everything(),
+		Interval:     time.Hour,
+		Tuning:       OvercommitTuning{TargetUtilization: 0.8, MinRatio: 0, MaxRatio: 1, MaxStep: 0.1},
+		templates:    utcache.NewUsageTemplateStore(),
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "node-1"}}
+
+	// 0.8 * 2000m / 500m - 1 is bounded by the max step
+	result, err := r.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, result.RequeueAfter)
+	assert.Len(t, c.patches, 1)
+	assert.Equal(t, "0.1", c.patches[0][schedv1alpha1.NodeCPUOvercommitRatioAnnotation])
+	assert.Contains(t, c.patches[0], schedv1alpha1.NodeCPUOvercommitTunedAtAnnotation)
+
+	// the update of the annotations does not step the ratio again before the interval
+	result, err = r.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, c.patches, 1)
+	assert.Greater(t, result.RequeueAfter, 59*time.Minute)
+	assert.LessOrEqual(t, result.RequeueAfter, time.Hour)
+
+	node.Annotations[schedv1alpha1.NodeCPUOvercommitTunedAtAnnotation] = time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	_, err = r.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, c.patches, 2)
+	assert.Equal(t, "0.2", c.patches[1][schedv1alpha1.NodeCPUOvercommitRatioAnnotation])
+}
+
+func TestUntilNextTuning(t *testing.T) {
+	now := time.Date(2024, 10, 18, 12, 0, 0, 0, time.UTC)
+	r := &NodeOvercommitReconciler{Interval: time.Hour}
+
+	tests := []struct {
+		name     string
+		tunedAt  string
+		expected time.Duration
+	}{
+		{name: "never tuned"},
+		{name: "invalid timestamp", tunedAt: "yesterday"},
+		{name: "tuned recently", tunedAt: "2024-10-18T11:45:00Z", expected: 45 * time.Minute},
+		{name: "interval passed", tunedAt: "2024-10-18T10:00:00Z", expected: -time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := st.MakeNode().Name("node-1").Obj()
+			if tt.tunedAt != "" {
+				node.Annotations = map[string]string{schedv1alpha1.NodeCPUOvercommitTunedAtAnnotation: tt.tunedAt}
+			}
+			assert.Equal(t, tt.expected, r.untilNextTuning(node, now))
+		})
+	}
+}
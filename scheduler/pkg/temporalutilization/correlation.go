@@ -0,0 +1,109 @@
+package temporalutilization
+
+import (
+	"fmt"
+	"math"
+
+	pluginConfig "gitee.com/openeuler/paws/scheduler/apis/config"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// flatVariance is the variance under which hourly usages are considered flat, their correlation is undefined
+const flatVariance = 1e-6
+
+// getAntiCorrelationWeight validates the anti-correlation weight args, the anti-correlation is disabled
+// when the weight is 0 or invalid
+func getAntiCorrelationWeight(args *pluginConfig.TemporalUtilizationArgs) float64 {
+	if args.AntiCorrelationWeight < 0 || args.AntiCorrelationWeight > 100 {
+		err := fmt.Errorf("must be between zero and a hundred")
+		klog.ErrorS(err, "Disabling anti-correlation, got", "antiCorrelationWeight", args.AntiCorrelationWeight)
+		return 0
+	}
+	return float64(args.AntiCorrelationWeight) / 100
+}
+
+// usageCorrelation returns the Pearson correlation, weighted by the slot weights, of the hourly usages of the pod
+// and of the node without the pod, the forecast being the usages of the node with the pod.
+// It returns false when either usages are flat, e.g. a pod assumed to use its requests
+func usageCorrelation(podUsage, forecast *UsageTemplate, slots []hourSlot) (float64, bool) {
+	if podUsage == nil || forecast == nil {
+		return 0, false
+	}
+
+	var sumWeights, sumPod, sumNode float64
+	pods := make([]float64, 0, len(slots))
+	nodes := make([]float64, 0, len(slots))
+	weights := make([]float64, 0, len(slots))
+	for _, slot := range slots {
+		total, ok := forecast.valueAt(slot)
+		if !ok {
+			continue
+		}
+		pod, _ := podUsage.valueAt(slot)
+		node := math.Max(float64(total-pod), 0)
+
+		pods, nodes, weights = append(pods, float64(pod)), append(nodes, node), append(weights, slot.weight)
+		sumWeights += slot.weight
+		sumPod += slot.weight * float64(pod)
+		sumNode += slot.weight * node
+	}
+	if sumWeights == 0 {
+		return 0, false
+	}
+
+	meanPod, meanNode := sumPod/sumWeights, sumNode/sumWeights
+	var covariance, variancePod, varianceNode float64
+	for i := range weights {
+		dp, dn := pods[i]-meanPod, nodes[i]-meanNode
+		covariance += weights[i] * dp * dn
+		variancePod += weights[i] * dp * dp
+		varianceNode += weights[i] * dn * dn
+	}
+	if variancePod/sumWeights < flatVariance || varianceNode/sumWeights < flatVariance {
+		return 0, false
+	}
+
+	r := covariance / math.Sqrt(variancePod*varianceNode)
+	return math.Max(-1, math.Min(1, r)), true
+}
+
+// antiCorrelationScore is the max node score for usages peaking at opposite hours, i.e. a correlation of -1,
+// the min node score for usages peaking at the same hours, and a neutral score when the correlation is undefined
+func antiCorrelationScore(r float64, ok bool) float64 {
+	if !ok {
+		return float64(framework.MaxNodeScore) / 2
+	}
+	return (1 - r) / 2 * float64(framework.MaxNodeScore)
+}
+
+// blendAntiCorrelation blends the anti-correlation score of the CPU usages with the hotspot score,
+// the anti-correlation score is scaled as the hotspot score which sums the scores of the slots
+func (pl *TemporalUtilization) blendAntiCorrelation(score int64, r float64, ok bool, slots []hourSlot) int64 {
+	totalWeight := 0.0
+	for _, slot := range slots {
+		totalWeight += slot.weight
+	}
+
+	w := pl.AntiCorrelationWeight
+	return int64(math.Round((1-w)*float64(score) + w*antiCorrelationScore(r, ok)*totalWeight))
+}
+
+// observePlacementCorrelation reports the correlation of the CPU usages of the pod and of the node it is placed on,
+// as computed when the node was scored, the lower the correlations the flatter the usages of the nodes
+func (pl *TemporalUtilization) observePlacementCorrelation(state *framework.CycleState, pod *v1.Pod, nodeName string) {
+	c, err := state.Read(podUsagesStateKey)
+	if err != nil {
+		return
+	}
+	podUsages, ok := c.(*podUsagesState)
+	if !ok || !podUsages.usable {
+		return
+	}
+
+	if r, ok := podUsages.correlations.Load(nodeName); ok {
+		placementUsageCorrelation.Observe(r.(float64))
+		klog.V(5).InfoS("Placement usage correlation", "pod", klog.KObj(pod), "node", nodeName, "correlation", r)
+	}
+}
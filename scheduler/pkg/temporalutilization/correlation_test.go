@@ -0,0 +1,111 @@
+package temporalutilization
+
+import (
+	"testing"
+
+	pluginConfig "gitee.com/openeuler/paws/scheduler/apis/config"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metricstestutil "k8s.io/component-base/metrics/testutil"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+)
+
+func TestGetAntiCorrelationWeight(t *testing.T) {
+	assert.Equal(t, 0.0, getAntiCorrelationWeight(&pluginConfig.TemporalUtilizationArgs{}))
+	assert.Equal(t, 0.3, getAntiCorrelationWeight(&pluginConfig.TemporalUtilizationArgs{AntiCorrelationWeight: 30}))
+	assert.Equal(t, 0.0, getAntiCorrelationWeight(&pluginConfig.TemporalUtilizationArgs{AntiCorrelationWeight: 130}))
+	assert.Equal(t, 0.0, getAntiCorrelationWeight(&pluginConfig.TemporalUtilizationArgs{AntiCorrelationWeight: -1}))
+}
+
+func TestUsageCorrelation(t *testing.T) {
+	slots := []hourSlot{
+		{isWeekday: true, hour: 1, weight: 1},
+		{isWeekday: true, hour: 2, weight: 1},
+		{isWeekday: true, hour: 3, weight: 1},
+		{isWeekday: true, hour: 4, weight: 1},
+	}
+	template := func(usages ...float32) *UsageTemplate {
+		ut := &UsageTemplate{weekDayHour: make(map[int16]float32)}
+		for i, usage := range usages {
+			ut.weekDayHour[int16(i+1)] = usage
+		}
+		return ut
+	}
+
+	// a daytime pod on a node busy at night, the forecasts include the pod
+	pod := template(400, 400, 100, 100)
+	r, ok := usageCorrelation(pod, template(500, 500, 500, 500), slots)
+	assert.True(t, ok)
+	assert.InDelta(t, -1, r, 1e-9)
+
+	// the node peaks at the same hours as the pod
+	r, ok = usageCorrelation(pod, template(800, 800, 200, 200), slots)
+	assert.True(t, ok)
+	assert.InDelta(t, 1, r, 1e-9)
+
+	// the correlation is undefined with a flat pod or node
+	_, ok = usageCorrelation(template(200, 200, 200, 200), template(500, 600, 300, 500), slots)
+	assert.False(t, ok)
+	_, ok = usageCorrelation(pod, template(600, 600, 300, 300), slots)
+	assert.False(t, ok)
+	_, ok = usageCorrelation(nil, template(500, 500, 500, 500), slots)
+	assert.False(t, ok)
+}
+
+func TestTemporalUtilizationBlendAntiCorrelation(t *testing.T) {
+	slots := []hourSlot{{isWeekday: true, hour: 1, weight: 1}, {isWeekday: true, hour: 2, weight: 1}}
+	podUsages := map[string]*UsageTemplate{
+		v1.ResourceCPU.String(): {weekDayHour: map[int16]float32{1: 400, 2: 100}},
+	}
+	complementary := map[string]*UsageTemplate{
+		v1.ResourceCPU.String(): {weekDayHour: map[int16]float32{1: 500, 2: 500}},
+	}
+	alike := map[string]*UsageTemplate{
+		v1.ResourceCPU.String(): {weekDayHour: map[int16]float32{1: 800, 2: 200}},
+	}
+	flat := map[string]*UsageTemplate{
+		v1.ResourceCPU.String(): {weekDayHour: map[int16]float32{1: 500, 2: 200}},
+	}
+
+	blend := func(pl *TemporalUtilization, forecasts map[string]*UsageTemplate) int64 {
+		r, ok := usageCorrelation(podUsages[v1.ResourceCPU.String()], forecasts[v1.ResourceCPU.String()], slots)
+		return pl.blendAntiCorrelation(120, r, ok, slots)
+	}
+
+	pl := &TemporalUtilization{AntiCorrelationWeight: 0.5}
+	// the hotspot score of 120 over two slots is blended with the anti-correlation score over two slots
+	assert.Equal(t, int64(160), blend(pl, complementary))
+	assert.Equal(t, int64(60), blend(pl, alike))
+	assert.Equal(t, int64(110), blend(pl, flat))
+
+	pl.AntiCorrelationWeight = 1
+	assert.Equal(t, int64(200), blend(pl, complementary))
+}
+
+func TestTemporalUtilizationObservePlacementCorrelation(t *testing.T) {
+	RegisterMetrics()
+	pl := &TemporalUtilization{}
+	pod := st.MakePod().Namespace("default").Name("pod-1").Obj()
+	countBefore, _ := metricstestutil.GetHistogramMetricCount(placementUsageCorrelation.ObserverMetric)
+
+	// the correlations are computed at Score only when the anti-correlation is enabled
+	state := framework.NewCycleState()
+	podUsages := &podUsagesState{usable: true}
+	state.Write(podUsagesStateKey, podUsages)
+	pl.observePlacementCorrelation(state, pod, "node-1")
+	count, _ := metricstestutil.GetHistogramMetricCount(placementUsageCorrelation.ObserverMetric)
+	assert.Equal(t, countBefore, count)
+
+	podUsages.correlations.Store("node-1", -0.5)
+	pl.observePlacementCorrelation(state, pod, "node-2")
+	pl.observePlacementCorrelation(state, pod, "node-1")
+	count, _ = metricstestutil.GetHistogramMetricCount(placementUsageCorrelation.ObserverMetric)
+	assert.Equal(t, countBefore+1, count)
+
+	// not reported for the pods without a usage template
+	podUsages.usable = false
+	pl.observePlacementCorrelation(state, pod, "node-1")
+	count, _ = metricstestutil.GetHistogramMetricCount(placementUsageCorrelation.ObserverMetric)
+	assert.Equal(t, countBefore+1, count)
+}
@@ -0,0 +1,250 @@
+package temporalutilization
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	schedv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const (
+	// maxDeferralWait is the longest a deferred pod waits at a time, the framework rejects longer waits.
+	// A pod still deferred by then is rejected and waits again in its next scheduling cycle
+	maxDeferralWait = 15 * time.Minute
+
+	// deferralCheckInterval is how often the waiting deferred pods are checked for a valley or their deadline
+	deferralCheckInterval = time.Minute
+
+	// valleyMargin is how close to the lowest forecast load before the deadline the current hour must be to be a valley
+	valleyMargin = 0.05
+
+	// ValleyDeferralResult, DeadlineDeferralResult and RejectedDeferralResult are how the wait of a deferred pod ended,
+	// a pod is rejected when its wait times out or another plugin rejects it
+	ValleyDeferralResult   = "valley"
+	DeadlineDeferralResult = "deadline"
+	RejectedDeferralResult = "rejected"
+)
+
+var _ framework.PermitPlugin = &TemporalUtilization{}
+
+// deferredPod is a pod waiting at Permit for a valley of its node
+type deferredPod struct {
+	nodeName string
+	deadline time.Time
+	since    time.Time
+}
+
+// podDeferrals is the deferred pods waiting at Permit by uid
+type podDeferrals struct {
+	sync.Mutex
+	pods map[types.UID]deferredPod
+
+	nodeLister corelisters.NodeLister
+}
+
+func newPodDeferrals(handle framework.Handle) *podDeferrals {
+	return &podDeferrals{
+		pods:       make(map[types.UID]deferredPod),
+		nodeLister: handle.SharedInformerFactory().Core().V1().Nodes().Lister(),
+	}
+}
+
+func (d *podDeferrals) add(uid types.UID, pod deferredPod) {
+	d.Lock()
+	defer d.Unlock()
+	d.pods[uid] = pod
+	deferredPods.Set(float64(len(d.pods)))
+}
+
+// remove forgets the deferred pod and reports its wait, false when the pod was not deferred
+func (d *podDeferrals) remove(uid types.UID, result string, now time.Time) bool {
+	d.Lock()
+	defer d.Unlock()
+	pod, ok := d.pods[uid]
+	if !ok {
+		return false
+	}
+	delete(d.pods, uid)
+	deferredPods.Set(float64(len(d.pods)))
+	deferredPodWaitSeconds.WithLabelValues(result).Observe(now.Sub(pod.since).Seconds())
+	return true
+}
+
+func (d *podDeferrals) get(uid types.UID) (deferredPod, bool) {
+	d.Lock()
+	defer d.Unlock()
+	pod, ok := d.pods[uid]
+	return pod, ok
+}
+
+// podDeferralDeadline returns the deadline of a deferrable pod, either a RFC3339 time or a duration from the creation of the pod.
+// It returns false when the pod is not deferrable
+func podDeferralDeadline(pod *v1.Pod) (time.Time, bool, error) {
+	value, ok := pod.Annotations[schedv1alpha1.PodDeferrableDeadlineAnnotation]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	if deadline, err := time.Parse(time.RFC3339, value); err == nil {
+		return deadline, true, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return pod.CreationTimestamp.Add(d), true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid deferrable deadline %q, expected a RFC3339 time or a duration", value)
+}
+
+// Permit holds a deferrable pod in waiting until the forecasts of its node enter a valley before its deadline,
+// other pods are admitted right away
+func (pl *TemporalUtilization) Permit(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) (*framework.Status, time.Duration) {
+	deadline, ok, err := podDeferralDeadline(pod)
+	if err != nil {
+		klog.ErrorS(err, "Admitting the pod without deferral", "pod", klog.KObj(pod))
+		return nil, 0
+	}
+	if !ok {
+		return nil, 0
+	}
+
+	now := time.Now()
+	if !now.Before(deadline) {
+		return nil, 0
+	}
+
+	valley, err := pl.inValley(pod, nodeName, now, deadline)
+	if err != nil {
+		klog.ErrorS(err, "Admitting the pod without deferral", "pod", klog.KObj(pod), "node", nodeName)
+		return nil, 0
+	}
+	if valley {
+		return nil, 0
+	}
+
+	// the pod is admitted at its deadline by the deferral check, not rejected by the framework timeout
+	timeout := deadline.Sub(now) + deferralCheckInterval
+	if timeout > maxDeferralWait {
+		timeout = maxDeferralWait
+	}
+	pl.deferrals.add(pod.UID, deferredPod{nodeName: nodeName, deadline: deadline, since: now})
+
+	klog.V(4).InfoS("Deferring the pod to a valley of the node", "pod", klog.KObj(pod), "node", nodeName, "deadline", deadline)
+	return framework.NewStatus(framework.Wait, "deferred to a valley of the node"), timeout
+}
+
+// runDeferrals checks the waiting deferred pods until the context is done
+func (pl *TemporalUtilization) runDeferrals(ctx context.Context) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		pl.allowDeferredPods(time.Now())
+	}, deferralCheckInterval)
+}
+
+// allowDeferredPods admits the waiting deferred pods whose node is in a valley or whose deadline is reached
+func (pl *TemporalUtilization) allowDeferredPods(now time.Time) {
+	var allowed []framework.WaitingPod
+	results := make(map[types.UID]string)
+	pl.handle.IterateOverWaitingPods(func(wp framework.WaitingPod) {
+		uid := wp.GetPod().UID
+		deferred, ok := pl.deferrals.get(uid)
+		if !ok {
+			return
+		}
+
+		if !now.Before(deferred.deadline) {
+			allowed, results[uid] = append(allowed, wp), DeadlineDeferralResult
+			return
+		}
+
+		valley, err := pl.inValley(wp.GetPod(), deferred.nodeName, now, deferred.deadline)
+		if err != nil {
+			// the pod waits until its timeout at the latest
+			klog.ErrorS(err, "Unable to check the valley of the deferred pod", "pod", klog.KObj(wp.GetPod()), "node", deferred.nodeName)
+			return
+		}
+		if valley {
+			allowed, results[uid] = append(allowed, wp), ValleyDeferralResult
+		}
+	})
+
+	// the waiting pods are allowed out of the iteration, which holds the lock of the waiting pods
+	for _, wp := range allowed {
+		pod := wp.GetPod()
+		if pl.deferrals.remove(pod.UID, results[pod.UID], now) {
+			klog.V(4).InfoS("Admitting the deferred pod", "pod", klog.KObj(pod), "result", results[pod.UID])
+			wp.Allow(Name)
+		}
+	}
+}
+
+// inValley returns whether the forecast load of the node at the current hour is within the valley margin
+// of its lowest forecast load until the deadline. The load is the one of the node without the deferred pod,
+// which is reserved on the node and would otherwise raise the hours from now on
+func (pl *TemporalUtilization) inValley(pod *v1.Pod, nodeName string, now, deadline time.Time) (bool, error) {
+	node, err := pl.deferrals.nodeLister.Get(nodeName)
+	if err != nil {
+		return false, err
+	}
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(node)
+
+	forecasts, _, err := obtainForecasts(pl.utMgr, nodeInfo, nodeName, nil, pl.SupportedTargetResources())
+	if err != nil {
+		return false, err
+	}
+
+	if isReservedOn(pl.utMgr, pod, nodeName) {
+		podUsages, err := getPodUsages(pl.utMgr, pod, pl.SupportedTargetResources())
+		if err != nil {
+			return false, err
+		}
+		subtractUsages(forecasts, podUsages)
+	}
+
+	hours := int(math.Ceil(deadline.Sub(now).Hours()))
+	return isValley(forecasts, nodeInfo, horizonSlots(now, hours, NoDecay, 0)), nil
+}
+
+// isReservedOn returns whether the pod is in the node pods cache of the node, i.e. its usages are in the node forecasts
+func isReservedOn(utMgr *UsageTemplateManager, pod *v1.Pod, nodeName string) bool {
+	for _, namespacedPod := range utMgr.GetNodePods(nodeName) {
+		if namespacedPod.Namespace == pod.Namespace && namespacedPod.Name == pod.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// isValley returns whether the load of the first slot is within the valley margin of the lowest load of the slots,
+// the load of a slot is the highest ratio of the forecasts to the capacity of the node among the resources
+func isValley(forecasts map[string]*UsageTemplate, nodeInfo *framework.NodeInfo, slots []hourSlot) bool {
+	if len(slots) == 0 {
+		return true
+	}
+
+	loads := make([]float64, len(slots))
+	for resource, forecast := range forecasts {
+		_, capacity, ok := temporalCapacity(nodeInfo, resource)
+		if !ok || capacity <= 0 || forecast == nil {
+			continue
+		}
+		for i, slot := range slots {
+			if value, ok := forecast.valueAt(slot); ok {
+				loads[i] = math.Max(loads[i], float64(value)/float64(capacity))
+			}
+		}
+	}
+
+	lowest := loads[0]
+	for _, load := range loads[1:] {
+		lowest = math.Min(lowest, load)
+	}
+	return loads[0] <= lowest+valleyMargin
+}
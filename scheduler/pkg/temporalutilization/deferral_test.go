@@ -0,0 +1,173 @@
+package temporalutilization
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	testutils "gitee.com/openeuler/paws/scheduler/pkg/test/util"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	testClientSet "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+)
+
+func TestPodDeferralDeadline(t *testing.T) {
+	created := time.Date(2024, 11, 4, 10, 0, 0, 0, time.UTC)
+	pod := func(deadline string) *v1.Pod {
+		p := st.MakePod().Name("batch").Obj()
+		p.CreationTimestamp = metav1.NewTime(created)
+		if deadline != "" {
+			p.Annotations = map[string]string{v1alpha1.PodDeferrableDeadlineAnnotation: deadline}
+		}
+		return p
+	}
+
+	_, ok, err := podDeferralDeadline(pod(""))
+	assert.False(t, ok)
+	assert.Nil(t, err)
+
+	deadline, ok, err := podDeferralDeadline(pod("2024-11-04T18:00:00Z"))
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.True(t, deadline.Equal(created.Add(8*time.Hour)))
+
+	deadline, ok, err = podDeferralDeadline(pod("6h"))
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.True(t, deadline.Equal(created.Add(6*time.Hour)))
+
+	_, ok, err = podDeferralDeadline(pod("tonight"))
+	assert.False(t, ok)
+	assert.NotNil(t, err)
+}
+
+func TestIsValley(t *testing.T) {
+	node := st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{
+		v1.ResourceCPU:    "1000m",
+		v1.ResourceMemory: "1000",
+	}).Obj()
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(node)
+
+	slots := []hourSlot{{isWeekday: true, hour: 1}, {isWeekday: true, hour: 2}, {isWeekday: true, hour: 3}}
+	forecasts := map[string]*UsageTemplate{
+		v1.ResourceCPU.String(): {
+			weekDayHour: map[int16]float32{1: 500, 2: 300, 3: 480},
+		},
+	}
+	assert.False(t, isValley(forecasts, nodeInfo, slots))
+	// within the margin of the lowest load
+	assert.True(t, isValley(forecasts, nodeInfo, slots[2:]))
+	assert.True(t, isValley(forecasts, nodeInfo, []hourSlot{{isWeekday: true, hour: 3}, {isWeekday: true, hour: 1}}))
+	assert.True(t, isValley(forecasts, nodeInfo, nil))
+
+	// the load is the highest among the resources
+	forecasts[v1.ResourceMemory.String()] = &UsageTemplate{
+		weekDayHour: map[int16]float32{1: 100, 2: 900, 3: 100},
+	}
+	assert.True(t, isValley(forecasts, nodeInfo, slots))
+}
+
+func TestTemporalUtilizationPermitDeferrablePod(t *testing.T) {
+	node := st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{
+		v1.ResourceCPU: "1000m",
+	}).Obj()
+
+	// the node peaks at the current and the next hour, in case the hour turns during the test
+	now := time.Now().UTC()
+	usages := testutils.SameUsageADay(100)
+	usages[now.Hour()] = 900
+	usages[now.Add(time.Hour).Hour()] = 900
+	labels := map[string]string{v1alpha1.UsageTemplateLabelIdentifier: "online"}
+	online := st.MakePod().Namespace("default").Name("online-1").UID("online-1").Node("node-1").Labels(labels).Obj()
+	mgr := newTestUsageEvaluationManager([]*v1.Node{node}, online, []*v1.Pod{online}, []*v1alpha1.UsageTemplate{
+		testutils.MakeUsageTemplate("online", "default", true, "BestEffort",
+			map[string]map[int]float32{"cpu": usages},
+			map[string]map[int]float32{"cpu": usages}, true),
+	})
+	mgr.OnAdd(online)
+
+	nodeInformer := informers.NewSharedInformerFactory(testClientSet.NewSimpleClientset(), 0).Core().V1().Nodes()
+	assert.Nil(t, nodeInformer.Informer().GetStore().Add(node))
+	pl := &TemporalUtilization{
+		utMgr: mgr,
+		deferrals: &podDeferrals{
+			pods:       make(map[types.UID]deferredPod),
+			nodeLister: nodeInformer.Lister(),
+		},
+	}
+
+	ctx := context.Background()
+	deferrable := func(deadline time.Duration) *v1.Pod {
+		p := st.MakePod().Namespace("default").Name("batch-1").UID("batch-1").Obj()
+		p.CreationTimestamp = metav1.NewTime(now)
+		p.Annotations = map[string]string{v1alpha1.PodDeferrableDeadlineAnnotation: deadline.String()}
+		return p
+	}
+
+	// a pod without the annotation is admitted right away
+	status, timeout := pl.Permit(ctx, framework.NewCycleState(), st.MakePod().Name("web-1").Obj(), "node-1")
+	assert.True(t, status.IsSuccess())
+	assert.Zero(t, timeout)
+
+	// the node is heading into a valley before the deadline, the pod waits at most the framework timeout
+	status, timeout = pl.Permit(ctx, framework.NewCycleState(), deferrable(6*time.Hour), "node-1")
+	assert.Equal(t, framework.Wait, status.Code())
+	assert.Equal(t, maxDeferralWait, timeout)
+	_, ok := pl.deferrals.get("batch-1")
+	assert.True(t, ok)
+
+	// the wait is reported when the pod is unreserved
+	assert.True(t, pl.deferrals.remove("batch-1", RejectedDeferralResult, time.Now()))
+	assert.False(t, pl.deferrals.remove("batch-1", RejectedDeferralResult, time.Now()))
+
+	// the deadline forces the admission
+	status, _ = pl.Permit(ctx, framework.NewCycleState(), deferrable(0), "node-1")
+	assert.True(t, status.IsSuccess())
+}
+
+func TestTemporalUtilizationPermitWithoutReservedPodUsages(t *testing.T) {
+	node := st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{
+		v1.ResourceCPU: "1000m",
+	}).Obj()
+
+	// the node is flat, the deferrable job peaks during its first 3 hours
+	onlineLabels := map[string]string{v1alpha1.UsageTemplateLabelIdentifier: "online"}
+	batchLabels := map[string]string{v1alpha1.UsageTemplateLabelIdentifier: "batch"}
+	online := st.MakePod().Namespace("default").Name("online-1").UID("online-1").Node("node-1").Labels(onlineLabels).Obj()
+	batch := st.MakePod().Namespace("default").Name("batch-1").UID("batch-1").Labels(batchLabels).Obj()
+	batch.CreationTimestamp = metav1.NewTime(time.Now())
+	batch.Annotations = map[string]string{v1alpha1.PodDeferrableDeadlineAnnotation: "6h"}
+
+	jobUsages := map[string]map[int]float32{"cpu": testutils.MakeUsageAcrossPeriods([][]float32{{0.0, 3.0, 500}})}
+	mgr := newTestUsageEvaluationManager([]*v1.Node{node}, batch, []*v1.Pod{online}, []*v1alpha1.UsageTemplate{
+		testutils.MakeUsageTemplate("online", "default", true, "BestEffort",
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(300)},
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(300)}, true),
+		testutils.MakeUsageTemplate("batch", "default", true, "BestEffort", jobUsages, jobUsages, false),
+	})
+	mgr.OnAdd(online)
+	// Reserve adds the pod to the node forecasts before Permit
+	mgr.addToCacheIfNotExists(batch, "node-1")
+
+	nodeInformer := informers.NewSharedInformerFactory(testClientSet.NewSimpleClientset(), 0).Core().V1().Nodes()
+	assert.Nil(t, nodeInformer.Informer().GetStore().Add(node))
+	pl := &TemporalUtilization{
+		utMgr: mgr,
+		deferrals: &podDeferrals{
+			pods:       make(map[types.UID]deferredPod),
+			nodeLister: nodeInformer.Lister(),
+		},
+	}
+
+	// the usages of the pod itself do not make the current hours a peak
+	status, timeout := pl.Permit(context.Background(), framework.NewCycleState(), batch, "node-1")
+	assert.True(t, status.IsSuccess())
+	assert.Zero(t, timeout)
+}
@@ -26,6 +26,9 @@ type dateTimeEstimator struct {
 	// should have length of 48, where the first 24 hours are weekdays,
 	// the last 24 hours are of weekends
 	Histograms []hourEstimator
+	// oldestSample and newestSample are the time range of the samples added
+	oldestSample time.Time
+	newestSample time.Time
 }
 
 type hourEstimator struct {
@@ -33,6 +36,8 @@ type hourEstimator struct {
 	Hour int
 	kvpa.Histogram
 	IsWeekday bool
+	// Samples is the number of samples added to the histogram
+	Samples int
 }
 
 func makeExpHistogram() (kvpa.Histogram, error) {
@@ -76,6 +81,54 @@ func NewDateTimeEstimator() (*dateTimeEstimator, error) {
 
 func (de *dateTimeEstimator) addSample(hour int, v float64, weight float64, time time.Time) {
 	de.Histograms[hour].AddSample(v, weight, time)
+	de.Histograms[hour].Samples++
+
+	if de.oldestSample.IsZero() || time.Before(de.oldestSample) {
+		de.oldestSample = time
+	}
+	if time.After(de.newestSample) {
+		de.newestSample = time
+	}
+}
+
+// ObservedHours returns the number of hours between the oldest and the newest sample
+func (de *dateTimeEstimator) ObservedHours() int {
+	if de.oldestSample.IsZero() {
+		return 0
+	}
+	return int(de.newestSample.Sub(de.oldestSample).Hours())
+}
+
+// CoveredHours returns the number of hour histograms which have samples
+func (de *dateTimeEstimator) CoveredHours() int {
+	covered := 0
+	for i := range de.Histograms {
+		if de.Histograms[i].Samples > 0 {
+			covered++
+		}
+	}
+	return covered
+}
+
+// Confidence scores between 0 and 100 how much the histograms can be trusted.
+// It is the ratio of the observed hours to the evaluation window, and for long running
+// applications, it is further multiplied by the ratio of the covered hours, since
+// every hour of the weekdays and weekends is expected to have samples.
+func (de *dateTimeEstimator) Confidence(window time.Duration) int {
+	if window <= 0 || len(de.Histograms) == 0 {
+		return 0
+	}
+
+	confidence := float64(de.ObservedHours()) / window.Hours()
+	if confidence > 1 {
+		confidence = 1
+	}
+
+	if de.IsLongRunning() {
+		confidence *= float64(de.CoveredHours()) / float64(len(de.Histograms))
+	}
+
+	return int(confidence * 100)
 }
 
 // IsLongRunning checks whether the application has run longer than 24 hours
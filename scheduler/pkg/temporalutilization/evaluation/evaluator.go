@@ -39,6 +39,9 @@ type UsageEvaluator struct {
 	recorder             record.EventRecorder
 	promClient           *PromClient
 	evaluationResolution time.Duration
+	// nodeMetricLabel is the label of the usage timeseries with the node name,
+	// the usages are not normalized by the node performance factor when it is empty
+	nodeMetricLabel string
 
 	// a synchronization queue for all the periodic evaluation
 	// to avoid too many spin off goroutines.
@@ -54,8 +57,8 @@ func CompFn(obj1, obj2 interface{}) bool {
 	return q1.NextEvaluationTime.Before(q2.NextEvaluationTime)
 }
 
-func NewUsageEvaluator(c client.Client, reconcilerScheme *runtime.Scheme, evaluationResolution, globalHTTPTimeout time.Duration, recorder record.EventRecorder, promAddress string) (*UsageEvaluator, error) {
-	pClient, err := NewPromClient(promAddress)
+func NewUsageEvaluator(c client.Client, reconcilerScheme *runtime.Scheme, evaluationResolution, globalHTTPTimeout time.Duration, recorder record.EventRecorder, promAddress string, promQueryCacheTTL time.Duration, promQueryCacheSize int, nodeMetricLabel string) (*UsageEvaluator, error) {
+	pClient, err := NewPromClient(promAddress, promQueryCacheTTL, promQueryCacheSize)
 	if err != nil {
 		log.Error(err, "unable to create prometheus client", "PromAddress", promAddress)
 		return nil, err
@@ -68,6 +71,7 @@ func NewUsageEvaluator(c client.Client, reconcilerScheme *runtime.Scheme, evalua
 		recorder:             recorder,
 		promClient:           pClient,
 		evaluationResolution: evaluationResolution,
+		nodeMetricLabel:      nodeMetricLabel,
 		clock:                clock.RealClock{},
 		evaluationQ:          kcache.NewHeap(kcache.MetaNamespaceKeyFunc, CompFn),
 		mu:                   &sync.RWMutex{},
@@ -176,7 +180,7 @@ func (ue *UsageEvaluator) evaluateResources(ctx context.Context, logger logr.Log
 }
 
 func (ue *UsageEvaluator) evaluateResource(ctx context.Context, resourceType string, ut *schedv1alpha1.UsageTemplate) {
-	query, err := ue.buildUsageQuery(ut.Spec.Filters, resourceType, ut.Spec.JoinFilters, ut.Spec.JoinLabels)
+	query, err := ue.buildQuery(ut, resourceType)
 	if err != nil {
 		log.Error(err, "unable to build query", "Resource", resourceType, "Filters", ut.Spec.Filters)
 		utils.UpdateReadyConditions(ctx, ue.client, log, ut, metav1.ConditionFalse, "Unable to build Prometheus Query", "BuildUsageQueryError")
@@ -185,13 +189,10 @@ func (ue *UsageEvaluator) evaluateResource(ctx context.Context, resourceType str
 
 	end := time.Now().UTC()
 
-	evaluationDays := v1alpha1.DefaultEvaluationWindowDays
-	if ut.Spec.EvaluationWindowDays != nil {
-		evaluationDays = int(*ut.Spec.EvaluationWindowDays)
-	}
+	window := ue.evaluationWindow(ctx, ut)
 
 	// inverse
-	start := end.AddDate(0, 0, -evaluationDays)
+	start := end.Add(-window)
 
 	metricTS, err := ue.promClient.FetchQueryRange(ctx, query, ue.globalHTTPTimeout, start, end, ue.evaluationResolution, log)
 	if err != nil {
@@ -200,6 +201,13 @@ func (ue *UsageEvaluator) evaluateResource(ctx context.Context, resourceType str
 		return
 	}
 
+	metricTS, err = ue.normalizeByNode(ctx, metricTS, resourceType)
+	if err != nil {
+		log.Error(err, "failed to normalize usages by node performance factor", "Resource", resourceType)
+		utils.UpdateReadyConditions(ctx, ue.client, log, ut, metav1.ConditionFalse, "Unable to normalize usages by node", "NormalizeUsageError")
+		return
+	}
+
 	// aggregate into per hour samples for a histogram
 	// TODO: how much overhead here to rebuild this everytime
 	h, err := ue.buildHistogram(metricTS)
@@ -210,7 +218,7 @@ func (ue *UsageEvaluator) evaluateResource(ctx context.Context, resourceType str
 	}
 
 	// take the percentile value from it
-	err = ue.estimateHourUsage(ctx, ut, h, resourceType, v1alpha1.SupportedResourceMetricScalingFactor[resourceType])
+	err = ue.estimateHourUsage(ctx, ut, h, window, resourceType, v1alpha1.SupportedResourceMetricScalingFactor[resourceType])
 	if err != nil {
 		log.Error(err, "failed to estimate hourly usage", "Resource", resourceType)
 		utils.UpdateReadyConditions(ctx, ue.client, log, ut, metav1.ConditionFalse, "Unable to estimate hourly usage", "EstimateHourlyUsageError")
@@ -219,6 +227,42 @@ func (ue *UsageEvaluator) evaluateResource(ctx context.Context, resourceType str
 	log.V(3).Info("successfully evaluated usage template", "usageTemplate", GetNamespacedName(ut), "Query", query)
 }
 
+// evaluationWindow returns the EvaluationWindowDays of the UsageTemplate,
+// shortened to the prometheus retention when the retention is shorter
+func (ue *UsageEvaluator) evaluationWindow(ctx context.Context, ut *schedv1alpha1.UsageTemplate) time.Duration {
+	evaluationDays := v1alpha1.DefaultEvaluationWindowDays
+	if ut.Spec.EvaluationWindowDays != nil {
+		evaluationDays = int(*ut.Spec.EvaluationWindowDays)
+	}
+	window := time.Duration(evaluationDays) * 24 * time.Hour
+
+	retention, err := ue.promClient.FetchRetention(ctx, ue.globalHTTPTimeout)
+	if err != nil {
+		log.V(3).Info("unable to obtain prometheus retention, using the evaluation window", "usageTemplate", GetNamespacedName(ut), "error", err)
+		return window
+	}
+
+	if retention > 0 && retention < window {
+		log.V(3).Info("prometheus retention is shorter than the evaluation window", "usageTemplate", GetNamespacedName(ut),
+			"EvaluationWindowDays", evaluationDays, "Retention", retention.String())
+		return retention
+	}
+
+	return window
+}
+
+// buildQuery builds the usage query according to the UsageTemplate join mode
+func (ue *UsageEvaluator) buildQuery(ut *schedv1alpha1.UsageTemplate, resourceType string) (string, error) {
+	switch ut.Spec.JoinMode {
+	case schedv1alpha1.KubeStateMetricsJoinMode:
+		return ue.buildKubeStateMetricsUsageQuery(ut, resourceType)
+	case "", schedv1alpha1.CAdvisorLabelsJoinMode:
+		return ue.buildUsageQuery(ut.Spec.Filters, resourceType, ut.Spec.JoinFilters, ut.Spec.JoinLabels)
+	default:
+		return "", fmt.Errorf("join mode %q not supported", ut.Spec.JoinMode)
+	}
+}
+
 // Because cAdvisor by default only assign the 'whitelistedlabels' on the top level layer 'pause' container,
 // we have to use the 'group_left' functionality to join the labels we need back to the original container usage timeseries
 // an example of this is the following
@@ -257,14 +301,50 @@ func (ue *UsageEvaluator) buildUsageQuery(rateFilters []string,
 	}
 
 	if len(joinFilters) > 0 && len(joinLabels) > 0 {
+		// keep the node label so that the usages can be normalized by the node they were measured on
+		byLabels := append([]string{}, joinLabels...)
+		byLabels = append(byLabels, "container")
+		if ue.nodeMetricLabel != "" {
+			byLabels = append(byLabels, ue.nodeMetricLabel)
+		}
 		// avg by (part_of, container) ((rate(container_cpu_usage_seconds_total{container="nginx-random"})) + on (namespace,pod) group_left(part_of) ( 0 * container_cpu_usage_seconds_total{part_of!="",namespace="default"}))
-		pquery = fmt.Sprintf("avg by (%s,container) (%s + on (namespace,pod) group_left(%s) (0 * %s{%s}))",
-			strings.Join(joinLabels, ","), pquery, strings.Join(joinLabels, ","), metricLabel, strings.Join(joinFilters, ","))
+		pquery = fmt.Sprintf("avg by (%s) (%s + on (namespace,pod) group_left(%s) (0 * %s{%s}))",
+			strings.Join(byLabels, ","), pquery, strings.Join(joinLabels, ","), metricLabel, strings.Join(joinFilters, ","))
 	}
 
 	return pquery, nil
 }
 
+// normalizeByNode scales the usages of each timeseries by the performance factor of the node it was measured on,
+// so that the usages are in reference cores whichever nodes the application ran on.
+// The timeseries without the node label or on a node without a factor are left unchanged
+func (ue *UsageEvaluator) normalizeByNode(ctx context.Context, values model.Value, resourceType string) (model.Value, error) {
+	if _, ok := schedv1alpha1.SupportedPerformanceFactorAnnotation[resourceType]; !ok || ue.nodeMetricLabel == "" {
+		return values, nil
+	}
+
+	matrix, ok := values.(model.Matrix)
+	if !ok {
+		return values, nil
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := ue.client.List(ctx, nodes); err != nil {
+		return nil, err
+	}
+
+	factors := make(map[string]float64, len(nodes.Items))
+	for i := range nodes.Items {
+		factor, err := utils.GetNodePerformanceFactor(&nodes.Items[i], resourceType)
+		if err != nil {
+			log.V(3).Info("ignoring node performance factor", "node", nodes.Items[i].Name, "error", err)
+		}
+		factors[nodes.Items[i].Name] = factor
+	}
+
+	return NormalizeByNode(matrix, model.LabelName(ue.nodeMetricLabel), factors), nil
+}
+
 func (ue *UsageEvaluator) buildHistogram(values model.Value) (*dateTimeEstimator, error) {
 	// TODO: Evaluate whether we should cache the estimator
 	// Alternative is to create a LRU Histogram
@@ -305,7 +385,7 @@ func (ue *UsageEvaluator) buildHistogram(values model.Value) (*dateTimeEstimator
 	return h, err
 }
 
-func (ue *UsageEvaluator) estimateHourUsage(ctx context.Context, ut *schedv1alpha1.UsageTemplate, h *dateTimeEstimator, resourceType string, scaleFactor float64) error {
+func (ue *UsageEvaluator) estimateHourUsage(ctx context.Context, ut *schedv1alpha1.UsageTemplate, h *dateTimeEstimator, window time.Duration, resourceType string, scaleFactor float64) error {
 	// default to 95 percentile to be conservative
 	percentile := 0.95
 	if ut.Spec.QualityOfServiceClass != string(corev1.PodQOSGuaranteed) {
@@ -332,6 +412,7 @@ func (ue *UsageEvaluator) estimateHourUsage(ctx context.Context, ut *schedv1alph
 			Percentile: strconv.FormatFloat(percentile, 'f', -1, 64),
 			Unit:       resourceTypeUnit,
 			IsWeekday:  h.Histograms[i].IsWeekday,
+			Count:      int32(h.Histograms[i].Samples),
 		}
 		samples = append(samples, sample)
 	}
@@ -339,21 +420,50 @@ func (ue *UsageEvaluator) estimateHourUsage(ctx context.Context, ut *schedv1alph
 	// when patching we first create a new copy
 	status := ut.Status.DeepCopy()
 
-	// reset resource usages
-	status.HistoricalUsage = &schedv1alpha1.ResourceUsages{
-		Items: []schedv1alpha1.ResourceUsage{},
+	// reset the usages of the resource, keeping the usages of the other resources
+	items := []schedv1alpha1.ResourceUsage{}
+	if status.HistoricalUsage != nil {
+		for _, item := range status.HistoricalUsage.Items {
+			if item.Resource != resourceType {
+				items = append(items, item)
+			}
+		}
 	}
 
-	status.HistoricalUsage.Items = append(status.HistoricalUsage.Items, schedv1alpha1.ResourceUsage{
-		Resource: resourceType,
-		Usages:   samples,
-	})
+	status.HistoricalUsage = &schedv1alpha1.ResourceUsages{
+		Items: append(items, schedv1alpha1.ResourceUsage{
+			Resource: resourceType,
+			Usages:   samples,
+			Coverage: &schedv1alpha1.EvaluationCoverage{
+				WindowHours:   int32(window.Hours()),
+				ObservedHours: int32(h.ObservedHours()),
+				CoveredHours:  int32(h.CoveredHours()),
+				Confidence:    int32(h.Confidence(window)),
+			},
+		}),
+	}
 
 	status.IsLongRunning = h.IsLongRunning()
+	status.Coverage = lowestCoverage(status.HistoricalUsage.Items)
 
 	return utils.UpdateStatus(ctx, ue.client, log, ut, status)
 }
 
+// lowestCoverage returns the coverage of the resource with the lowest confidence,
+// so that the summary is not the one of whichever resource was evaluated last
+func lowestCoverage(items []schedv1alpha1.ResourceUsage) *schedv1alpha1.EvaluationCoverage {
+	var lowest *schedv1alpha1.EvaluationCoverage
+	for _, item := range items {
+		if item.Coverage != nil && (lowest == nil || item.Coverage.Confidence < lowest.Confidence) {
+			lowest = item.Coverage
+		}
+	}
+	if lowest == nil {
+		return nil
+	}
+	return lowest.DeepCopy()
+}
+
 func (ue *UsageEvaluator) evaluateOne(ctx context.Context) {
 	obj, err := ue.evaluationQ.Pop()
 	if err != nil {
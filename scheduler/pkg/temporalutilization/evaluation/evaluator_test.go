@@ -0,0 +1,22 @@
+package evaluation
+
+import (
+	"testing"
+
+	schedv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLowestCoverage(t *testing.T) {
+	assert.Nil(t, lowestCoverage(nil))
+	assert.Nil(t, lowestCoverage([]schedv1alpha1.ResourceUsage{{Resource: "cpu"}}))
+
+	// the summary is the least trusted resource, whichever was evaluated last
+	items := []schedv1alpha1.ResourceUsage{
+		{Resource: "cpu", Coverage: &schedv1alpha1.EvaluationCoverage{CoveredHours: 12, Confidence: 20}},
+		{Resource: "memory", Coverage: &schedv1alpha1.EvaluationCoverage{CoveredHours: 48, Confidence: 90}},
+	}
+	lowest := lowestCoverage(items)
+	assert.Equal(t, &schedv1alpha1.EvaluationCoverage{CoveredHours: 12, Confidence: 20}, lowest)
+	assert.NotSame(t, items[0].Coverage, lowest)
+}
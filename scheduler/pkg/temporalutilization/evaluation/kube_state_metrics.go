@@ -0,0 +1,172 @@
+package evaluation
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	schedv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	kubePodLabelsMetric = "kube_pod_labels"
+	kubePodOwnerMetric  = "kube_pod_owner"
+
+	// kubeStateMetricsLabelPrefix is the prefix kube-state-metrics adds to the pod labels in kube_pod_labels
+	kubeStateMetricsLabelPrefix = "label_"
+)
+
+// invalidLabelCharRE follows how kube-state-metrics sanitizes kubernetes label keys into prometheus label names
+var invalidLabelCharRE = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// ownerChain describes how a workload that does not own pods directly is resolved
+// through the intermediate owner, e.g. Deployment -> ReplicaSet -> Pod
+type ownerChain struct {
+	// intermediateKind is the owner_kind of the pods in kube_pod_owner
+	intermediateKind string
+	// ownerMetric is the kube-state-metrics metric mapping the intermediate object to its owner
+	ownerMetric string
+	// intermediateLabel is the label of ownerMetric holding the intermediate object name
+	intermediateLabel string
+}
+
+var (
+	// directOwnerKinds are the workloads that appear as owner_kind in kube_pod_owner
+	directOwnerKinds = map[string]bool{
+		"ReplicaSet":  true,
+		"StatefulSet": true,
+		"DaemonSet":   true,
+		"Job":         true,
+	}
+
+	indirectOwnerKinds = map[string]ownerChain{
+		"Deployment": {intermediateKind: "ReplicaSet", ownerMetric: "kube_replicaset_owner", intermediateLabel: "replicaset"},
+		"CronJob":    {intermediateKind: "Job", ownerMetric: "kube_job_owner", intermediateLabel: "job_name"},
+	}
+)
+
+// ValidateKubeStateMetricsJoin checks whether the UsageTemplate can be evaluated in KubeStateMetrics join mode
+func ValidateKubeStateMetricsJoin(spec *schedv1alpha1.UsageTemplateSpec) error {
+	if spec.PodSelector == nil && spec.Owner == nil {
+		return fmt.Errorf("expect podSelector or owner to be specified in %s join mode", schedv1alpha1.KubeStateMetricsJoinMode)
+	}
+
+	if spec.Owner != nil {
+		if len(spec.Owner.Name) == 0 {
+			return fmt.Errorf("expect owner name to be specified")
+		}
+		if _, ok := indirectOwnerKinds[spec.Owner.Kind]; !ok && !directOwnerKinds[spec.Owner.Kind] {
+			return fmt.Errorf("owner kind %q not supported", spec.Owner.Kind)
+		}
+	}
+
+	if spec.PodSelector != nil {
+		if _, err := podSelectorMatchers(spec.PodSelector); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildKubeStateMetricsUsageQuery builds the usage query for the pods selected by kube-state-metrics.
+// The usage timeseries are kept per pod and multiplied by the selector timeseries (always 1),
+// an example with both podSelector and owner is the following
+// rate(container_cpu_usage_seconds_total{container="nginx",namespace="default",container!=""}[2m])
+// * on (namespace,pod) group_left() max by (namespace,pod) (kube_pod_labels{namespace="default",label_app="nginx"})
+// * on (namespace,pod) group_left() max by (namespace,pod) (kube_pod_owner{namespace="default",owner_kind="StatefulSet",owner_name="nginx"})
+func (ue *UsageEvaluator) buildKubeStateMetricsUsageQuery(ut *schedv1alpha1.UsageTemplate, resourceType string) (string, error) {
+	if err := ValidateKubeStateMetricsJoin(&ut.Spec); err != nil {
+		return "", err
+	}
+
+	namespaceFilter := fmt.Sprintf("namespace=%q", ut.Namespace)
+
+	filters := append([]string{}, ut.Spec.Filters...)
+	filters = append(filters, namespaceFilter)
+	pquery, err := ue.buildUsageQuery(filters, resourceType, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if ut.Spec.PodSelector != nil {
+		matchers, err := podSelectorMatchers(ut.Spec.PodSelector)
+		if err != nil {
+			return "", err
+		}
+		selector := fmt.Sprintf("%s{%s}", kubePodLabelsMetric, strings.Join(append([]string{namespaceFilter}, matchers...), ","))
+		pquery = joinPodSelector(pquery, selector)
+	}
+
+	if ut.Spec.Owner != nil {
+		pquery = joinPodSelector(pquery, ownerSelector(ut.Spec.Owner, namespaceFilter))
+	}
+
+	return pquery, nil
+}
+
+func joinPodSelector(pquery string, selector string) string {
+	return fmt.Sprintf("%s * on (namespace,pod) group_left() max by (namespace,pod) (%s)", pquery, selector)
+}
+
+func ownerSelector(owner *schedv1alpha1.WorkloadReference, namespaceFilter string) string {
+	chain, ok := indirectOwnerKinds[owner.Kind]
+	if !ok {
+		return fmt.Sprintf("%s{%s,owner_kind=%q,owner_name=%q}", kubePodOwnerMetric, namespaceFilter, owner.Kind, owner.Name)
+	}
+
+	// rename the intermediate object label to owner_name so that it joins the pods owner_name
+	intermediate := fmt.Sprintf("label_replace(%s{%s,owner_kind=%q,owner_name=%q}, \"owner_name\", \"$1\", %q, \"(.*)\")",
+		chain.ownerMetric, namespaceFilter, owner.Kind, owner.Name, chain.intermediateLabel)
+
+	return fmt.Sprintf("%s{%s,owner_kind=%q} * on (namespace,owner_name) group_left() max by (namespace,owner_name) (%s)",
+		kubePodOwnerMetric, namespaceFilter, chain.intermediateKind, intermediate)
+}
+
+// podSelectorMatchers translates a kubernetes label selector into kube_pod_labels matchers
+func podSelectorMatchers(selector *metav1.LabelSelector) ([]string, error) {
+	matchers := []string{}
+
+	keys := make([]string, 0, len(selector.MatchLabels))
+	for k := range selector.MatchLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", kubeStateMetricsLabelName(k), selector.MatchLabels[k]))
+	}
+
+	for _, expr := range selector.MatchExpressions {
+		name := kubeStateMetricsLabelName(expr.Key)
+		switch expr.Operator {
+		case metav1.LabelSelectorOpIn, metav1.LabelSelectorOpNotIn:
+			if len(expr.Values) == 0 {
+				return nil, fmt.Errorf("expect values for operator %s on key %s", expr.Operator, expr.Key)
+			}
+			values := make([]string, 0, len(expr.Values))
+			for _, v := range expr.Values {
+				values = append(values, regexp.QuoteMeta(v))
+			}
+			op := "=~"
+			if expr.Operator == metav1.LabelSelectorOpNotIn {
+				op = "!~"
+			}
+			matchers = append(matchers, fmt.Sprintf("%s%s%q", name, op, strings.Join(values, "|")))
+		case metav1.LabelSelectorOpExists:
+			matchers = append(matchers, fmt.Sprintf("%s!=\"\"", name))
+		case metav1.LabelSelectorOpDoesNotExist:
+			matchers = append(matchers, fmt.Sprintf("%s=\"\"", name))
+		default:
+			return nil, fmt.Errorf("label selector operator %s not supported", expr.Operator)
+		}
+	}
+
+	return matchers, nil
+}
+
+func kubeStateMetricsLabelName(key string) string {
+	return kubeStateMetricsLabelPrefix + invalidLabelCharRE.ReplaceAllString(key, "_")
+}
@@ -0,0 +1,90 @@
+package evaluation
+
+import (
+	"testing"
+
+	schedv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildKubeStateMetricsUsageQuery(t *testing.T) {
+	rateQuery := `rate(container_cpu_usage_seconds_total{container="nginx",namespace="default",container!=""}[2m])`
+
+	tests := []struct {
+		name        string
+		spec        schedv1alpha1.UsageTemplateSpec
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name: "select pods by kubernetes labels",
+			spec: schedv1alpha1.UsageTemplateSpec{
+				Filters: []string{`container="nginx"`},
+				PodSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app.kubernetes.io/name": "nginx"},
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"web", "edge.v2"}},
+						{Key: "canary", Operator: metav1.LabelSelectorOpDoesNotExist},
+					},
+				},
+			},
+			expected: rateQuery + ` * on (namespace,pod) group_left() max by (namespace,pod) ` +
+				`(kube_pod_labels{namespace="default",label_app_kubernetes_io_name="nginx",label_tier=~"web|edge\\.v2",label_canary=""})`,
+		},
+		{
+			name: "select pods by direct owner",
+			spec: schedv1alpha1.UsageTemplateSpec{
+				Filters: []string{`container="nginx"`},
+				Owner:   &schedv1alpha1.WorkloadReference{Kind: "StatefulSet", Name: "nginx"},
+			},
+			expected: rateQuery + ` * on (namespace,pod) group_left() max by (namespace,pod) ` +
+				`(kube_pod_owner{namespace="default",owner_kind="StatefulSet",owner_name="nginx"})`,
+		},
+		{
+			name: "select pods by deployment through its replicasets",
+			spec: schedv1alpha1.UsageTemplateSpec{
+				Filters: []string{`container="nginx"`},
+				Owner:   &schedv1alpha1.WorkloadReference{Kind: "Deployment", Name: "nginx"},
+			},
+			expected: rateQuery + ` * on (namespace,pod) group_left() max by (namespace,pod) ` +
+				`(kube_pod_owner{namespace="default",owner_kind="ReplicaSet"} * on (namespace,owner_name) group_left() max by (namespace,owner_name) ` +
+				`(label_replace(kube_replicaset_owner{namespace="default",owner_kind="Deployment",owner_name="nginx"}, "owner_name", "$1", "replicaset", "(.*)")))`,
+		},
+		{
+			name: "missing selector",
+			spec: schedv1alpha1.UsageTemplateSpec{
+				Filters: []string{`container="nginx"`},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "unsupported owner kind",
+			spec: schedv1alpha1.UsageTemplateSpec{
+				Filters: []string{`container="nginx"`},
+				Owner:   &schedv1alpha1.WorkloadReference{Kind: "Pod", Name: "nginx"},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ue := &UsageEvaluator{}
+			tt.spec.JoinMode = schedv1alpha1.KubeStateMetricsJoinMode
+			ut := &schedv1alpha1.UsageTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+				Spec:       tt.spec,
+			}
+
+			query, err := ue.buildQuery(ut, "cpu")
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, query)
+		})
+	}
+}
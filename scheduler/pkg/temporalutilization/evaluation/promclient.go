@@ -2,25 +2,51 @@ package evaluation
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
+	prommetrics "gitee.com/openeuler/paws/scheduler/pkg/metrics"
 	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/api"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
+	"golang.org/x/sync/singleflight"
+	kcache "k8s.io/apimachinery/pkg/util/cache"
 )
 
 type PromClient struct {
 	client api.Client
+
+	// queryCache stores range query results shared across usage templates with identical queries,
+	// it is nil when caching is disabled
+	queryCache *kcache.LRUExpireCache
+	cacheTTL   time.Duration
+	// inflight coalesces concurrent identical range queries into a single request
+	inflight singleflight.Group
+
+	// retention is the storage retention of prometheus, fetched again once it expires
+	retentionLock    sync.Mutex
+	retention        time.Duration
+	retentionExpires time.Time
 }
 
 const (
 	DefaultPromAddress = "http://prometheus-kube-prometheus-stack-prometheus:9090"
+
+	// DefaultQueryCacheTTL is how long a range query result is reused
+	DefaultQueryCacheTTL = 5 * time.Minute
+	// DefaultQueryCacheSize is the maximum number of range query results kept in memory
+	DefaultQueryCacheSize = 128
+	// retentionTTL is how long the storage retention of prometheus is reused, it only changes on a restart
+	retentionTTL = time.Hour
 )
 
 // NewPromClient returns a prometheus client
+// a cacheTTL or cacheSize less than or equal to 0 disables the query result cache
 // TODO: TLS connect
-func NewPromClient(promAddress string) (*PromClient, error) {
+func NewPromClient(promAddress string, cacheTTL time.Duration, cacheSize int) (*PromClient, error) {
 
 	targetAddress := DefaultPromAddress
 	if len(promAddress) > 0 {
@@ -36,10 +62,69 @@ func NewPromClient(promAddress string) (*PromClient, error) {
 		return nil, err
 	}
 
-	return &PromClient{client: client}, nil
+	pc := &PromClient{client: client}
+	if cacheTTL > 0 && cacheSize > 0 {
+		pc.queryCache = kcache.NewLRUExpireCache(cacheSize)
+		pc.cacheTTL = cacheTTL
+	}
+
+	return pc, nil
 }
 
+// FetchQueryRange runs a range query against prometheus.
+// When the query cache is enabled, the range is aligned to the step so that usage templates
+// with identical queries evaluated within the same step share the result.
+// NOTE: the returned value may be shared between callers and must be treated as read-only.
 func (pc *PromClient) FetchQueryRange(ctx context.Context, query string, timeout time.Duration, start, end time.Time, step time.Duration, logger logr.Logger) (model.Value, error) {
+	if pc.queryCache == nil {
+		return pc.queryRange(ctx, query, timeout, start, end, step, logger)
+	}
+
+	if step > 0 {
+		start = start.Truncate(step)
+		end = end.Truncate(step)
+	}
+
+	key := queryCacheKey(query, start, end, step)
+	if value, ok := pc.queryCache.Get(key); ok {
+		prommetrics.IncrementPromQueryCacheHit()
+		logger.V(6).Info("Prometheus query cache hit", "Query", query)
+		return value.(model.Value), nil
+	}
+
+	// the shared query is detached from the context of the caller that started it,
+	// so that a canceled caller does not fail the callers waiting for the same query
+	queried := false
+	results := pc.inflight.DoChan(key, func() (interface{}, error) {
+		queried = true
+		prommetrics.IncrementPromQueryCacheMiss()
+		result, err := pc.queryRange(context.Background(), query, timeout, start, end, step, logger)
+		if err != nil {
+			return nil, err
+		}
+		pc.queryCache.Add(key, result, pc.cacheTTL)
+		return result, nil
+	})
+
+	var res singleflight.Result
+	select {
+	case res = <-results:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+
+	if !queried {
+		prommetrics.IncrementPromQueryCacheHit()
+		logger.V(6).Info("Prometheus query coalesced with an in-flight query", "Query", query)
+	}
+
+	return res.Val.(model.Value), nil
+}
+
+func (pc *PromClient) queryRange(ctx context.Context, query string, timeout time.Duration, start, end time.Time, step time.Duration, logger logr.Logger) (model.Value, error) {
 	pv1 := v1.NewAPI(pc.client)
 	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
@@ -51,7 +136,7 @@ func (pc *PromClient) FetchQueryRange(ctx context.Context, query string, timeout
 	})
 
 	if len(warnings) > 0 {
-		logger.V(2).Info("Warning from prom: %v", warnings)
+		logger.V(2).Info("Warning from prom", "Warnings", warnings)
 	}
 
 	if err != nil {
@@ -60,3 +145,53 @@ func (pc *PromClient) FetchQueryRange(ctx context.Context, query string, timeout
 
 	return value, err
 }
+
+// FetchRetention returns the time based storage retention of prometheus,
+// e.g. 15d for a prometheus started with "--storage.tsdb.retention.time=15d".
+// The retention is cached for retentionTTL, the errors are not
+func (pc *PromClient) FetchRetention(ctx context.Context, timeout time.Duration) (time.Duration, error) {
+	pc.retentionLock.Lock()
+	defer pc.retentionLock.Unlock()
+
+	now := time.Now()
+	if now.Before(pc.retentionExpires) {
+		return pc.retention, nil
+	}
+
+	pv1 := v1.NewAPI(pc.client)
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	info, err := pv1.Runtimeinfo(timeoutCtx)
+	if err != nil {
+		return 0, err
+	}
+
+	retention, err := parseStorageRetention(info.StorageRetention)
+	if err != nil {
+		return 0, err
+	}
+
+	pc.retention, pc.retentionExpires = retention, now.Add(retentionTTL)
+	return retention, nil
+}
+
+// parseStorageRetention parses the retention reported by prometheus runtime info,
+// which may also contain a size based retention, e.g. "15d or 10GiB"
+func parseStorageRetention(retention string) (time.Duration, error) {
+	fields := strings.Fields(retention)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty storage retention")
+	}
+
+	d, err := model.ParseDuration(fields[0])
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse storage retention %q: %w", retention, err)
+	}
+
+	return time.Duration(d), nil
+}
+
+func queryCacheKey(query string, start, end time.Time, step time.Duration) string {
+	return fmt.Sprintf("%s|%d|%d|%d", query, start.Unix(), end.Unix(), int64(step))
+}
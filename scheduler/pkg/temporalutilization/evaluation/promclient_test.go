@@ -0,0 +1,205 @@
+package evaluation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+)
+
+const testMatrixResponse = `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"container":"nginx"},"values":[[1700000000,"0.5"]]}]}}`
+
+func newTestPromServer(t *testing.T, requests *int32, delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(testMatrixResponse)); err != nil {
+			t.Errorf("unable to write response: %v", err)
+		}
+	}))
+}
+
+func TestPromClientQueryCache(t *testing.T) {
+	end := time.Date(2024, 10, 18, 12, 2, 0, 0, time.UTC)
+	start := end.AddDate(0, 0, -14)
+	step := 5 * time.Minute
+
+	tests := []struct {
+		name             string
+		cacheTTL         time.Duration
+		queries          []string
+		endOffsets       []time.Duration
+		expectedRequests int32
+	}{
+		{
+			name:             "cache disabled, every query hits prometheus",
+			cacheTTL:         0,
+			queries:          []string{"q1", "q1"},
+			endOffsets:       []time.Duration{0, 0},
+			expectedRequests: 2,
+		},
+		{
+			name:             "identical queries within the same step share the result",
+			cacheTTL:         time.Minute,
+			queries:          []string{"q1", "q1"},
+			endOffsets:       []time.Duration{0, time.Minute},
+			expectedRequests: 1,
+		},
+		{
+			name:             "different queries are cached separately",
+			cacheTTL:         time.Minute,
+			queries:          []string{"q1", "q2"},
+			endOffsets:       []time.Duration{0, 0},
+			expectedRequests: 2,
+		},
+		{
+			name:             "identical queries in different steps are cached separately",
+			cacheTTL:         time.Minute,
+			queries:          []string{"q1", "q1"},
+			endOffsets:       []time.Duration{0, step},
+			expectedRequests: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := newTestPromServer(t, &requests, 0)
+			defer server.Close()
+
+			pc, err := NewPromClient(server.URL, tt.cacheTTL, DefaultQueryCacheSize)
+			assert.NoError(t, err)
+
+			for i, query := range tt.queries {
+				value, err := pc.FetchQueryRange(context.Background(), query, time.Second,
+					start.Add(tt.endOffsets[i]), end.Add(tt.endOffsets[i]), step, logr.Discard())
+				assert.NoError(t, err)
+				assert.NotNil(t, value)
+			}
+
+			assert.Equal(t, tt.expectedRequests, atomic.LoadInt32(&requests))
+		})
+	}
+}
+
+func TestPromClientCoalescesConcurrentQueries(t *testing.T) {
+	var requests int32
+	server := newTestPromServer(t, &requests, 100*time.Millisecond)
+	defer server.Close()
+
+	pc, err := NewPromClient(server.URL, time.Minute, DefaultQueryCacheSize)
+	assert.NoError(t, err)
+
+	end := time.Now().UTC()
+	start := end.AddDate(0, 0, -14)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := pc.FetchQueryRange(context.Background(), "q1", time.Second, start, end, 5*time.Minute, logr.Discard())
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestPromClientCanceledCallerDoesNotFailCoalescedQueries(t *testing.T) {
+	var requests int32
+	server := newTestPromServer(t, &requests, 200*time.Millisecond)
+	defer server.Close()
+
+	pc, err := NewPromClient(server.URL, time.Minute, DefaultQueryCacheSize)
+	assert.NoError(t, err)
+
+	end := time.Now().UTC()
+	start := end.AddDate(0, 0, -14)
+
+	// the first caller starts the query and gives up before prometheus answers
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := pc.FetchQueryRange(ctx, "q1", time.Second, start, end, 5*time.Minute, logr.Discard())
+		canceled <- err
+	}()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&requests) == 1 }, time.Second, 10*time.Millisecond)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		value, err := pc.FetchQueryRange(context.Background(), "q1", time.Second, start, end, 5*time.Minute, logr.Discard())
+		assert.NoError(t, err)
+		assert.NotNil(t, value)
+	}()
+
+	cancel()
+	assert.ErrorIs(t, <-canceled, context.Canceled)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestPromClientFetchRetentionCached(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":{"storageRetention":"15d"}}`)); err != nil {
+			t.Errorf("unable to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	pc, err := NewPromClient(server.URL, 0, 0)
+	assert.NoError(t, err)
+
+	// every evaluation of every resource reads the retention
+	for i := 0; i < 3; i++ {
+		retention, err := pc.FetchRetention(context.Background(), time.Second)
+		assert.NoError(t, err)
+		assert.Equal(t, 15*24*time.Hour, retention)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// the retention is fetched again once expired
+	pc.retentionExpires = time.Now().Add(-time.Second)
+	_, err = pc.FetchRetention(context.Background(), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestParseStorageRetention(t *testing.T) {
+	tests := []struct {
+		retention   string
+		expected    time.Duration
+		expectedErr bool
+	}{
+		{retention: "15d", expected: 15 * 24 * time.Hour},
+		{retention: "2w or 10GiB", expected: 14 * 24 * time.Hour},
+		{retention: "10GiB", expectedErr: true},
+		{retention: "", expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.retention, func(t *testing.T) {
+			retention, err := parseStorageRetention(tt.retention)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, retention)
+		})
+	}
+}
@@ -173,3 +173,71 @@ func AddShiftedWeightedSampleByWeek(h *dateTimeEstimator, maxWeek int, values mo
 func GetNamespacedName(ut *v1alpha1.UsageTemplate) string {
 	return fmt.Sprintf("%s/%s", ut.Namespace, ut.Name)
 }
+
+// NormalizeByNode returns a copy of the timeseries with the values multiplied by the performance factor
+// of the node in nodeLabel, the timeseries are copied as the query results are shared by the query cache
+func NormalizeByNode(matrix model.Matrix, nodeLabel model.LabelName, factors map[string]float64) model.Matrix {
+	results := make(model.Matrix, 0, len(matrix))
+	for _, series := range matrix {
+		factor, ok := factors[string(series.Metric[nodeLabel])]
+		if !ok || factor == 1 {
+			results = append(results, series)
+			continue
+		}
+
+		normalized := &model.SampleStream{
+			Metric: series.Metric,
+			Values: make([]model.SamplePair, len(series.Values)),
+		}
+		for i, v := range series.Values {
+			normalized.Values[i] = model.SamplePair{
+				Timestamp: v.Timestamp,
+				Value:     model.SampleValue(float64(v.Value) * factor),
+			}
+		}
+		results = append(results, normalized)
+	}
+
+	return results
+}
+
+// EstimateHourlyUsage estimates the percentile of the samples by hour of the weekdays and of the weekends,
+// the samples are weighted by week as for the usage templates and scaled by the scale factor
+func EstimateHourlyUsage(values model.Value, percentile float64, scaleFactor float64, now time.Time) (tutils.HourlyUsage, error) {
+	usage := tutils.NewHourlyUsage()
+
+	h, err := NewDateTimeEstimator()
+	if err != nil {
+		return usage, err
+	}
+
+	// the series are not of a container, the oldest week is looked up across all of them
+	matrix, ok := values.(model.Matrix)
+	if !ok {
+		return usage, fmt.Errorf("expected Matrix type, but got %v", values.Type())
+	}
+	maxWeek := 0
+	for _, series := range matrix {
+		for _, v := range series.Values {
+			maxWeek = tutils.Max(maxWeek, GetWeekDifferenceUTC(v.Timestamp.Time(), now))
+		}
+	}
+
+	if err := AddWeightedSampleByWeek(h, maxWeek, values, now); err != nil {
+		return usage, err
+	}
+
+	for i := range h.Histograms {
+		if h.Histograms[i].IsEmpty() {
+			continue
+		}
+		value := h.Histograms[i].Percentile(percentile) * scaleFactor
+		if h.Histograms[i].IsWeekday {
+			usage.Weekday[int16(h.Histograms[i].Hour)] = value
+		} else {
+			usage.Weekend[int16(h.Histograms[i].Hour-24)] = value
+		}
+	}
+
+	return usage, nil
+}
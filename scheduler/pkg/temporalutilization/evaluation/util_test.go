@@ -0,0 +1,88 @@
+package evaluation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeByNode(t *testing.T) {
+	series := func(node string, value model.SampleValue) *model.SampleStream {
+		metric := model.Metric{"container": "nginx"}
+		if node != "" {
+			metric["node"] = model.LabelValue(node)
+		}
+		return &model.SampleStream{
+			Metric: metric,
+			Values: []model.SamplePair{{Timestamp: 0, Value: value}, {Timestamp: 60000, Value: value}},
+		}
+	}
+
+	matrix := model.Matrix{
+		series("fast-1", 0.5),
+		series("reference-1", 0.5),
+		series("unknown-1", 0.5),
+		series("", 0.5),
+	}
+	factors := map[string]float64{"fast-1": 2, "reference-1": 1}
+
+	normalized := NormalizeByNode(matrix, "node", factors)
+	assert.Len(t, normalized, 4)
+	// the usages on the fast node are doubled in reference cores
+	assert.Equal(t, model.SampleValue(1), normalized[0].Values[0].Value)
+	assert.Equal(t, model.SampleValue(1), normalized[0].Values[1].Value)
+	assert.Equal(t, matrix[0].Metric, normalized[0].Metric)
+	for _, s := range normalized[1:] {
+		assert.Equal(t, model.SampleValue(0.5), s.Values[0].Value)
+	}
+
+	// the query results shared by the cache are left untouched
+	assert.Equal(t, model.SampleValue(0.5), matrix[0].Values[0].Value)
+}
+
+func TestBuildUsageQueryKeepsNodeLabel(t *testing.T) {
+	ue := &UsageEvaluator{nodeMetricLabel: "node"}
+	query, err := ue.buildUsageQuery([]string{`container="nginx"`}, "cpu", []string{`part_of!=""`}, []string{"part_of"})
+	assert.Nil(t, err)
+	assert.Contains(t, query, "avg by (part_of,container,node) (")
+
+	ue.nodeMetricLabel = ""
+	query, err = ue.buildUsageQuery([]string{`container="nginx"`}, "cpu", []string{`part_of!=""`}, []string{"part_of"})
+	assert.Nil(t, err)
+	assert.Contains(t, query, "avg by (part_of,container) (")
+}
+
+func TestEstimateHourlyUsage(t *testing.T) {
+	samples := func(start time.Time, value model.SampleValue) []model.SamplePair {
+		var pairs []model.SamplePair
+		for ts := start; ts.Before(start.Add(time.Hour)); ts = ts.Add(5 * time.Minute) {
+			pairs = append(pairs, model.SamplePair{Timestamp: model.TimeFromUnixNano(ts.UnixNano()), Value: value})
+		}
+		return pairs
+	}
+
+	// a Monday 10:00 at 2 cores and a Saturday 03:00 at 1 core
+	weekday := time.Date(2024, 10, 14, 10, 0, 0, 0, time.UTC)
+	weekend := time.Date(2024, 10, 19, 3, 0, 0, 0, time.UTC)
+	matrix := model.Matrix{
+		&model.SampleStream{
+			Metric: model.Metric{},
+			Values: append(samples(weekday, 2), samples(weekend, 1)...),
+		},
+	}
+
+	usage, err := EstimateHourlyUsage(matrix, 0.95, 1000, weekend.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, usage.Weekday, 1)
+	assert.Len(t, usage.Weekend, 1)
+	// the value is the end of the histogram bucket of the samples
+	assert.GreaterOrEqual(t, usage.Weekday[10], float64(2000))
+	assert.InEpsilon(t, 2000, usage.Weekday[10], 0.15)
+	assert.GreaterOrEqual(t, usage.Weekend[3], float64(1000))
+	assert.InEpsilon(t, 1000, usage.Weekend[3], 0.15)
+
+	_, err = EstimateHourlyUsage(model.Vector{}, 0.95, 1000, weekend)
+	assert.Error(t, err)
+}
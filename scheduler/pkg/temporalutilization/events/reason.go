@@ -6,4 +6,15 @@ const (
 	CheckFailed        = "UsaageTemplateCheckFailed"
 	ReadyForEvaluation = "UsageTemplateReadyForEvaluation"
 	EvaluationStarted  = "UsageTemplateEvaluationStarted"
+	ParseFailed        = "UsageTemplateParseFailed"
+
+	OvercommitTuned        = "OvercommitRatioTuned"
+	OvercommitTuningDryRun = "OvercommitRatioTuningDryRun"
+	OvercommitTuningFailed = "OvercommitRatioTuningFailed"
+
+	NodeBaselineFailed = "NodeBaselineEstimationFailed"
+
+	NodeUsageForecastFailed = "NodeUsageForecastFailed"
+
+	WaitingForUsageTemplate = "WaitingForUsageTemplate"
 )
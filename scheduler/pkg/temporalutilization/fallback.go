@@ -0,0 +1,243 @@
+package temporalutilization
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/cache"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/api/v1/resource"
+)
+
+// FallbackMode is the usage assumed for pods without a usable usage template
+type FallbackMode string
+
+const (
+	// ByClassFallback assumes the default requests for BestEffort pods, the limits for Guaranteed pods
+	// and the requests for Burstable pods
+	ByClassFallback FallbackMode = "ByClass"
+	// RequestsFallback assumes the requests, or the default requests when not specified
+	RequestsFallback FallbackMode = "Requests"
+	// LimitsFallback assumes the limits, or the requests when not specified
+	LimitsFallback FallbackMode = "Limits"
+	// LimitsPercentageFallback assumes a percentage of the limits, or the requests when not specified
+	LimitsPercentageFallback FallbackMode = "LimitsPercentage"
+	// NamespaceAggregateFallback assumes the hourly maximum of the usable usage templates in the pod namespace,
+	// or falls back to ByClass when there is none
+	NamespaceAggregateFallback FallbackMode = "NamespaceAggregate"
+)
+
+var supportedFallbackModes = map[FallbackMode]bool{
+	ByClassFallback:            true,
+	RequestsFallback:           true,
+	LimitsFallback:             true,
+	LimitsPercentageFallback:   true,
+	NamespaceAggregateFallback: true,
+}
+
+// FallbackPolicy decides the usage assumed for pods without a usable usage template
+type FallbackPolicy struct {
+	Mode FallbackMode
+	// LimitsPercentage is the percentage of the limits assumed in LimitsPercentage mode
+	LimitsPercentage int32
+	// FilterByRequests filters the incoming pods without a usable usage template by their requests
+	// instead of by temporal usages
+	FilterByRequests bool
+}
+
+// ValidateFallbackPolicy checks the fallback mode is supported and the limits percentage of the LimitsPercentage mode
+func ValidateFallbackPolicy(mode FallbackMode, limitsPercentage int32) error {
+	if !supportedFallbackModes[mode] {
+		return fmt.Errorf("fallback policy %q not supported", mode)
+	}
+
+	if mode == LimitsPercentageFallback && (limitsPercentage <= 0 || limitsPercentage > 100) {
+		return fmt.Errorf("fallback limits percentage must be greater than zero and less than or equal to a hundred, got %d", limitsPercentage)
+	}
+
+	return nil
+}
+
+// getFallbackPolicy returns the fallback policy of a namespace, which is the configured policy
+// overridden by the namespace annotations if any
+func (utMgr *UsageTemplateManager) getFallbackPolicy(namespace string) FallbackPolicy {
+	policy := utMgr.Fallback
+	if utMgr.nsLister == nil {
+		return policy
+	}
+
+	ns, err := utMgr.nsLister.Get(namespace)
+	if err != nil {
+		klog.V(5).InfoS("unable to get namespace, using the configured fallback policy", "namespace", namespace, "err", err)
+		return policy
+	}
+
+	return NamespaceFallbackPolicy(policy, ns)
+}
+
+// NamespaceFallbackPolicy returns the fallback policy overridden by the annotations of the namespace if any,
+// the policy is kept when the annotations are invalid
+func NamespaceFallbackPolicy(policy FallbackPolicy, ns *v1.Namespace) FallbackPolicy {
+	override := policy
+	if mode, ok := ns.Annotations[v1alpha1.NamespaceUsageFallbackPolicyAnnotation]; ok {
+		override.Mode = FallbackMode(mode)
+	}
+
+	if value, ok := ns.Annotations[v1alpha1.NamespaceUsageFallbackLimitsPercentageAnnotation]; ok {
+		percentage, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			klog.ErrorS(err, "invalid fallback limits percentage annotation, using the configured fallback policy", "namespace", ns.Name)
+			return policy
+		}
+		override.LimitsPercentage = int32(percentage)
+	}
+
+	if err := ValidateFallbackPolicy(override.Mode, override.LimitsPercentage); err != nil {
+		klog.ErrorS(err, "invalid fallback policy annotations, using the configured fallback policy", "namespace", ns.Name)
+		return policy
+	}
+
+	return override
+}
+
+// assumeUsage returns the usage assumed for a pod without a usable usage template
+func (utMgr *UsageTemplateManager) assumeUsage(p *v1.Pod, resourceName string) (*UsageTemplate, error) {
+	return assumeUsageByPolicy(utMgr.getFallbackPolicy(p.Namespace), p, resourceName, func() (*UsageTemplate, error) {
+		return aggregateNamespaceUsage(utMgr, p, resourceName)
+	})
+}
+
+// AssumedHourlyUsage returns the weekday and weekend hourly usages of the resource the scheduler assumes
+// for a pod without a usable usage template by the fallback policy of its namespace.
+// namespaceTemplates are the parsed usage templates of the namespace of the pod, aggregated in NamespaceAggregate mode
+func AssumedHourlyUsage(policy FallbackPolicy, thresholds CoverageThresholds, pod *v1.Pod, resourceName string,
+	namespaceTemplates []*cache.UsageTemplateCache) (map[int16]float32, map[int16]float32, error) {
+	usage, err := assumeUsageByPolicy(policy, pod, resourceName, func() (*UsageTemplate, error) {
+		return aggregateUsages(namespaceTemplates, thresholds, pod, resourceName)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return usage.weekDayHour, usage.weekendHour, nil
+}
+
+// assumeUsageByPolicy returns the usage assumed by the fallback policy, namespaceUsage aggregates the usage templates
+// of the namespace of the pod and returns nil when there is none
+func assumeUsageByPolicy(policy FallbackPolicy, p *v1.Pod, resourceName string, namespaceUsage func() (*UsageTemplate, error)) (*UsageTemplate, error) {
+	switch policy.Mode {
+	case RequestsFallback:
+		return assumeUsageByRequests(p, resourceName)
+	case LimitsFallback:
+		return assumeUsageByLimits(p, resourceName, 100)
+	case LimitsPercentageFallback:
+		return assumeUsageByLimits(p, resourceName, policy.LimitsPercentage)
+	case NamespaceAggregateFallback:
+		usage, err := namespaceUsage()
+		if err != nil {
+			klog.ErrorS(err, "unable to aggregate namespace usage, assuming usage by class", "pod", klog.KObj(p), "resource", resourceName)
+		} else if usage != nil {
+			return usage, nil
+		}
+	}
+
+	return assumeUsageByClass(p, resourceName)
+}
+
+func assumeUsageByRequests(p *v1.Pod, resourceName string) (*UsageTemplate, error) {
+	reqs, _ := resource.PodRequestsAndLimits(p)
+
+	v, err := getResourceValue(resourceName, reqs)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == 0 {
+		v, err = getDefaultResourceValue(resourceName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sameUtilizationByHour(float32(v)), nil
+}
+
+func assumeUsageByLimits(p *v1.Pod, resourceName string, percentage int32) (*UsageTemplate, error) {
+	_, limits := resource.PodRequestsAndLimits(p)
+
+	v, err := getResourceValue(resourceName, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == 0 {
+		// no limits, nothing to take the percentage of
+		return assumeUsageByRequests(p, resourceName)
+	}
+
+	return sameUtilizationByHour(float32(v) * float32(percentage) / 100), nil
+}
+
+// aggregateNamespaceUsage takes the hourly maximum usages of the usable usage templates in the namespace of the pod,
+// it returns nil when there is none
+func aggregateNamespaceUsage(utMgr *UsageTemplateManager, p *v1.Pod, resourceName string) (*UsageTemplate, error) {
+	uts, err := utMgr.utLister.UsageTemplates(p.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := make([]*cache.UsageTemplateCache, 0, len(uts))
+	for _, ut := range uts {
+		if hasUsableUsageTemplate(utMgr, ut, resourceName) {
+			parsed = append(parsed, utMgr.templates.Get(ut))
+		}
+	}
+
+	return aggregateUsages(parsed, utMgr.MinCoverage, p, resourceName)
+}
+
+// aggregateUsages takes the hourly maximum usages of the usable parsed usage templates, it returns nil when there is none.
+// The usages of the short-lived templates are anchored at the pod start as the usages of its own template would be.
+func aggregateUsages(parsed []*cache.UsageTemplateCache, thresholds CoverageThresholds, p *v1.Pod, resourceName string) (*UsageTemplate, error) {
+	var results *UsageTemplate
+	startHour, elapsedHours := usageAnchor(p, time.Now().UTC())
+	for _, template := range parsed {
+		if !isUsableUsageTemplate(template.Object, resourceName, thresholds) {
+			continue
+		}
+
+		var usage *UsageTemplate
+		if !template.Object.Status.IsLongRunning && elapsedHours > expectedDurationHours(template.Object, resourceName) {
+			// the pod outlived the short-lived usages, they no longer contribute
+			usage = noUsage(resourceName)
+		} else {
+			var err error
+			usage, err = extractUsageFromCRD(template, resourceName, startHour, elapsedHours)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if results == nil {
+			results = usage
+			continue
+		}
+
+		maxByHour(results.weekDayHour, usage.weekDayHour)
+		maxByHour(results.weekendHour, usage.weekendHour)
+	}
+
+	return results, nil
+}
+
+func maxByHour(results, usages map[int16]float32) {
+	for hour, value := range usages {
+		if current, ok := results[hour]; !ok || value > current {
+			results[hour] = value
+		}
+	}
+}
@@ -2,9 +2,8 @@ package temporalutilization
 
 import (
 	"fmt"
-	"strings"
-
 	"math"
+	"strings"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -13,7 +12,6 @@ import (
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/noderesources"
 )
 
-
 func computePodResourceRequest(pod *v1.Pod) *preFilterState {
 	result := &preFilterState{}
 	// 处理容器资源请求
@@ -53,7 +51,7 @@ func fitsRequest(podRequest *preFilterState, nodeInfo *framework.NodeInfo, ignor
 	}
 
 	// 检查资源请求是否超出节点分配的可用资源
-	checkResource := func(resourceName string, requested int64, nodeAllocatable int64, nodeRequested int64, ignore bool) {
+	checkResource := func(resourceName v1.ResourceName, requested int64, nodeAllocatable int64, nodeRequested int64, ignore bool) {
 		if !ignore && requested > (nodeAllocatable-nodeRequested) {
 			insufficientResources = append(insufficientResources, noderesources.InsufficientResource{
 				ResourceName: resourceName,
@@ -65,9 +63,9 @@ func fitsRequest(podRequest *preFilterState, nodeInfo *framework.NodeInfo, ignor
 		}
 	}
 
-	checkResource(v1.ResourceCPU.String(), podRequest.MilliCPU, nodeInfo.Allocatable.MilliCPU, nodeInfo.Requested.MilliCPU, ignoreResources.Has(v1.ResourceCPU.String()))
-	checkResource(v1.ResourceMemory.String(), podRequest.Memory, nodeInfo.Allocatable.Memory, nodeInfo.Requested.Memory, ignoreResources.Has(v1.ResourceMemory.String()))
-	checkResource(v1.ResourceEphemeralStorage.String(), podRequest.EphemeralStorage, nodeInfo.Allocatable.EphemeralStorage, nodeInfo.Requested.EphemeralStorage, false)
+	checkResource(v1.ResourceCPU, podRequest.MilliCPU, nodeInfo.Allocatable.MilliCPU, nodeInfo.Requested.MilliCPU, ignoreResources.Has(v1.ResourceCPU.String()))
+	checkResource(v1.ResourceMemory, podRequest.Memory, nodeInfo.Allocatable.Memory, nodeInfo.Requested.Memory, ignoreResources.Has(v1.ResourceMemory.String()))
+	checkResource(v1.ResourceEphemeralStorage, podRequest.EphemeralStorage, nodeInfo.Allocatable.EphemeralStorage, nodeInfo.Requested.EphemeralStorage, false)
 
 	// 检查标量资源请求
 	for rName, rQuant := range podRequest.ScalarResources {
@@ -97,35 +95,36 @@ func fitsRequest(podRequest *preFilterState, nodeInfo *framework.NodeInfo, ignor
 	return insufficientResources
 }
 
-func fitsRequestWithTemporal(requested map[string]*UsageTemplate, forecasts map[string]*UsageTemplate, nodeInfo *framework.NodeInfo) []noderesources.InsufficientResource {
+// fitsRequestWithTemporal checks whether the forecasts are within the node capacity at the given hours
+func fitsRequestWithTemporal(requested map[string]*UsageTemplate, forecasts map[string]*UsageTemplate, nodeInfo *framework.NodeInfo, slots []hourSlot) []noderesources.InsufficientResource {
 	insufficientResources := []noderesources.InsufficientResource{}
 
 	checkTemporalResource := func(resource string, templates *UsageTemplate, requested map[string]*UsageTemplate) {
-		for _, timePeriod := range [][]int{templates.weekDayHour, templates.weekendHour} {
-			for h, value := range timePeriod {
-				total := int64(math.Round(float64(value)))
-
-				if resource == v1.ResourceCPU.String() && total > nodeInfo.Allocatable.MilliCPU {
-					requestedResource := requested[resource]
-					requestedVal := int64(math.Round(float64(requestedResource.weekDayHour[h])))
-					insufficientResources = append(insufficientResources, noderesources.InsufficientResource{
-						ResourceName: v1.ResourceCPU,
-						Reason:       fmt.Sprintf("Insufficient cpu at hour: %d", h),
-						Requested:    requestedVal,
-						Used:         total - requestedVal,
-						Capacity:     nodeInfo.Allocatable.MilliCPU,
-					})
-				} else if resource == v1.ResourceMemory.String() && total > nodeInfo.Allocatable.Memory {
-					requestedResource := requested[resource]
-					requestedVal := int64(math.Round(float64(requestedResource.weekDayHour[h])))
-					insufficientResources = append(insufficientResources, noderesources.InsufficientResource{
-						ResourceName: v1.ResourceMemory,
-						Reason:       fmt.Sprintf("Insufficient memory at hour: %d", h),
-						Requested:    requestedVal,
-						Used:         total - requestedVal,
-						Capacity:     nodeInfo.Allocatable.Memory,
-					})
+		for _, slot := range slots {
+			value, ok := templates.valueAt(slot)
+			if !ok {
+				continue
+			}
+			total := int64(math.Round(float64(value)))
+
+			resourceName, capacity, ok := temporalCapacity(nodeInfo, resource)
+			if !ok {
+				continue
+			}
+
+			if total > capacity {
+				requestedVal := int64(0)
+				if requestedResource, ok := requested[resource]; ok {
+					v, _ := requestedResource.valueAt(slot)
+					requestedVal = int64(math.Round(float64(v)))
 				}
+				insufficientResources = append(insufficientResources, noderesources.InsufficientResource{
+					ResourceName: resourceName,
+					Reason:       fmt.Sprintf("Insufficient %s at hour: %d", resource, slot.hour),
+					Requested:    requestedVal,
+					Used:         total - requestedVal,
+					Capacity:     capacity,
+				})
 			}
 		}
 	}
@@ -138,6 +137,19 @@ func fitsRequestWithTemporal(requested map[string]*UsageTemplate, forecasts map[
 	return insufficientResources
 }
 
+// temporalCapacity returns the capacity of the node the forecasts of the resource are compared to,
+// false when the resource is not filtered by temporal usages
+func temporalCapacity(nodeInfo *framework.NodeInfo, resource string) (v1.ResourceName, int64, bool) {
+	switch resource {
+	case v1.ResourceCPU.String():
+		return v1.ResourceCPU, scaleByPerformanceFactor(nodeInfo.Node(), resource, nodeInfo.Allocatable.MilliCPU), true
+	case v1.ResourceMemory.String():
+		return v1.ResourceMemory, nodeInfo.Allocatable.Memory, true
+	default:
+		return "", 0, false
+	}
+}
+
 func getPreFilterState(cycleState *framework.CycleState) (*preFilterState, error) {
 	c, err := cycleState.Read(preFilterStateKey)
 	if err != nil {
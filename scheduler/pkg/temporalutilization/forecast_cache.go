@@ -0,0 +1,297 @@
+package temporalutilization
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/utils"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+)
+
+// nodeForecast is the aggregated hourly usages of the pods on a node
+type nodeForecast struct {
+	// epoch is the hour the usages were computed in. They are recomputed every hour, since the usages of
+	// short-lived pods move forward in time, and the fallback usages depend on the other usage templates
+	epoch time.Time
+	// pods are the usages of each pod on the node
+	pods map[NamespacedPod]map[string]*UsageTemplate
+	// templates are the usage template namespace/name of each pod on the node
+	templates map[NamespacedPod]string
+	// total is the sum of the pods usages
+	total map[string]*UsageTemplate
+}
+
+// forecastCache incrementally maintains the aggregated hourly usages of the pods on every node,
+// so that the forecasts of a node are obtained without resolving the usage template of every pod
+type forecastCache struct {
+	sync.Mutex
+	utMgr           *UsageTemplateManager
+	targetResources []string
+
+	nodes map[string]*nodeForecast
+	// templatePods indexes the node of the cached pods by their usage template namespace/name
+	templatePods map[string]map[NamespacedPod]string
+}
+
+func newForecastCache(utMgr *UsageTemplateManager, targetResources []string) *forecastCache {
+	return &forecastCache{
+		utMgr:           utMgr,
+		targetResources: targetResources,
+		nodes:           make(map[string]*nodeForecast),
+		templatePods:    make(map[string]map[NamespacedPod]string),
+	}
+}
+
+func currentEpoch() time.Time {
+	return time.Now().UTC().Truncate(time.Hour)
+}
+
+// get returns the aggregated usages of the pods on the node.
+// The results are shared and must not be modified, they are replaced rather than modified by the cache.
+func (fc *forecastCache) get(nodeName string) (map[string]*UsageTemplate, error) {
+	fc.Lock()
+	defer fc.Unlock()
+
+	if nf, ok := fc.nodes[nodeName]; ok && nf.epoch.Equal(currentEpoch()) {
+		return nf.total, nil
+	}
+
+	nf, err := fc.rebuild(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return nf.total, nil
+}
+
+// rebuild computes the usages of every pod on the node from scratch
+func (fc *forecastCache) rebuild(nodeName string) (*nodeForecast, error) {
+	fc.forgetNode(nodeName)
+
+	nf := &nodeForecast{
+		epoch:     currentEpoch(),
+		pods:      make(map[NamespacedPod]map[string]*UsageTemplate),
+		templates: make(map[NamespacedPod]string),
+	}
+	fc.nodes[nodeName] = nf
+
+	for _, namespacedPod := range fc.utMgr.GetNodePods(nodeName) {
+		pod, err := fc.utMgr.podLister.Pods(namespacedPod.Namespace).Get(namespacedPod.Name)
+		if apierrors.IsNotFound(err) {
+			// the pod was deleted, it is removed from the node pods cache by its delete event or the next resync
+			klog.V(5).InfoS("unable to get pod, skipping it in the node forecast", "pod", namespacedPod, "node", nodeName, "err", err)
+			continue
+		}
+		if err != nil {
+			fc.forgetNode(nodeName)
+			return nil, err
+		}
+
+		usages, err := getPodUsages(fc.utMgr, pod, fc.targetResources)
+		if err != nil {
+			fc.forgetNode(nodeName)
+			return nil, err
+		}
+
+		nf.pods[namespacedPod] = usages
+		fc.indexPod(nf, pod, namespacedPod, nodeName)
+	}
+
+	nf.sum(fc.targetResources)
+	return nf, nil
+}
+
+// addPod adds or refreshes the usages of a pod on the node
+func (fc *forecastCache) addPod(pod *v1.Pod, nodeName string) {
+	fc.Lock()
+	defer fc.Unlock()
+
+	nf, ok := fc.nodes[nodeName]
+	if !ok || !nf.epoch.Equal(currentEpoch()) {
+		// the node is rebuilt including the pod when it is read next time
+		return
+	}
+
+	namespacedPod := NamespacedPod{Namespace: pod.Namespace, Name: pod.Name}
+	usages, err := getPodUsages(fc.utMgr, pod, fc.targetResources)
+	if err != nil {
+		klog.ErrorS(err, "unable to obtain usage for pod, rebuilding the node forecast next time", "pod", klog.KObj(pod), "node", nodeName)
+		fc.forgetNode(nodeName)
+		return
+	}
+
+	_, refresh := nf.pods[namespacedPod]
+	nf.pods[namespacedPod] = usages
+	fc.indexPod(nf, pod, namespacedPod, nodeName)
+
+	if refresh {
+		nf.sum(fc.targetResources)
+		return
+	}
+
+	// the total may be in use by readers, so it is copied rather than modified
+	total := make(map[string]*UsageTemplate, len(nf.total))
+	addUsages(total, nf.total)
+	addUsages(total, usages)
+	nf.total = total
+}
+
+// deletePod removes the usages of a pod from the node
+func (fc *forecastCache) deletePod(pod *v1.Pod, nodeName string) {
+	fc.Lock()
+	defer fc.Unlock()
+
+	namespacedPod := NamespacedPod{Namespace: pod.Namespace, Name: pod.Name}
+	nf, ok := fc.nodes[nodeName]
+	if !ok {
+		return
+	}
+
+	if _, ok := nf.pods[namespacedPod]; !ok {
+		return
+	}
+
+	// sum again the usages of the remaining pods rather than subtracting,
+	// so that the hours without usages stay absent
+	fc.unindexPod(nf, namespacedPod)
+	delete(nf.pods, namespacedPod)
+	nf.sum(fc.targetResources)
+}
+
+// refreshTemplate recomputes the usages of the pods belonging to the usage template
+func (fc *forecastCache) refreshTemplate(key string) {
+	fc.Lock()
+	defer fc.Unlock()
+
+	refreshed := make(map[string]*nodeForecast)
+	for namespacedPod, nodeName := range fc.templatePods[key] {
+		nf, ok := fc.nodes[nodeName]
+		if !ok {
+			continue
+		}
+
+		pod, err := fc.utMgr.podLister.Pods(namespacedPod.Namespace).Get(namespacedPod.Name)
+		if err != nil {
+			klog.V(5).InfoS("unable to get pod, rebuilding the node forecast next time", "pod", namespacedPod, "node", nodeName, "err", err)
+			fc.forgetNode(nodeName)
+			continue
+		}
+
+		usages, err := getPodUsages(fc.utMgr, pod, fc.targetResources)
+		if err != nil {
+			klog.ErrorS(err, "unable to obtain usage for pod, rebuilding the node forecast next time", "pod", klog.KObj(pod), "node", nodeName)
+			fc.forgetNode(nodeName)
+			continue
+		}
+
+		nf.pods[namespacedPod] = usages
+		refreshed[nodeName] = nf
+	}
+
+	for nodeName, nf := range refreshed {
+		if _, ok := fc.nodes[nodeName]; ok {
+			nf.sum(fc.targetResources)
+		}
+	}
+}
+
+// invalidateNode drops the cached usages of a node, they are rebuilt when read next time
+func (fc *forecastCache) invalidateNode(nodeName string) {
+	fc.Lock()
+	defer fc.Unlock()
+	fc.forgetNode(nodeName)
+}
+
+// forgetNode drops the cached usages of a node
+func (fc *forecastCache) forgetNode(nodeName string) {
+	nf, ok := fc.nodes[nodeName]
+	if !ok {
+		return
+	}
+
+	for namespacedPod := range nf.templates {
+		fc.unindexPod(nf, namespacedPod)
+	}
+	delete(fc.nodes, nodeName)
+}
+
+func (fc *forecastCache) indexPod(nf *nodeForecast, pod *v1.Pod, namespacedPod NamespacedPod, nodeName string) {
+	// the usage template label may have changed
+	fc.unindexPod(nf, namespacedPod)
+
+	key := usageTemplateKey(pod)
+	if len(key) == 0 {
+		return
+	}
+
+	if _, ok := fc.templatePods[key]; !ok {
+		fc.templatePods[key] = make(map[NamespacedPod]string)
+	}
+	fc.templatePods[key][namespacedPod] = nodeName
+	nf.templates[namespacedPod] = key
+}
+
+func (fc *forecastCache) unindexPod(nf *nodeForecast, namespacedPod NamespacedPod) {
+	key, ok := nf.templates[namespacedPod]
+	if !ok {
+		return
+	}
+	delete(nf.templates, namespacedPod)
+
+	if pods, ok := fc.templatePods[key]; ok {
+		delete(pods, namespacedPod)
+		if len(pods) == 0 {
+			delete(fc.templatePods, key)
+		}
+	}
+}
+
+// sum adds up the usages of the pods on the node
+func (nf *nodeForecast) sum(targetResources []string) {
+	nf.total = make(map[string]*UsageTemplate, len(targetResources))
+	for _, usages := range nf.pods {
+		addUsages(nf.total, usages)
+	}
+}
+
+// usageTemplateKey returns the namespace/name of the usage template a pod belongs to, empty if none
+func usageTemplateKey(pod *v1.Pod) string {
+	utName := utils.GetUsageTemplateLabel(pod)
+	if len(utName) == 0 {
+		return ""
+	}
+
+	namespace := pod.GetNamespace()
+	if len(namespace) == 0 {
+		namespace = "default"
+	}
+	return fmt.Sprintf("%v/%v", namespace, utName)
+}
+
+// addUsages adds the hourly usages into results
+func addUsages(results map[string]*UsageTemplate, usages map[string]*UsageTemplate) {
+	for resource, usageTemplate := range usages {
+		if usageTemplate == nil {
+			continue
+		}
+
+		if _, ok := results[resource]; !ok {
+			results[resource] = &UsageTemplate{
+				resource:    resource,
+				unit:        DefaultResourceUnitMap[resource],
+				weekDayHour: make(map[int16]float32),
+				weekendHour: make(map[int16]float32),
+			}
+		}
+
+		for hour, value := range usageTemplate.weekDayHour {
+			results[resource].weekDayHour[hour] += value
+		}
+
+		for hour, value := range usageTemplate.weekendHour {
+			results[resource].weekendHour[hour] += value
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package temporalutilization
+
+import (
+	"context"
+	"testing"
+
+	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	fakeclientset "gitee.com/openeuler/paws/scheduler/pkg/generated/clientset/versioned/fake"
+	pawsinformers "gitee.com/openeuler/paws/scheduler/pkg/generated/informers/externalversions"
+	testutils "gitee.com/openeuler/paws/scheduler/pkg/test/util"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	testClientSet "k8s.io/client-go/kubernetes/fake"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+)
+
+func TestForecastCacheIncrementalUpdates(t *testing.T) {
+	node := st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{
+		v1.ResourceCPU: "4000m",
+	}).Obj()
+	incomingPod := st.MakePod().Namespace("default").Name("pod-0").Obj()
+	pod1 := st.MakePod().Namespace("default").Name("pod-1").Node("node-1").Labels(map[string]string{
+		v1alpha1.UsageTemplateLabelIdentifier: "test-crd-1",
+	}).Obj()
+	pod2 := st.MakePod().Namespace("default").Name("pod-2").Node("node-1").Labels(map[string]string{
+		v1alpha1.UsageTemplateLabelIdentifier: "test-crd-2",
+	}).Obj()
+	usageTemplates := []*v1alpha1.UsageTemplate{
+		testutils.MakeUsageTemplate("test-crd-1", "default", true, "BestEffort",
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(100)},
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(100)}, true),
+		testutils.MakeUsageTemplate("test-crd-2", "default", true, "BestEffort",
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(200)},
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(200)}, true),
+	}
+
+	ctx := context.Background()
+	informerFactory := informers.NewSharedInformerFactory(testClientSet.NewSimpleClientset(), 0)
+	podInformer := informerFactory.Core().V1().Pods()
+	informerFactory.Start(ctx.Done())
+	for _, pod := range []*v1.Pod{incomingPod, pod1, pod2} {
+		podInformer.Informer().GetStore().Add(pod)
+	}
+
+	pawsCS := fakeclientset.NewSimpleClientset()
+	pawsInformerFactory := pawsinformers.NewSharedInformerFactory(pawsCS, 0)
+	utInformer := pawsInformerFactory.Scheduling().V1alpha1().UsageTemplates()
+	pawsInformerFactory.Start(ctx.Done())
+	for _, ut := range usageTemplates {
+		utInformer.Informer().GetStore().Add(ut)
+	}
+
+	mgr := NewUsageTemplateManager(pawsCS, newTestSharedLister(nil, []*v1.Node{node}), utInformer, podInformer)
+	resources := supportedTargetResources()
+
+	// the usages summed from scratch
+	expectedForecasts := func(pods ...*v1.Pod) map[string]*UsageTemplate {
+		results := make(map[string]*UsageTemplate)
+		for _, pod := range pods {
+			usages, err := getPodUsages(mgr, pod, resources)
+			assert.Nil(t, err)
+			addUsages(results, usages)
+		}
+		return results
+	}
+
+	mgr.OnAdd(pod1)
+	forecasts, err := mgr.forecasts.get("node-1")
+	assert.Nil(t, err)
+	assert.Equal(t, expectedForecasts(pod1), forecasts)
+
+	// a pod added after the node is cached is added to the total
+	previous := forecasts
+	mgr.OnAdd(pod2)
+	forecasts, err = mgr.forecasts.get("node-1")
+	assert.Nil(t, err)
+	assert.Equal(t, expectedForecasts(pod1, pod2), forecasts)
+	// the total handed out before is left untouched
+	assert.Equal(t, expectedForecasts(pod1), previous)
+
+	// a usage template change refreshes the pods belonging to it
+	updated := testutils.MakeUsageTemplate("test-crd-2", "default", true, "BestEffort",
+		map[string]map[int]float32{"cpu": testutils.SameUsageADay(500)},
+		map[string]map[int]float32{"cpu": testutils.SameUsageADay(500)}, true)
+	updated.ResourceVersion = "2"
+	assert.Nil(t, utInformer.Informer().GetStore().Update(updated))
+	mgr.onUsageTemplateChange(updated)
+	forecasts, err = mgr.forecasts.get("node-1")
+	assert.Nil(t, err)
+	assert.Equal(t, expectedForecasts(pod1, pod2), forecasts)
+	assert.Equal(t, float32(600), forecasts["cpu"].weekDayHour[0])
+
+	// a deleted pod is removed from the total
+	mgr.OnDelete(pod1)
+	forecasts, err = mgr.forecasts.get("node-1")
+	assert.Nil(t, err)
+	assert.Equal(t, expectedForecasts(pod2), forecasts)
+
+	// a pod left in the node pods cache but already deleted does not fail the node
+	mgr.Lock()
+	mgr.NodePodsCache["node-1"] = append(mgr.NodePodsCache["node-1"], NamespacedPod{Namespace: "default", Name: "deleted"})
+	mgr.Unlock()
+	mgr.forecasts.invalidateNode("node-1")
+	forecasts, err = mgr.forecasts.get("node-1")
+	assert.Nil(t, err)
+	assert.Equal(t, expectedForecasts(pod2), forecasts)
+}
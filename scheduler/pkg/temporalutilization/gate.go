@@ -0,0 +1,92 @@
+package temporalutilization
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	schedv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	utevents "gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/events"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+var _ framework.PreEnqueuePlugin = &TemporalUtilization{}
+
+// podUsageTemplateWaitTimeout returns how long after its creation an opted in pod waits for its usage template to be evaluated,
+// false when the pod does not wait
+func podUsageTemplateWaitTimeout(pod *v1.Pod) (time.Duration, bool, error) {
+	value, ok := pod.Annotations[schedv1alpha1.PodUsageTemplateWaitTimeoutAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return 0, false, fmt.Errorf("invalid usage template wait timeout %q, expected a duration", value)
+	}
+	return timeout, true, nil
+}
+
+// PreEnqueue holds an opted in pod out of the scheduling queue until its usage template is evaluated,
+// at the latest until its wait timeout from the creation of the pod. The waiting reason is recorded as an event of the pod
+func (pl *TemporalUtilization) PreEnqueue(ctx context.Context, pod *v1.Pod) *framework.Status {
+	timeout, ok, err := podUsageTemplateWaitTimeout(pod)
+	if err != nil {
+		klog.ErrorS(err, "Enqueuing the pod without waiting for its usage template", "pod", klog.KObj(pod))
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+
+	utName, ut := pl.utMgr.GetUsageTemplate(pod)
+	if len(utName) == 0 {
+		return nil
+	}
+
+	deadline := pod.CreationTimestamp.Add(timeout)
+	if !time.Now().Before(deadline) {
+		klog.V(4).InfoS("Enqueuing the pod after waiting for its usage template", "pod", klog.KObj(pod), "usageTemplate", utName)
+		return nil
+	}
+
+	var msg string
+	switch {
+	case ut == nil:
+		msg = fmt.Sprintf("waiting for the usage template %s to be created until %s", utName, deadline.Format(time.RFC3339))
+	case !ut.Spec.Enabled:
+		// a disabled usage template is never evaluated
+		return nil
+	default:
+		evaluated := true
+		for _, res := range pl.SupportedTargetResources() {
+			if !hasHistoricalUsage(ut, res) {
+				evaluated = false
+				break
+			}
+		}
+		// a usage template evaluated from too little data is not waited for, it may take days to be trusted
+		if evaluated {
+			return nil
+		}
+		msg = fmt.Sprintf("waiting for the usage template %s to be evaluated until %s", utName, deadline.Format(time.RFC3339))
+	}
+
+	pl.handle.EventRecorder().Eventf(pod, nil, v1.EventTypeNormal, utevents.WaitingForUsageTemplate, "Scheduling", msg)
+	return framework.NewStatus(framework.UnschedulableAndUnresolvable, msg)
+}
+
+// hasHistoricalUsage checks whether the usage template was evaluated for the resource, whatever its coverage
+func hasHistoricalUsage(ut *schedv1alpha1.UsageTemplate, res string) bool {
+	if ut.Status.HistoricalUsage == nil {
+		return false
+	}
+	for _, item := range ut.Status.HistoricalUsage.Items {
+		if item.Resource == res {
+			return true
+		}
+	}
+	return false
+}
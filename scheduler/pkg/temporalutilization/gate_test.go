@@ -0,0 +1,95 @@
+package temporalutilization
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	testutils "gitee.com/openeuler/paws/scheduler/pkg/test/util"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+)
+
+// testEventHandle is a framework handle recording the events
+type testEventHandle struct {
+	framework.Handle
+	recorder *events.FakeRecorder
+}
+
+func (h *testEventHandle) EventRecorder() events.EventRecorder {
+	return h.recorder
+}
+
+func TestTemporalUtilizationPreEnqueue(t *testing.T) {
+	usageTemplate := func(name string, enabled bool) *v1alpha1.UsageTemplate {
+		return testutils.MakeUsageTemplate(name, "default", enabled, "BestEffort",
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(400)},
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(400)}, true)
+	}
+	pending := usageTemplate("pending", true)
+	pending.Status.HistoricalUsage = nil
+	// evaluated from too little data to be used by the scheduler
+	lowConfidence := usageTemplate("low-confidence", true)
+	lowConfidence.Status.Coverage = &v1alpha1.EvaluationCoverage{Confidence: 10}
+	memoryOnly := testutils.MakeUsageTemplate("memory-only", "default", true, "BestEffort",
+		map[string]map[int]float32{"memory": testutils.SameUsageADay(400)},
+		map[string]map[int]float32{"memory": testutils.SameUsageADay(400)}, true)
+
+	pod := func(template string, timeout string, created time.Time) *v1.Pod {
+		p := st.MakePod().Namespace("default").Name("pod-1").Labels(map[string]string{v1alpha1.UsageTemplateLabelIdentifier: template}).Obj()
+		p.CreationTimestamp = metav1.NewTime(created)
+		if timeout != "" {
+			p.Annotations = map[string]string{v1alpha1.PodUsageTemplateWaitTimeoutAnnotation: timeout}
+		}
+		return p
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name  string
+		pod   *v1.Pod
+		gated bool
+	}{
+		{name: "not opted in", pod: pod("pending", "", now)},
+		{name: "invalid timeout", pod: pod("pending", "soon", now)},
+		{name: "not evaluated", pod: pod("pending", "30m", now), gated: true},
+		{name: "not created", pod: pod("missing", "30m", now), gated: true},
+		{name: "evaluated", pod: pod("evaluated", "30m", now)},
+		{name: "evaluated with a low confidence", pod: pod("low-confidence", "30m", now)},
+		{name: "not evaluated for the target resources", pod: pod("memory-only", "30m", now), gated: true},
+		{name: "disabled", pod: pod("disabled", "30m", now)},
+		{name: "timed out", pod: pod("pending", "30m", now.Add(-time.Hour))},
+		{name: "without usage template", pod: pod("", "30m", now)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := newTestUsageEvaluationManager(nil, tt.pod, nil, []*v1alpha1.UsageTemplate{
+				pending, usageTemplate("evaluated", true), usageTemplate("disabled", false), lowConfidence, memoryOnly,
+			})
+			mgr.MinCoverage = CoverageThresholds{MinConfidence: 50}
+			recorder := events.NewFakeRecorder(1)
+			pl := &TemporalUtilization{
+				utMgr:  mgr,
+				handle: &testEventHandle{recorder: recorder},
+			}
+
+			status := pl.PreEnqueue(context.Background(), tt.pod)
+			if !tt.gated {
+				assert.True(t, status.IsSuccess())
+				assert.Len(t, recorder.Events, 0)
+				return
+			}
+
+			assert.Equal(t, framework.UnschedulableAndUnresolvable, status.Code())
+			// the waiting reason is surfaced on the pod
+			assert.Len(t, recorder.Events, 1)
+			assert.Contains(t, <-recorder.Events, "WaitingForUsageTemplate")
+		})
+	}
+}
@@ -3,10 +3,11 @@ package temporalutilization
 import (
 	"fmt"
 	"math"
-	"strconv"
 	"time"
 
 	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/cache"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/utils"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/api/v1/resource"
@@ -30,28 +31,48 @@ func sameUtilizationByHour(milliValue float32) *UsageTemplate {
 	return &results
 }
 
+// noUsage returns empty hourly usages of the resource
+func noUsage(resourceName string) *UsageTemplate {
+	return &UsageTemplate{
+		resource:    resourceName,
+		weekDayHour: make(map[int16]float32),
+		weekendHour: make(map[int16]float32),
+	}
+}
+
 func getUsageTemplatesByPod(utMgr *UsageTemplateManager, namespace string, podName string, targetResources []string) (map[string]*UsageTemplate, error) {
 	pod, err := utMgr.podLister.Pods(namespace).Get(podName)
 	if err != nil {
 		return nil, err
 	}
 
+	return getPodUsages(utMgr, pod, targetResources)
+}
+
+// getPodUsages returns the hourly usages of the pod on the target resources
+func getPodUsages(utMgr *UsageTemplateManager, pod *v1.Pod, targetResources []string) (map[string]*UsageTemplate, error) {
 	_, ut := utMgr.GetUsageTemplate(pod)
 	podUsages := make(map[string]*UsageTemplate)
+	startHour, elapsedHours := usageAnchor(pod, time.Now().UTC())
 
-	// assume utilization by class when there is no CRD or we are not using it
+	// assume utilization by the fallback policy when there is no CRD or we are not using it
 	for _, res := range targetResources {
 		var err error
 
-		if ut == nil || !ut.Spec.Enabled {
-			podUsages[res], err = assumeUsageByClass(pod, res)
+		usable := hasUsableUsageTemplate(utMgr, ut, res)
+		if usable && !ut.Status.IsLongRunning && elapsedHours > expectedDurationHours(ut, res) {
+			// the pod outlived its short-lived usages, it is expected to end and no longer contributes
+			klog.V(5).InfoS("pod runs longer than its usage template, assuming no usage",
+				"pod", klog.KObj(pod), "usageTemplate", klog.KObj(ut), "resource", res, "elapsedHours", elapsedHours)
+			podUsages[res] = noUsage(res)
+			continue
+		}
+
+		if usable {
+			podUsages[res], err = extractUsageFromCRD(utMgr.templates.Get(ut), res, startHour, elapsedHours)
 		} else {
-			if ut.Status.HistoricalUsage != nil {
-				podUsages[res], err = extractUsageFromCRD(ut, res, time.Now().UTC().Hour())
-			} else {
-				// we do not have any historical usage yet
-				podUsages[res], err = assumeUsageByClass(pod, res)
-			}
+			// we do not have any historical usage yet, or not enough data to trust it
+			podUsages[res], err = utMgr.assumeUsage(pod, res)
 		}
 
 		if err != nil {
@@ -66,51 +87,116 @@ func getUsageTemplatesByPod(utMgr *UsageTemplateManager, namespace string, podNa
 	return podUsages, nil
 }
 
-func sumPodUsageByNode(cachePods []NamespacedPod, utMgr *UsageTemplateManager, targetResources []string) (map[string]*UsageTemplate, error) {
-	results := make(map[string]*UsageTemplate)
+// PodHourlyUsage returns the weekday and weekend hourly usages of the resource of a pod from its parsed usage template,
+// as the scheduler forecasts them at now, i.e. none for a pod that outlived the usages of a short-lived application.
+// It returns false when the usage template has no historical usage of the resource or not evaluated from enough data,
+// in which case the scheduler assumes the usages by AssumedHourlyUsage
+func PodHourlyUsage(parsed *cache.UsageTemplateCache, pod *v1.Pod, resourceName string, thresholds CoverageThresholds,
+	now time.Time) (map[int16]float32, map[int16]float32, bool) {
+	if parsed == nil || !isUsableUsageTemplate(parsed.Object, resourceName, thresholds) || len(parsed.Usages[resourceName]) == 0 {
+		return nil, nil, false
+	}
 
-	for _, cachePod := range cachePods {
+	ut := parsed.Object
+	startHour, elapsedHours := usageAnchor(pod, now.UTC())
+	if !ut.Status.IsLongRunning && elapsedHours > expectedDurationHours(ut, resourceName) {
+		return map[int16]float32{}, map[int16]float32{}, true
+	}
 
-		podUsages, err := getUsageTemplatesByPod(utMgr, cachePod.Namespace, cachePod.Name, targetResources)
-		if err != nil {
-			return results, err
-		}
+	usage, err := extractUsageFromCRD(parsed, resourceName, startHour, elapsedHours)
+	if err != nil {
+		return nil, nil, false
+	}
 
-		for resource, usageTemplate := range podUsages {
-			if _, ok := results[resource]; !ok {
-				results[resource] = &UsageTemplate{
-					resource:    resource,
-					unit:        DefaultResourceUnitMap[resource],
-					weekDayHour: make(map[int16]float32),
-					weekendHour: make(map[int16]float32),
-				}
-			}
+	return usage.weekDayHour, usage.weekendHour, true
+}
 
-			if usageTemplate == nil {
-				klog.Warningf("empty usage template for pod: %s | resource: %s", cachePod.Name, resource)
-				continue
-			}
+// usageAnchor returns the hour of the day the usages of a short-lived pod start from,
+// and the number of hours of the usages that have already elapsed.
+// Pods that have not started yet, e.g. the pod being scheduled, start from the current hour.
+func usageAnchor(pod *v1.Pod, now time.Time) (int, int) {
+	if pod.Status.StartTime == nil || pod.Status.StartTime.Time.After(now) {
+		return now.Hour(), 0
+	}
 
-			for hour, value := range usageTemplate.weekDayHour {
-				if _, ok := results[resource].weekDayHour[hour]; !ok {
-					results[resource].weekDayHour[hour] = value
-				} else {
-					results[resource].weekDayHour[hour] += value
-				}
+	startTime := pod.Status.StartTime.Time.UTC()
+	return startTime.Hour(), int(now.Sub(startTime).Hours())
+}
+
+// expectedDurationHours returns the last hour of the usages of a resource, i.e. the hour a short-lived pod is expected to end
+func expectedDurationHours(ut *v1alpha1.UsageTemplate, res string) int {
+	duration := 0
+	for _, item := range ut.Status.HistoricalUsage.Items {
+		if item.Resource != res {
+			continue
+		}
+		for _, usage := range item.Usages {
+			if hour := sampleHourOfDay(usage.Hour); hour > duration {
+				duration = hour
 			}
+		}
+	}
+	return duration
+}
 
-			for hour, value := range usageTemplate.weekendHour {
-				if _, ok := results[resource].weekendHour[hour]; !ok {
-					results[resource].weekendHour[hour] = value
-				} else {
-					results[resource].weekendHour[hour] += value
+// sampleHourOfDay returns the hour of the day of a sample, the weekend samples are evaluated as the hours 24 to 47
+func sampleHourOfDay(hour int32) int {
+	return int(hour) % NumHoursInADay
+}
+
+// hasUsableUsageTemplate checks whether the usage template is enabled and has historical usages evaluated from enough data
+func hasUsableUsageTemplate(utMgr *UsageTemplateManager, ut *v1alpha1.UsageTemplate, res string) bool {
+	return isUsableUsageTemplate(ut, res, utMgr.MinCoverage)
+}
+
+func isUsableUsageTemplate(ut *v1alpha1.UsageTemplate, res string, thresholds CoverageThresholds) bool {
+	if ut == nil || !ut.Spec.Enabled || ut.Status.HistoricalUsage == nil {
+		return false
+	}
+	return hasSufficientCoverage(ut, res, thresholds)
+}
+
+// hasSufficientCoverage checks whether the historical usages of a resource were evaluated from enough data
+func hasSufficientCoverage(ut *v1alpha1.UsageTemplate, res string, thresholds CoverageThresholds) bool {
+	if thresholds.MinConfidence > 0 {
+		if coverage := resourceCoverage(ut, res); coverage == nil || coverage.Confidence < thresholds.MinConfidence {
+			klog.V(5).InfoS("usage template confidence below threshold, assuming usage by class",
+				"usageTemplate", klog.KObj(ut), "minConfidence", thresholds.MinConfidence)
+			return false
+		}
+	}
+
+	if thresholds.MinSamplesPerHour > 0 {
+		for _, item := range ut.Status.HistoricalUsage.Items {
+			if item.Resource != res {
+				continue
+			}
+			for _, sample := range item.Usages {
+				if sample.Count < thresholds.MinSamplesPerHour {
+					klog.V(5).InfoS("usage template samples below threshold, assuming usage by class",
+						"usageTemplate", klog.KObj(ut), "resource", res, "hour", sample.Hour,
+						"isWeekday", sample.IsWeekday, "count", sample.Count, "minSamplesPerHour", thresholds.MinSamplesPerHour)
+					return false
 				}
 			}
 		}
-
 	}
 
-	return results, nil
+	return true
+}
+
+// resourceCoverage returns the coverage of the evaluation of a resource,
+// or the coverage of the usage template when it was evaluated before the resources had their own
+func resourceCoverage(ut *v1alpha1.UsageTemplate, res string) *v1alpha1.EvaluationCoverage {
+	if ut.Status.HistoricalUsage == nil {
+		return ut.Status.Coverage
+	}
+	for _, item := range ut.Status.HistoricalUsage.Items {
+		if item.Resource == res && item.Coverage != nil {
+			return item.Coverage
+		}
+	}
+	return ut.Status.Coverage
 }
 
 func sumUsageByHour(a, b map[string]*UsageTemplate) map[string]*UsageTemplate {
@@ -156,12 +242,27 @@ func getResourceValue(resourceName string, resourceList v1.ResourceList) (int64,
 	case v1.ResourceCPU.String():
 		return resourceList.Cpu().MilliValue(), nil
 	case v1.ResourceMemory.String():
-		return resourceList.Memory().MilliValue(), nil
+		return resourceList.Memory().Value(), nil
 	default:
 		return 0, fmt.Errorf("unsupported resource %s", resourceName)
 	}
 }
 
+// scaleByPerformanceFactor converts the node capacity of the resource into reference cores,
+// the usage templates are normalized by the performance factor of the nodes they were measured on
+func scaleByPerformanceFactor(node *v1.Node, resourceName string, capacity int64) int64 {
+	factor, err := utils.GetNodePerformanceFactor(node, resourceName)
+	if err != nil {
+		klog.V(4).ErrorS(err, "Ignoring node performance factor", "node", klog.KObj(node))
+	}
+
+	if factor == 1 {
+		return capacity
+	}
+
+	return int64(math.Round(float64(capacity) * factor))
+}
+
 func assumeUsageByClass(p *v1.Pod, resourceName string) (*UsageTemplate, error) {
 
 	pClass := v1qos.GetPodQOS(p)
@@ -192,45 +293,40 @@ func assumeUsageByClass(p *v1.Pod, resourceName string) (*UsageTemplate, error)
 	return sameUtilizationByHour(float32(v)), nil
 }
 
-func extractUsageFromCRD(ut *v1alpha1.UsageTemplate, resourceName string, currentHour int) (*UsageTemplate, error) {
+// extractUsageFromCRD converts the parsed historical usages of a resource into hourly usages.
+// The usages of an app that is not long running are relative to the pod start, they are shifted to startHour,
+// and the first elapsedHours of them are dropped since they have already passed.
+func extractUsageFromCRD(parsed *cache.UsageTemplateCache, resourceName string, startHour int, elapsedHours int) (*UsageTemplate, error) {
 	results := &UsageTemplate{
 		resource:    resourceName,
 		weekDayHour: make(map[int16]float32),
 		weekendHour: make(map[int16]float32),
 	}
 
-	if ut == nil || ut.Status.HistoricalUsage == nil {
+	if parsed == nil || parsed.Object.Status.HistoricalUsage == nil {
 		return nil, fmt.Errorf("no usage template historical usage")
 	}
 
-	historicalUsage := ut.Status.HistoricalUsage
+	ut := parsed.Object
 
 	offset := 0
 
-	// when an app is not longrunning, we off set the hour from current hour
+	// when an app is not longrunning, we off set the hour from the pod start hour
 	if !ut.Status.IsLongRunning {
-		offset = currentHour
+		offset = startHour
 	}
 
-	for _, item := range historicalUsage.Items {
-		if item.Resource != resourceName {
+	for _, usage := range parsed.Usages[resourceName] {
+		if !ut.Status.IsLongRunning && sampleHourOfDay(usage.Hour) < elapsedHours {
 			continue
 		}
 
-		for _, usage := range item.Usages {
-			v, err := strconv.ParseFloat(usage.Value, 64)
-			if err != nil {
-				klog.ErrorS(err, "cannot parse float", "value", usage.Value)
-				continue
-			}
-
-			offsetHour := math.Mod(float64(offset)+float64(usage.Hour), NumHoursInADay)
+		offsetHour := math.Mod(float64(offset)+float64(usage.Hour), NumHoursInADay)
 
-			if usage.IsWeekday {
-				results.weekDayHour[int16(offsetHour)] = float32(v)
-			} else {
-				results.weekendHour[int16(offsetHour)] = float32(v)
-			}
+		if usage.IsWeekday {
+			results.weekDayHour[int16(offsetHour)] = usage.Value
+		} else {
+			results.weekendHour[int16(offsetHour)] = usage.Value
 		}
 	}
 
@@ -270,18 +366,22 @@ func extractUsageFromCRD(ut *v1alpha1.UsageTemplate, resourceName string, curren
 	return results, nil
 }
 
-func obtainForecasts(utMgr *UsageTemplateManager, nodeInfo *framework.NodeInfo, nodeName string, pod *v1.Pod, supportedTargetResources []string) (map[string]*UsageTemplate, string, error) {
-
-	pods := utMgr.GetNodePods(nodeName)
-
-	pods = append(pods, NamespacedPod{Namespace: pod.Namespace, Name: pod.Name})
+func obtainForecasts(utMgr *UsageTemplateManager, nodeInfo *framework.NodeInfo, nodeName string, podUsages map[string]*UsageTemplate, supportedTargetResources []string) (map[string]*UsageTemplate, string, error) {
 
-	// let's calculate the forecast and see what we get
-	forecasts, err := sumPodUsageByNode(pods, utMgr, supportedTargetResources)
+	nodeUsages, err := utMgr.forecasts.get(nodeName)
 	if err != nil {
 		return nil, fmt.Sprintf("Summing Pod Usages for node %q: %v", nodeName, err), err
 	}
 
+	// let's calculate the forecast and see what we get
+	forecasts := make(map[string]*UsageTemplate, len(supportedTargetResources))
+	addUsages(forecasts, nodeUsages)
+	addUsages(forecasts, podUsages)
+	if node := nodeInfo.Node(); node != nil {
+		// the usage of the node not attributed to any pod, e.g. the kernel, the kubelet and the host daemons
+		addUsages(forecasts, utMgr.baselines.get(node, supportedTargetResources))
+	}
+
 	for k, v := range forecasts {
 		if v != nil {
 			klog.V(6).InfoS("Forecast", "Node", klog.KObj(nodeInfo.Node()), "Resource", k, "UsageTemplate: WeekDay", v.weekDayHour, "UsageTemplate: WeekEnd", v.weekendHour)
@@ -13,7 +13,6 @@ package temporalutilization
 // Date: 2024-10-18
 
 import (
-	"math"
 	"testing"
 	"time"
 
@@ -21,6 +20,10 @@ import (
 	testutils "gitee.com/openeuler/paws/scheduler/pkg/test/util"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	testClientSet "k8s.io/client-go/kubernetes/fake"
 	st "k8s.io/kubernetes/pkg/scheduler/testing"
 )
 
@@ -146,3 +149,340 @@ func shiftUsageTemplate(old map[string]*UsageTemplate, currentHour int) map[stri
 	}
 	return new
 }
+
+func TestUsageTemplateCoverageFallback(t *testing.T) {
+	pod := st.MakePod().Namespace("default").Name("pod-1").Labels(map[string]string{
+		v1alpha1.UsageTemplateLabelIdentifier: "test-crd-1",
+	}).Containers([]v1.Container{
+		st.MakeContainer().Resources(map[v1.ResourceName]string{
+			v1.ResourceCPU: "900m",
+		}).Obj(),
+	}).Obj()
+
+	tests := []struct {
+		name             string
+		thresholds       CoverageThresholds
+		coverage         *v1alpha1.EvaluationCoverage
+		resourceCoverage *v1alpha1.EvaluationCoverage
+		sampleCount      int32
+		expectedFallback bool
+	}{
+		{
+			name:             "no thresholds, usages are used",
+			thresholds:       CoverageThresholds{},
+			expectedFallback: false,
+		},
+		{
+			name:             "confidence above threshold, usages are used",
+			thresholds:       CoverageThresholds{MinConfidence: 50},
+			coverage:         &v1alpha1.EvaluationCoverage{Confidence: 80},
+			expectedFallback: false,
+		},
+		{
+			name:             "confidence below threshold, assume usage by requests",
+			thresholds:       CoverageThresholds{MinConfidence: 50},
+			coverage:         &v1alpha1.EvaluationCoverage{Confidence: 10},
+			expectedFallback: true,
+		},
+		{
+			name:             "resource confidence above threshold, usages are used",
+			thresholds:       CoverageThresholds{MinConfidence: 50},
+			coverage:         &v1alpha1.EvaluationCoverage{Confidence: 10},
+			resourceCoverage: &v1alpha1.EvaluationCoverage{Confidence: 80},
+			expectedFallback: false,
+		},
+		{
+			name:             "resource confidence below threshold, assume usage by requests",
+			thresholds:       CoverageThresholds{MinConfidence: 50},
+			coverage:         &v1alpha1.EvaluationCoverage{Confidence: 80},
+			resourceCoverage: &v1alpha1.EvaluationCoverage{Confidence: 10},
+			expectedFallback: true,
+		},
+		{
+			name:             "no coverage reported, assume usage by requests",
+			thresholds:       CoverageThresholds{MinConfidence: 50},
+			expectedFallback: true,
+		},
+		{
+			name:             "enough samples per hour, usages are used",
+			thresholds:       CoverageThresholds{MinSamplesPerHour: 12},
+			sampleCount:      12,
+			expectedFallback: false,
+		},
+		{
+			name:             "too few samples per hour, assume usage by requests",
+			thresholds:       CoverageThresholds{MinSamplesPerHour: 12},
+			sampleCount:      3,
+			expectedFallback: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ut := testutils.MakeUsageTemplate("test-crd-1", "default", true, "Burstable",
+				map[string]map[int]float32{
+					"cpu": testutils.SameUsageADay(100),
+				}, map[string]map[int]float32{}, true)
+			ut.Status.Coverage = tt.coverage
+			for i := range ut.Status.HistoricalUsage.Items {
+				ut.Status.HistoricalUsage.Items[i].Coverage = tt.resourceCoverage
+				for j := range ut.Status.HistoricalUsage.Items[i].Usages {
+					ut.Status.HistoricalUsage.Items[i].Usages[j].Count = tt.sampleCount
+				}
+			}
+
+			mgr := newTestUsageEvaluationManager(nil, pod, nil, []*v1alpha1.UsageTemplate{ut})
+			mgr.MinCoverage = tt.thresholds
+
+			podUsages, err := getUsageTemplatesByPod(mgr, pod.Namespace, pod.Name, []string{"cpu"})
+			assert.NoError(t, err)
+
+			if tt.expectedFallback {
+				assert.Equal(t, sameUtilizationByHour(900), podUsages["cpu"])
+			} else {
+				assert.Equal(t, float32(100), podUsages["cpu"].weekDayHour[0])
+			}
+		})
+	}
+}
+
+func TestUsageFallbackPolicy(t *testing.T) {
+	burstablePod := st.MakePod().Namespace("default").Name("pod-1").Containers([]v1.Container{
+		st.MakeContainer().Resources(map[v1.ResourceName]string{
+			v1.ResourceCPU: "200m",
+		}).Obj(),
+	}).Obj()
+	burstablePod.Spec.Containers[0].Resources.Limits = v1.ResourceList{
+		v1.ResourceCPU: resource.MustParse("800m"),
+	}
+
+	bestEffortPod := st.MakePod().Namespace("default").Name("pod-1").Containers([]v1.Container{
+		st.MakeContainer().Obj(),
+	}).Obj()
+
+	// a short-lived template with a start-up peak in the first 3 hours, expected to end after 8 hours
+	shortLived := testutils.MakeUsageTemplate("test-crd-3", "default", true, "Burstable",
+		map[string]map[int]float32{"cpu": testutils.MakeUsageAcrossPeriods([][]float32{
+			{0.0, 3.0, 500},
+			{3.0, 8.0, 100},
+		})}, map[string]map[int]float32{}, false)
+	startedPod := func(d time.Duration) *v1.Pod {
+		pod := bestEffortPod.DeepCopy()
+		pod.Status.StartTime = &metav1.Time{Time: time.Now().Add(-d)}
+		return pod
+	}
+	runningPod := startedPod(4*time.Hour + 30*time.Minute)
+	// the pod started 4 hours ago, the start-up peak has passed
+	anchored := noUsage("cpu")
+	for h := 4; h < 8; h++ {
+		hour := int16((runningPod.Status.StartTime.UTC().Hour() + h) % NumHoursInADay)
+		anchored.weekDayHour[hour] = 100
+		anchored.weekendHour[hour] = 100
+	}
+
+	tests := []struct {
+		name                 string
+		pod                  *v1.Pod
+		fallback             FallbackPolicy
+		namespaceAnnotations map[string]string
+		usageTemplates       []*v1alpha1.UsageTemplate
+		expected             *UsageTemplate
+	}{
+		{
+			name:     "by class assumes requests of burstable pods",
+			pod:      burstablePod,
+			fallback: FallbackPolicy{Mode: ByClassFallback},
+			expected: sameUtilizationByHour(200),
+		},
+		{
+			name:     "requests assumes the default requests of best effort pods",
+			pod:      bestEffortPod,
+			fallback: FallbackPolicy{Mode: RequestsFallback},
+			expected: sameUtilizationByHour(100),
+		},
+		{
+			name:     "limits assumes limits",
+			pod:      burstablePod,
+			fallback: FallbackPolicy{Mode: LimitsFallback},
+			expected: sameUtilizationByHour(800),
+		},
+		{
+			name:     "limits percentage assumes a percentage of limits",
+			pod:      burstablePod,
+			fallback: FallbackPolicy{Mode: LimitsPercentageFallback, LimitsPercentage: 50},
+			expected: sameUtilizationByHour(400),
+		},
+		{
+			name:     "limits percentage assumes default requests when there are no limits",
+			pod:      bestEffortPod,
+			fallback: FallbackPolicy{Mode: LimitsPercentageFallback, LimitsPercentage: 50},
+			expected: sameUtilizationByHour(100),
+		},
+		{
+			name:     "namespace annotations override the configured policy",
+			pod:      burstablePod,
+			fallback: FallbackPolicy{Mode: ByClassFallback},
+			namespaceAnnotations: map[string]string{
+				v1alpha1.NamespaceUsageFallbackPolicyAnnotation:           string(LimitsPercentageFallback),
+				v1alpha1.NamespaceUsageFallbackLimitsPercentageAnnotation: "75",
+			},
+			// 75% of the 800m limits, rather than the 200m requests by class
+			expected: sameUtilizationByHour(600),
+		},
+		{
+			name:     "invalid namespace annotations are ignored",
+			pod:      burstablePod,
+			fallback: FallbackPolicy{Mode: LimitsFallback},
+			namespaceAnnotations: map[string]string{
+				v1alpha1.NamespaceUsageFallbackPolicyAnnotation: "Unknown",
+			},
+			expected: sameUtilizationByHour(800),
+		},
+		{
+			name:     "namespace aggregate takes the hourly maximum of the namespace templates",
+			pod:      bestEffortPod,
+			fallback: FallbackPolicy{Mode: NamespaceAggregateFallback},
+			usageTemplates: []*v1alpha1.UsageTemplate{
+				testutils.MakeUsageTemplate("test-crd-1", "default", true, "Burstable",
+					map[string]map[int]float32{"cpu": testutils.SameUsageADay(300)},
+					map[string]map[int]float32{"cpu": testutils.SameUsageADay(300)}, true),
+				testutils.MakeUsageTemplate("test-crd-2", "default", true, "Burstable",
+					map[string]map[int]float32{"cpu": testutils.SameUsageADay(500)},
+					map[string]map[int]float32{"cpu": testutils.SameUsageADay(100)}, true),
+			},
+			expected: &UsageTemplate{
+				resource:    "cpu",
+				weekDayHour: sameUtilizationByHour(500).weekDayHour,
+				weekendHour: sameUtilizationByHour(300).weekendHour,
+			},
+		},
+		{
+			name:           "namespace aggregate anchors short-lived templates at the pod start",
+			pod:            runningPod,
+			fallback:       FallbackPolicy{Mode: NamespaceAggregateFallback},
+			usageTemplates: []*v1alpha1.UsageTemplate{shortLived},
+			expected:       anchored,
+		},
+		{
+			name:           "namespace aggregate drops the short-lived templates the pod outlived",
+			pod:            startedPod(10 * time.Hour),
+			fallback:       FallbackPolicy{Mode: NamespaceAggregateFallback},
+			usageTemplates: []*v1alpha1.UsageTemplate{shortLived},
+			expected:       noUsage("cpu"),
+		},
+		{
+			name:     "namespace aggregate assumes usage by class without namespace templates",
+			pod:      bestEffortPod,
+			fallback: FallbackPolicy{Mode: NamespaceAggregateFallback},
+			expected: sameUtilizationByHour(100),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := newTestUsageEvaluationManager(nil, tt.pod, nil, tt.usageTemplates)
+			mgr.Fallback = tt.fallback
+
+			nsInformer := informers.NewSharedInformerFactory(testClientSet.NewSimpleClientset(), 0).Core().V1().Namespaces()
+			assert.NoError(t, nsInformer.Informer().GetStore().Add(&v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "default", Annotations: tt.namespaceAnnotations},
+			}))
+			mgr.nsLister = nsInformer.Lister()
+
+			podUsages, err := getUsageTemplatesByPod(mgr, tt.pod.Namespace, tt.pod.Name, []string{"cpu"})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, podUsages["cpu"])
+		})
+	}
+}
+
+func TestShortLivedUsageAnchoredToPodStart(t *testing.T) {
+	now := time.Now().UTC()
+
+	// a job with a start-up peak in the first 3 hours, and expected to end after 8 hours,
+	// evaluated from weekday runs or from weekend runs whose samples are the hours 24 to 47
+	usages := map[string]map[int]float32{
+		"cpu": testutils.MakeUsageAcrossPeriods([][]float32{
+			{0.0, 3.0, 500},
+			{3.0, 8.0, 100},
+		}),
+	}
+	templates := map[string]*v1alpha1.UsageTemplate{
+		"weekday": testutils.MakeUsageTemplate("test-crd-1", "default", true, "Burstable",
+			usages, map[string]map[int]float32{}, false),
+		"weekend": testutils.MakeUsageTemplate("test-crd-1", "default", true, "Burstable",
+			map[string]map[int]float32{}, usages, false),
+	}
+
+	makePod := func(startTime *time.Time) *v1.Pod {
+		pod := st.MakePod().Namespace("default").Name("pod-1").Labels(map[string]string{
+			v1alpha1.UsageTemplateLabelIdentifier: "test-crd-1",
+		}).Containers([]v1.Container{
+			st.MakeContainer().Resources(map[v1.ResourceName]string{
+				v1.ResourceCPU: "900m",
+			}).Obj(),
+		}).Obj()
+		if startTime != nil {
+			pod.Status.StartTime = &metav1.Time{Time: *startTime}
+		}
+		return pod
+	}
+
+	// expectedFrom builds the usages from the given relative hour, anchored to the start hour
+	expectedFrom := func(startHour, fromHour int) *UsageTemplate {
+		expected := &UsageTemplate{
+			resource:    "cpu",
+			weekDayHour: make(map[int16]float32),
+			weekendHour: make(map[int16]float32),
+		}
+		for h := fromHour; h < 8; h++ {
+			v := float32(100)
+			if h < 3 {
+				v = 500
+			}
+			expected.weekDayHour[int16((startHour+h)%NumHoursInADay)] = v
+			expected.weekendHour[int16((startHour+h)%NumHoursInADay)] = v
+		}
+		return expected
+	}
+
+	startedHoursAgo := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+
+	tests := []struct {
+		name      string
+		startTime *time.Time
+		expected  *UsageTemplate
+	}{
+		{
+			name:     "pod not started yet starts from the current hour",
+			expected: expectedFrom(now.Hour(), 0),
+		},
+		{
+			name:      "running pod starts from its start hour without the elapsed hours",
+			startTime: startedHoursAgo(2*time.Hour + 30*time.Minute),
+			expected:  expectedFrom(now.Add(-2*time.Hour-30*time.Minute).Hour(), 2),
+		},
+		{
+			name:      "pod running longer than expected contributes no usage",
+			startTime: startedHoursAgo(10 * time.Hour),
+			expected:  noUsage("cpu"),
+		},
+	}
+
+	for samples, ut := range templates {
+		for _, tt := range tests {
+			t.Run(samples+" "+tt.name, func(t *testing.T) {
+				pod := makePod(tt.startTime)
+				mgr := newTestUsageEvaluationManager(nil, pod, nil, []*v1alpha1.UsageTemplate{ut})
+
+				podUsages, err := getUsageTemplatesByPod(mgr, pod.Namespace, pod.Name, []string{"cpu"})
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, podUsages["cpu"])
+				assert.Equal(t, 7, expectedDurationHours(ut, "cpu"))
+			})
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package temporalutilization
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// DecayCurve decides how much less the hours further away from now weigh in scoring
+type DecayCurve string
+
+const (
+	// NoDecay weighs every hour equally
+	NoDecay DecayCurve = "None"
+	// LinearDecay weighs the hours linearly less until the end of the horizon
+	LinearDecay DecayCurve = "Linear"
+	// ExponentialDecay halves the weight of the hours every half-life
+	ExponentialDecay DecayCurve = "Exponential"
+
+	// DefaultDecayHalfLifeHours is the half-life of the exponential decay
+	DefaultDecayHalfLifeHours = 6
+
+	// NumHoursInAWeek is the longest horizon, after which the weekday and weekend hours repeat
+	NumHoursInAWeek = 7 * NumHoursInADay
+)
+
+var supportedDecayCurves = map[DecayCurve]bool{
+	NoDecay:          true,
+	LinearDecay:      true,
+	ExponentialDecay: true,
+}
+
+// Horizon decides which hours of the forecasts are considered by the filtering and scoring
+type Horizon struct {
+	// ScoringHours is the number of upcoming hours scored, 0 scores all the hours of a week
+	ScoringHours int
+	// Decay is the curve weighing the upcoming hours in scoring
+	Decay DecayCurve
+	// DecayHalfLifeHours is the half-life of the exponential decay
+	DecayHalfLifeHours int
+	// InferFromTemplate limits the hours to the expected lifetime of the pods
+	// whose usage templates are not long running
+	InferFromTemplate bool
+}
+
+func validateHorizon(h Horizon) error {
+	if h.ScoringHours < 0 || h.ScoringHours > NumHoursInAWeek {
+		return fmt.Errorf("scoring horizon must be between zero and %d hours, got %d", NumHoursInAWeek, h.ScoringHours)
+	}
+
+	if !supportedDecayCurves[h.Decay] {
+		return fmt.Errorf("scoring decay %q not supported", h.Decay)
+	}
+
+	if h.Decay == ExponentialDecay && h.DecayHalfLifeHours <= 0 {
+		return fmt.Errorf("scoring decay half-life must be greater than zero, got %d", h.DecayHalfLifeHours)
+	}
+
+	return nil
+}
+
+// hourSlot is an hour of the weekday or weekend usages, weighted by how far away it is from now
+type hourSlot struct {
+	isWeekday bool
+	hour      int16
+	weight    float64
+}
+
+// filterSlots returns the hours the pod is expected to overlap, i.e. all the hours for long running pods
+func (h Horizon) filterSlots(now time.Time, lifetimeHours int) []hourSlot {
+	return horizonSlots(now, lifetimeHours, NoDecay, 0)
+}
+
+// scoreSlots returns the upcoming hours within the scoring horizon and the lifetime of the pod, weighted by the decay
+func (h Horizon) scoreSlots(now time.Time, lifetimeHours int) []hourSlot {
+	hours := h.ScoringHours
+	if lifetimeHours > 0 && (hours == 0 || lifetimeHours < hours) {
+		hours = lifetimeHours
+	}
+	return horizonSlots(now, hours, h.Decay, h.DecayHalfLifeHours)
+}
+
+// horizonSlots returns the weekday and weekend hours from now until the horizon,
+// a horizon of 0 or longer than a week returns all of them.
+// An hour repeating in the horizon is weighed by its first occurrence.
+func horizonSlots(now time.Time, horizonHours int, decay DecayCurve, halfLifeHours int) []hourSlot {
+	span := horizonHours
+	if span <= 0 || span > NumHoursInAWeek {
+		span = NumHoursInAWeek
+	}
+
+	now = now.UTC()
+	seen := make(map[int16]bool, 2*NumHoursInADay)
+	slots := make([]hourSlot, 0, 2*NumHoursInADay)
+	for k := 0; k < span; k++ {
+		t := now.Add(time.Duration(k) * time.Hour)
+		isWeekday := t.Weekday() >= time.Monday && t.Weekday() <= time.Friday
+
+		key := int16(t.Hour())
+		if !isWeekday {
+			key += NumHoursInADay
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		slots = append(slots, hourSlot{
+			isWeekday: isWeekday,
+			hour:      int16(t.Hour()),
+			weight:    decayWeight(decay, k, span, halfLifeHours),
+		})
+	}
+
+	return slots
+}
+
+func decayWeight(decay DecayCurve, hoursAway int, span int, halfLifeHours int) float64 {
+	switch decay {
+	case LinearDecay:
+		return float64(span-hoursAway) / float64(span)
+	case ExponentialDecay:
+		return math.Pow(0.5, float64(hoursAway)/float64(halfLifeHours))
+	default:
+		return 1
+	}
+}
+
+// podLifetimeHours returns the expected lifetime of the pod inferred from its usage template,
+// or 0 when the pod is expected to be long running or has no usable usage template
+func podLifetimeHours(utMgr *UsageTemplateManager, pod *v1.Pod, targetResources []string) int {
+	_, ut := utMgr.GetUsageTemplate(pod)
+
+	lifetime := 0
+	for _, res := range targetResources {
+		if !hasUsableUsageTemplate(utMgr, ut, res) || ut.Status.IsLongRunning {
+			return 0
+		}
+
+		// usages are indexed by 0, so the pod overlaps one more hour than the last one
+		if hours := expectedDurationHours(ut, res) + 1; hours > lifetime {
+			lifetime = hours
+		}
+	}
+
+	return lifetime
+}
+
+// valueAt returns the usage at the hour slot
+func (u *UsageTemplate) valueAt(slot hourSlot) (float32, bool) {
+	if slot.isWeekday {
+		v, ok := u.weekDayHour[slot.hour]
+		return v, ok
+	}
+	v, ok := u.weekendHour[slot.hour]
+	return v, ok
+}
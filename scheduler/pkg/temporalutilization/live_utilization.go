@@ -0,0 +1,214 @@
+package temporalutilization
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	pluginConfig "gitee.com/openeuler/paws/scheduler/apis/config"
+	schedv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	"github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+const (
+	DefaultLiveUtilizationRefreshSeconds = 30
+
+	// liveUtilizationMaxAgeRefreshes is the number of refresh intervals after which the live usages are stale
+	liveUtilizationMaxAgeRefreshes = 3
+)
+
+// NodeUsageSource returns the current CPU usage of the nodes in millicores, by node name
+type NodeUsageSource interface {
+	NodeCPUUsages(ctx context.Context) (map[string]float64, error)
+}
+
+// promNodeUsageSource queries the current CPU usage of the nodes from Prometheus,
+// measured on the root cgroup so that the pods without a usage template and the system daemons are included
+type promNodeUsageSource struct {
+	api       promv1.API
+	nodeLabel string
+	timeout   time.Duration
+}
+
+func newPromNodeUsageSource(address, nodeLabel string, timeout time.Duration) (*promNodeUsageSource, error) {
+	client, err := api.NewClient(api.Config{Address: address})
+	if err != nil {
+		return nil, err
+	}
+
+	return &promNodeUsageSource{api: promv1.NewAPI(client), nodeLabel: nodeLabel, timeout: timeout}, nil
+}
+
+// nodeCPUUsageQuery builds the query of the current CPU usage of every node, e.g.
+// sum by (node) (rate(container_cpu_usage_seconds_total{id="/"}[2m]))
+func nodeCPUUsageQuery(nodeLabel string) string {
+	resourceName := v1.ResourceCPU.String()
+	return fmt.Sprintf("sum by (%s) (%s(%s{id=\"/\"}[%s]))", nodeLabel,
+		schedv1alpha1.SupportedResourcesRangeMethod[resourceName], schedv1alpha1.SupportedResourcesMetricLabel[resourceName],
+		schedv1alpha1.SupportedResourcesRateTimeWindow[resourceName])
+}
+
+func (s *promNodeUsageSource) NodeCPUUsages(ctx context.Context) (map[string]float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	value, warnings, err := s.api.Query(ctx, nodeCPUUsageQuery(s.nodeLabel), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(warnings) > 0 {
+		klog.V(4).InfoS("Prometheus query warnings", "warnings", strings.Join(warnings, ","))
+	}
+
+	vector, ok := value.(model.Vector)
+	if !ok {
+		return nil, fmt.Errorf("unexpected prometheus result type %s", value.Type())
+	}
+
+	scalingFactor := schedv1alpha1.SupportedResourceMetricScalingFactor[v1.ResourceCPU.String()]
+	usages := make(map[string]float64, len(vector))
+	for _, sample := range vector {
+		node := string(sample.Metric[model.LabelName(s.nodeLabel)])
+		if node == "" {
+			continue
+		}
+		usages[node] = float64(sample.Value) * scalingFactor
+	}
+
+	return usages, nil
+}
+
+// liveUtilization caches the current CPU usage of the nodes, refreshed in the background like a load watcher
+// so that scoring never waits on the source
+type liveUtilization struct {
+	source   NodeUsageSource
+	interval time.Duration
+	// weight is the weight (0-1] of the live usage blended with the forecast of the current hour
+	weight float64
+	clock  clock.Clock
+
+	mu      sync.RWMutex
+	usages  map[string]float64
+	updated time.Time
+}
+
+// getLiveUtilization validates the live utilization args, the live utilization is disabled
+// when the weight is 0 or invalid
+func getLiveUtilization(args *pluginConfig.TemporalUtilizationArgs) *liveUtilization {
+	if args.LiveUtilizationWeight == 0 {
+		return nil
+	}
+
+	if args.LiveUtilizationWeight < 0 || args.LiveUtilizationWeight > 100 {
+		err := fmt.Errorf("must be between zero and a hundred")
+		klog.ErrorS(err, "Disabling live utilization, got", "liveUtilizationWeight", args.LiveUtilizationWeight)
+		return nil
+	}
+
+	refresh := args.LiveUtilizationRefreshSeconds
+	if refresh <= 0 {
+		err := fmt.Errorf("must be greater than zero")
+		klog.ErrorS(err, "Using default live utilization refresh, got", "liveUtilizationRefreshSeconds", refresh,
+			"default", DefaultLiveUtilizationRefreshSeconds)
+		refresh = DefaultLiveUtilizationRefreshSeconds
+	}
+	interval := time.Duration(refresh) * time.Second
+
+	source, err := newPromNodeUsageSource(args.LiveUtilizationPrometheusAddress, args.LiveUtilizationNodeMetricLabel, interval)
+	if err != nil {
+		klog.ErrorS(err, "Disabling live utilization, unable to create prometheus client",
+			"liveUtilizationPrometheusAddress", args.LiveUtilizationPrometheusAddress)
+		return nil
+	}
+
+	return newLiveUtilization(source, interval, float64(args.LiveUtilizationWeight)/100)
+}
+
+func newLiveUtilization(source NodeUsageSource, interval time.Duration, weight float64) *liveUtilization {
+	return &liveUtilization{
+		source:   source,
+		interval: interval,
+		weight:   weight,
+		clock:    clock.RealClock{},
+		usages:   make(map[string]float64),
+	}
+}
+
+// run refreshes the live usages until the context is done
+func (l *liveUtilization) run(ctx context.Context) {
+	wait.UntilWithContext(ctx, l.refresh, l.interval)
+}
+
+func (l *liveUtilization) refresh(ctx context.Context) {
+	usages, err := l.source.NodeCPUUsages(ctx)
+	if err != nil {
+		liveUtilizationRefreshFailures.Inc()
+		klog.ErrorS(err, "Unable to refresh the live node utilization")
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.usages = usages
+	l.updated = l.clock.Now()
+}
+
+// get returns the live CPU usage of the node in millicores, or false when it is unknown or stale
+func (l *liveUtilization) get(nodeName string) (float64, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.clock.Since(l.updated) > liveUtilizationMaxAgeRefreshes*l.interval {
+		return 0, false
+	}
+
+	usage, ok := l.usages[nodeName]
+	return usage, ok
+}
+
+// blendLiveUtilization blends the CPU forecast of the current hour with the live usage of the node
+// plus the usage of the pod, so that the usage missing from the usage templates is seen before the next evaluation.
+// The live usage is in millicores of the node and is scaled by its performance factor as the forecasts are in reference cores
+func (pl *TemporalUtilization) blendLiveUtilization(forecasts map[string]*UsageTemplate, podUsages map[string]*UsageTemplate, node *v1.Node, now time.Time) {
+	if pl.live == nil {
+		return
+	}
+
+	resourceName := v1.ResourceCPU.String()
+	forecast, ok := forecasts[resourceName]
+	if !ok {
+		return
+	}
+
+	live, ok := pl.live.get(node.Name)
+	if !ok {
+		return
+	}
+
+	slot := horizonSlots(now, 1, NoDecay, 0)[0]
+	forecastValue, _ := forecast.valueAt(slot)
+
+	liveValue := float64(scaleByPerformanceFactor(node, resourceName, int64(live)))
+	if podUsage, ok := podUsages[resourceName]; ok && podUsage != nil {
+		if v, ok := podUsage.valueAt(slot); ok {
+			liveValue += float64(v)
+		}
+	}
+
+	blended := float32((1-pl.live.weight)*float64(forecastValue) + pl.live.weight*liveValue)
+	if slot.isWeekday {
+		forecast.weekDayHour[slot.hour] = blended
+	} else {
+		forecast.weekendHour[slot.hour] = blended
+	}
+
+	klog.V(6).InfoS("Blended live utilization", "node", klog.KObj(node), "forecast", forecastValue, "live", liveValue, "blended", blended)
+}
@@ -0,0 +1,123 @@
+package temporalutilization
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"gitee.com/openeuler/paws/scheduler/apis/config/v1beta3"
+	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	testutils "gitee.com/openeuler/paws/scheduler/pkg/test/util"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+type fakeNodeUsageSource struct {
+	usages map[string]float64
+	err    error
+}
+
+func (s *fakeNodeUsageSource) NodeCPUUsages(ctx context.Context) (map[string]float64, error) {
+	return s.usages, s.err
+}
+
+func TestNodeCPUUsageQuery(t *testing.T) {
+	assert.Equal(t, `sum by (node) (rate(container_cpu_usage_seconds_total{id="/"}[2m]))`, nodeCPUUsageQuery("node"))
+}
+
+func TestLiveUtilizationStaleness(t *testing.T) {
+	source := &fakeNodeUsageSource{usages: map[string]float64{"node-1": 500}}
+	live := newLiveUtilization(source, 30*time.Second, 0.5)
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	live.clock = fakeClock
+
+	_, ok := live.get("node-1")
+	assert.False(t, ok, "never refreshed")
+
+	live.refresh(context.Background())
+	usage, ok := live.get("node-1")
+	assert.True(t, ok)
+	assert.Equal(t, float64(500), usage)
+
+	_, ok = live.get("node-2")
+	assert.False(t, ok, "unknown node")
+
+	// a failed refresh keeps the last usages until they are stale
+	source.err = fmt.Errorf("prometheus unavailable")
+	fakeClock.Step(time.Minute)
+	live.refresh(context.Background())
+	_, ok = live.get("node-1")
+	assert.True(t, ok)
+
+	fakeClock.Step(time.Minute)
+	_, ok = live.get("node-1")
+	assert.False(t, ok, "stale")
+}
+
+func TestTemporalUtilizationScoringWithLiveUtilization(t *testing.T) {
+	nodeResources := map[v1.ResourceName]string{
+		v1.ResourceCPU: "1000m",
+	}
+	nodes := []*v1.Node{
+		st.MakeNode().Name("quiet-1").Capacity(nodeResources).Obj(),
+		st.MakeNode().Name("busy-1").Capacity(nodeResources).Obj(),
+	}
+
+	// both nodes are forecast at 300m, the busy one is running 900m not in any usage template
+	pod := st.MakePod().Name("pod-1").Namespace("default").Obj()
+	var scheduledPods []*v1.Pod
+	for _, node := range nodes {
+		scheduledPods = append(scheduledPods, st.MakePod().Namespace("default").Name("pod-"+node.Name).Node(node.Name).Labels(map[string]string{
+			v1alpha1.UsageTemplateLabelIdentifier: "test-crd-1",
+		}).Obj())
+	}
+	usageTemplates := []*v1alpha1.UsageTemplate{
+		testutils.MakeUsageTemplate("test-crd-1", "default", true, "BestEffort",
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(300)},
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(300)}, true),
+	}
+
+	mgr := newTestUsageEvaluationManager(nodes, pod, scheduledPods, usageTemplates)
+	for _, sp := range scheduledPods {
+		mgr.OnAdd(sp)
+	}
+
+	score := func(pl *TemporalUtilization) map[string]int64 {
+		state := framework.NewCycleState()
+		scores := make(map[string]int64)
+		for _, node := range nodes {
+			score, status := pl.Score(context.Background(), state, pod, node.Name)
+			assert.True(t, status.IsSuccess())
+			scores[node.Name] = score
+		}
+		return scores
+	}
+
+	pl := &TemporalUtilization{
+		HotSpotThreshold: int32(v1beta3.DefaultHotSpotThreshold),
+		utMgr:            mgr,
+	}
+	scores := score(pl)
+	assert.Equal(t, scores["quiet-1"], scores["busy-1"])
+
+	pl.live = newLiveUtilization(&fakeNodeUsageSource{usages: map[string]float64{"quiet-1": 300, "busy-1": 900}}, 30*time.Second, 0.5)
+	pl.live.refresh(context.Background())
+	scores = score(pl)
+	assert.Greater(t, scores["quiet-1"], scores["busy-1"])
+
+	// only the current hour is blended
+	forecasts, _, err := obtainForecasts(mgr, framework.NewNodeInfo(), "busy-1", nil, pl.SupportedTargetResources())
+	assert.NoError(t, err)
+	now := time.Now()
+	pl.blendLiveUtilization(forecasts, nil, nodes[1], now)
+	current := horizonSlots(now, 1, NoDecay, 0)[0]
+	next := horizonSlots(now.Add(time.Hour), 1, NoDecay, 0)[0]
+	value, _ := forecasts[v1.ResourceCPU.String()].valueAt(current)
+	assert.Equal(t, float32(600), value)
+	value, _ = forecasts[v1.ResourceCPU.String()].valueAt(next)
+	assert.Equal(t, float32(300), value)
+}
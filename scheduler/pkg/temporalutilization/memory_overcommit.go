@@ -0,0 +1,72 @@
+package temporalutilization
+
+import (
+	"fmt"
+	"math"
+
+	pluginConfig "gitee.com/openeuler/paws/scheduler/apis/config"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// DefaultMemoryOOMMarginPercentage is the percentage of the allocatable memory kept free of the forecast peak working set
+const DefaultMemoryOOMMarginPercentage = 10
+
+// getMemoryOOMMargin validates the memory OOM margin arg and returns the fraction of the allocatable memory
+// kept free of the forecast peak working set, an invalid margin falls back to the default
+func getMemoryOOMMargin(args *pluginConfig.TemporalUtilizationArgs) float64 {
+	if args.MemoryOOMMarginPercentage < 0 || args.MemoryOOMMarginPercentage > 100 {
+		err := fmt.Errorf("must be between zero and a hundred")
+		klog.ErrorS(err, "Using default memory OOM margin, got", "memoryOOMMarginPercentage", args.MemoryOOMMarginPercentage,
+			"default", DefaultMemoryOOMMarginPercentage)
+		return float64(DefaultMemoryOOMMarginPercentage) / 100
+	}
+	return float64(args.MemoryOOMMarginPercentage) / 100
+}
+
+// capMemoryOvercommit caps the overcommitted memory of the node. Unlike CPU which is throttled, memory beyond
+// the node is OOM killed, so the overcommit is capped by the allocatable memory left at the forecast peak
+// working set of the node and the pod over the hours, less the OOM margin.
+// Memory is not overcommitted when the memory forecasts are not maintained
+func (pl *TemporalUtilization) capMemoryOvercommit(resourceList v1.ResourceList, nodeInfo *framework.NodeInfo, podUsages *podUsagesState, slots []hourSlot) error {
+	overcommitted, ok := resourceList[v1.ResourceMemory]
+	if !ok {
+		return nil
+	}
+
+	if pl.utMgr.memoryForecasts == nil {
+		delete(resourceList, v1.ResourceMemory)
+		return nil
+	}
+
+	nodeUsages, err := pl.utMgr.memoryForecasts.get(nodeInfo.Node().Name)
+	if err != nil {
+		return err
+	}
+
+	forecasts := make(map[string]*UsageTemplate, 1)
+	addUsages(forecasts, nodeUsages)
+	if podUsages != nil {
+		addUsages(forecasts, podUsages.memoryUsages)
+	}
+
+	peak := float64(0)
+	if forecast, ok := forecasts[v1.ResourceMemory.String()]; ok {
+		for _, slot := range slots {
+			if v, ok := forecast.valueAt(slot); ok {
+				peak = math.Max(peak, float64(v))
+			}
+		}
+	}
+
+	ceiling := int64(math.Max(0, math.Round(float64(nodeInfo.Allocatable.Memory)*(1-pl.MemoryOOMMargin)-peak)))
+	if overcommitted.Value() > ceiling {
+		klog.V(4).InfoS("Capping memory overcommit by the forecast peak working set", "node", klog.KObj(nodeInfo.Node()),
+			"overcommitted", overcommitted.Value(), "ceiling", ceiling, "peak", int64(peak))
+		resourceList[v1.ResourceMemory] = *resource.NewQuantity(ceiling, resource.BinarySI)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package temporalutilization
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	metricsSubsystem = "paws_temporal_utilization"
+
+	// StaleDrift is a pod found in NodePodsCache but no longer on the node
+	StaleDrift = "stale"
+	// MissingDrift is a pod found on the node but missing from NodePodsCache
+	MissingDrift = "missing"
+)
+
+var (
+	nodePodsCacheSize = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "node_pods_cache_size",
+			Help:           "Number of pods tracked in the node pods cache",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	nodePodsCacheDrift = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "node_pods_cache_drift_total",
+			Help:           "Number of pods corrected in the node pods cache when reconciling with the scheduler snapshot, by type",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"type"},
+	)
+
+	liveUtilizationRefreshFailures = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "live_utilization_refresh_failures_total",
+			Help:           "Number of failed queries of the live node utilization",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	deferredPods = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "deferred_pods",
+			Help:           "Number of deferrable pods waiting for a valley of their node",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	deferredPodWaitSeconds = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "deferred_pod_wait_seconds",
+			Help:           "Time a deferrable pod waited for a valley of its node, by how the wait ended",
+			Buckets:        []float64{30, 60, 120, 300, 600, 900},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+
+	placementUsageCorrelation = metrics.NewHistogram(
+		&metrics.HistogramOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "placement_usage_correlation",
+			Help:           "Correlation of the hourly CPU usages of a pod with a usage template and of the node it is placed on",
+			Buckets:        metrics.LinearBuckets(-0.75, 0.25, 8),
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	registerMetrics sync.Once
+)
+
+// RegisterMetrics registers the plugin metrics with the scheduler metrics
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(nodePodsCacheSize, nodePodsCacheDrift, liveUtilizationRefreshFailures, deferredPods, deferredPodWaitSeconds,
+			placementUsageCorrelation)
+	})
+}
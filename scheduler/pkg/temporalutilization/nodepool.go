@@ -0,0 +1,87 @@
+package temporalutilization
+
+import (
+	"fmt"
+	"math"
+
+	pluginConfig "gitee.com/openeuler/paws/scheduler/apis/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+)
+
+// HotSpotPolicy is the hotspot threshold and the overcommit ceiling of a node
+type HotSpotPolicy struct {
+	HotSpotThreshold int32
+	HardThreshold    bool
+	// MaxOvercommitRatio caps the overcommit ratio annotations of the node
+	MaxOvercommitRatio float64
+}
+
+// NodePoolPolicy is the hotspot policy of the nodes matching the selector
+type NodePoolPolicy struct {
+	Selector labels.Selector
+	HotSpotPolicy
+}
+
+// getNodePoolPolicies validates the node pool policy args, the policies with an invalid node selector are ignored
+// and the invalid thresholds fall back to the plugin ones
+func getNodePoolPolicies(args *pluginConfig.TemporalUtilizationArgs, defaultPolicy HotSpotPolicy) []NodePoolPolicy {
+	policies := make([]NodePoolPolicy, 0, len(args.NodePoolPolicies))
+	for i, p := range args.NodePoolPolicies {
+		selector, err := metav1.LabelSelectorAsSelector(p.NodeSelector)
+		if err != nil {
+			klog.ErrorS(err, "Ignoring node pool policy with an invalid node selector", "index", i)
+			continue
+		}
+
+		policy := NodePoolPolicy{
+			Selector: selector,
+			HotSpotPolicy: HotSpotPolicy{
+				HotSpotThreshold:   p.HotSpotThreshold,
+				HardThreshold:      p.HardThreshold,
+				MaxOvercommitRatio: defaultPolicy.MaxOvercommitRatio,
+			},
+		}
+
+		if p.HotSpotThreshold <= 0 || p.HotSpotThreshold > 100 {
+			err := fmt.Errorf("must be greater than one and less than or equal to a hundred")
+			klog.ErrorS(err, "Using the plugin hotspot threshold for node pool policy, got", "index", i, "threshold", p.HotSpotThreshold)
+			policy.HotSpotThreshold = defaultPolicy.HotSpotThreshold
+		}
+
+		if p.MaxOvercommitPercentage != nil {
+			if *p.MaxOvercommitPercentage >= 0 {
+				policy.MaxOvercommitRatio = float64(*p.MaxOvercommitPercentage) / 100
+			} else {
+				err := fmt.Errorf("must not be negative")
+				klog.ErrorS(err, "Not capping the overcommit of node pool policy, got", "index", i, "maxOvercommitPercentage", *p.MaxOvercommitPercentage)
+			}
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies
+}
+
+// hotSpotPolicy returns the policy of the first node pool the node belongs to, or the plugin policy
+func (pl *TemporalUtilization) hotSpotPolicy(node *v1.Node) HotSpotPolicy {
+	for _, p := range pl.NodePoolPolicies {
+		if p.Selector.Matches(labels.Set(node.Labels)) {
+			return p.HotSpotPolicy
+		}
+	}
+
+	return pl.defaultHotSpotPolicy()
+}
+
+// defaultHotSpotPolicy is the policy of the nodes not in any node pool, their overcommit is not capped
+func (pl *TemporalUtilization) defaultHotSpotPolicy() HotSpotPolicy {
+	return HotSpotPolicy{
+		HotSpotThreshold:   pl.HotSpotThreshold,
+		HardThreshold:      pl.HardThreshold,
+		MaxOvercommitRatio: math.MaxFloat64,
+	}
+}
@@ -53,28 +53,46 @@ func convertToQuantity(resourceName string, value int64) (resource.Quantity, err
 
 // CalculateOvercommitResources calculates the overcommitted resources for the node based on the given ratios in annotations.
 func CalculateOvercommitResources(nodeInfo *framework.NodeInfo, supportedOvercommitResource map[string]string) (v1.ResourceList, error) {
+	return CalculateCappedOvercommitResources(nodeInfo, supportedOvercommitResource, math.MaxFloat64)
+}
+
+// CalculateCappedOvercommitResources calculates the overcommitted resources for the node based on the given ratios in annotations,
+// the ratios greater than maxRatio are capped at maxRatio.
+func CalculateCappedOvercommitResources(nodeInfo *framework.NodeInfo, supportedOvercommitResource map[string]string, maxRatio float64) (v1.ResourceList, error) {
+	return CalculateScheduledOvercommitResources(nodeInfo, supportedOvercommitResource, nil, maxRatio, nil)
+}
+
+// CalculateScheduledOvercommitResources calculates the overcommitted resources for the node over the given hours,
+// based on the ratios in the schedule annotations and, for the hours not in the schedules, the ratios in the ratio annotations.
+// The lowest ratio over the hours is used and the ratios greater than maxRatio are capped at maxRatio.
+func CalculateScheduledOvercommitResources(nodeInfo *framework.NodeInfo, supportedOvercommitResource map[string]string,
+	supportedOvercommitSchedule map[string]string, maxRatio float64, hours []HourOfWeek) (v1.ResourceList, error) {
 	results := v1.ResourceList{}
 	var errors []error // Collect errors for all resources
 
-	for resourceName, annotation := range supportedOvercommitResource {
-		v, ok := nodeInfo.Node().Annotations[annotation]
-		if !ok {
-			continue
-		}
+	resourceNames := make(map[string]bool, len(supportedOvercommitResource))
+	for resourceName := range supportedOvercommitResource {
+		resourceNames[resourceName] = true
+	}
+	for resourceName := range supportedOvercommitSchedule {
+		resourceNames[resourceName] = true
+	}
 
-		// Parse the ratio from the annotation
-		ratio, err := strconv.ParseFloat(v, 64) // Using 64-bit float for better precision
+	for resourceName := range resourceNames {
+		ratio, ok, err := overcommitRatio(nodeInfo.Node(), resourceName, supportedOvercommitResource[resourceName],
+			supportedOvercommitSchedule[resourceName], hours)
 		if err != nil {
-			errors = append(errors, fmt.Errorf("failed to parse ratio for resource %v: %v", resourceName, err))
+			errors = append(errors, err)
 			continue
 		}
-
-		// Ensure ratio is non-negative
-		if ratio < 0.0 {
-			errors = append(errors, fmt.Errorf("invalid ratio for resource %v: got %v", resourceName, ratio))
+		if !ok {
 			continue
 		}
 
+		if ratio > maxRatio {
+			ratio = maxRatio
+		}
+
 		// Get allocatable resource for the node
 		allocatable, err := getNodeAllocatableResource(nodeInfo, resourceName)
 		if err != nil {
@@ -102,3 +120,43 @@ func CalculateOvercommitResources(nodeInfo *framework.NodeInfo, supportedOvercom
 
 	return results, nil
 }
+
+// overcommitRatio returns the lowest overcommit ratio of the resource over the hours, the hours not in the schedule
+// annotation use the ratio annotation, or no overcommit without one. ok is false when the node has neither annotation
+func overcommitRatio(node *v1.Node, resourceName, ratioAnnotation, scheduleAnnotation string, hours []HourOfWeek) (float64, bool, error) {
+	ratio, hasRatio := 0.0, false
+	if v, ok := node.Annotations[ratioAnnotation]; ok && ratioAnnotation != "" {
+		// Parse the ratio from the annotation
+		r, err := strconv.ParseFloat(v, 64) // Using 64-bit float for better precision
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to parse ratio for resource %v: %v", resourceName, err)
+		}
+
+		// Ensure ratio is non-negative
+		if r < 0.0 {
+			return 0, false, fmt.Errorf("invalid ratio for resource %v: got %v", resourceName, r)
+		}
+		ratio, hasRatio = r, true
+	}
+
+	v, ok := node.Annotations[scheduleAnnotation]
+	if !ok || scheduleAnnotation == "" || len(hours) == 0 {
+		return ratio, hasRatio, nil
+	}
+
+	schedule, err := ParseSchedule(v)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to parse overcommit schedule for resource %v: %v", resourceName, err)
+	}
+
+	lowest := math.MaxFloat64
+	for _, hour := range hours {
+		r, ok := schedule[hour]
+		if !ok {
+			r = ratio
+		}
+		lowest = math.Min(lowest, r)
+	}
+
+	return lowest, true, nil
+}
@@ -1,6 +1,7 @@
 package overcommit
 
 import (
+	"math"
 	"testing"
 
 	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
@@ -88,3 +89,78 @@ func TestOvercommitRatioCalculation(t *testing.T) {
 	}
 }
 
+
+func TestCappedOvercommitRatioCalculation(t *testing.T) {
+	node := st.MakeNode().Capacity(map[v1.ResourceName]string{
+		v1.ResourceCPU: "1000m",
+	}).Obj()
+	node.Annotations = map[string]string{
+		v1alpha1.NodeCPUOvercommitRatioAnnotation: "0.8",
+	}
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(node)
+
+	got, err := CalculateCappedOvercommitResources(nodeInfo, v1alpha1.SupportedOvercommitResourceAnnotation, 0.5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500), got.Cpu().MilliValue())
+
+	got, err = CalculateCappedOvercommitResources(nodeInfo, v1alpha1.SupportedOvercommitResourceAnnotation, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(800), got.Cpu().MilliValue())
+}
+
+func TestParseSchedule(t *testing.T) {
+	schedule, err := ParseSchedule("0-23=0.5; weekday:8-18=0.1;weekend:3=0")
+	assert.NoError(t, err)
+	assert.Len(t, schedule, 2*hoursInADay)
+	assert.Equal(t, 0.5, schedule[HourOfWeek{IsWeekday: true, Hour: 7}])
+	assert.Equal(t, 0.1, schedule[HourOfWeek{IsWeekday: true, Hour: 8}])
+	assert.Equal(t, 0.1, schedule[HourOfWeek{IsWeekday: true, Hour: 18}])
+	assert.Equal(t, 0.5, schedule[HourOfWeek{IsWeekday: false, Hour: 8}])
+	assert.Equal(t, float64(0), schedule[HourOfWeek{IsWeekday: false, Hour: 3}])
+
+	for _, invalid := range []string{"8-18", "monday:8-18=0.1", "18-8=0.1", "0-24=0.1", "8=-0.1", "8=abc"} {
+		_, err := ParseSchedule(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestScheduledOvercommitRatioCalculation(t *testing.T) {
+	node := st.MakeNode().Capacity(map[v1.ResourceName]string{
+		v1.ResourceCPU: "1000m",
+	}).Obj()
+	node.Annotations = map[string]string{
+		v1alpha1.NodeCPUOvercommitRatioAnnotation:    "0.3",
+		v1alpha1.NodeCPUOvercommitScheduleAnnotation: "0-7=0.5;weekday:8-18=0.1",
+	}
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(node)
+
+	calculate := func(hours ...HourOfWeek) int64 {
+		got, err := CalculateScheduledOvercommitResources(nodeInfo, v1alpha1.SupportedOvercommitResourceAnnotation,
+			v1alpha1.SupportedOvercommitScheduleAnnotation, math.MaxFloat64, hours)
+		assert.NoError(t, err)
+		return got.Cpu().MilliValue()
+	}
+
+	night := HourOfWeek{IsWeekday: true, Hour: 2}
+	business := HourOfWeek{IsWeekday: true, Hour: 10}
+	evening := HourOfWeek{IsWeekday: true, Hour: 20}
+
+	assert.Equal(t, int64(500), calculate(night))
+	// the lowest ratio of the overlapped hours
+	assert.Equal(t, int64(100), calculate(night, business))
+	// the hours not in the schedule use the ratio annotation
+	assert.Equal(t, int64(300), calculate(night, evening))
+	// without hours, only the ratio annotation
+	assert.Equal(t, int64(300), calculate())
+
+	// without the ratio annotation the hours not in the schedule are not overcommitted
+	delete(node.Annotations, v1alpha1.NodeCPUOvercommitRatioAnnotation)
+	assert.Equal(t, int64(0), calculate(night, evening))
+
+	node.Annotations[v1alpha1.NodeCPUOvercommitScheduleAnnotation] = "invalid"
+	_, err := CalculateScheduledOvercommitResources(nodeInfo, v1alpha1.SupportedOvercommitResourceAnnotation,
+		v1alpha1.SupportedOvercommitScheduleAnnotation, math.MaxFloat64, []HourOfWeek{night})
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package overcommit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	weekdayPrefix = "weekday"
+	weekendPrefix = "weekend"
+	hoursInADay   = 24
+)
+
+// HourOfWeek is an hour in UTC of the weekdays or of the weekend days
+type HourOfWeek struct {
+	IsWeekday bool
+	Hour      int16
+}
+
+// Schedule is the overcommit ratio by hour of the week
+type Schedule map[HourOfWeek]float64
+
+// ParseSchedule parses the entries of an overcommit schedule separated by ";",
+// an entry is "[weekday:|weekend:]<start>-<end>=<ratio>" or "[weekday:|weekend:]<hour>=<ratio>"
+// with the UTC hours inclusive, an entry without a prefix applies to every day.
+// The later entries override the former ones, e.g. "0-23=0.5;weekday:8-18=0.1"
+func ParseSchedule(v string) (Schedule, error) {
+	schedule := Schedule{}
+	for _, entry := range strings.Split(v, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		days := []bool{true, false}
+		if prefix, rest, ok := strings.Cut(entry, ":"); ok {
+			switch strings.TrimSpace(prefix) {
+			case weekdayPrefix:
+				days = []bool{true}
+			case weekendPrefix:
+				days = []bool{false}
+			default:
+				return nil, fmt.Errorf("unknown days %q in overcommit schedule entry %q", prefix, entry)
+			}
+			entry = rest
+		}
+
+		hours, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("expect <hours>=<ratio> in overcommit schedule entry %q", entry)
+		}
+
+		start, end, err := parseHours(strings.TrimSpace(hours))
+		if err != nil {
+			return nil, err
+		}
+
+		ratio, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ratio of overcommit schedule entry %q: %v", entry, err)
+		}
+		if ratio < 0.0 {
+			return nil, fmt.Errorf("invalid ratio of overcommit schedule entry %q: got %v", entry, ratio)
+		}
+
+		for _, isWeekday := range days {
+			for h := start; h <= end; h++ {
+				schedule[HourOfWeek{IsWeekday: isWeekday, Hour: h}] = ratio
+			}
+		}
+	}
+
+	return schedule, nil
+}
+
+func parseHours(hours string) (int16, int16, error) {
+	first, last, isRange := strings.Cut(hours, "-")
+	start, err := parseHour(first)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !isRange {
+		return start, start, nil
+	}
+
+	end, err := parseHour(last)
+	if err != nil {
+		return 0, 0, err
+	}
+	if end < start {
+		return 0, 0, fmt.Errorf("invalid overcommit schedule hours %q: the range does not wrap around midnight", hours)
+	}
+	return start, end, nil
+}
+
+func parseHour(v string) (int16, error) {
+	hour, err := strconv.ParseInt(strings.TrimSpace(v), 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse overcommit schedule hour %q: %v", v, err)
+	}
+	if hour < 0 || hour >= hoursInADay {
+		return 0, fmt.Errorf("invalid overcommit schedule hour %q: must be between 0 and 23", v)
+	}
+	return int16(hour), nil
+}
@@ -0,0 +1,322 @@
+package temporalutilization
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	policylisters "k8s.io/client-go/listers/policy/v1"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/klog/v2"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	v1qos "k8s.io/kubernetes/pkg/apis/core/v1/helper/qos"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/preemption"
+	"k8s.io/kubernetes/pkg/scheduler/util"
+)
+
+const (
+	// minCandidateNodesPercentage and minCandidateNodesAbsolute are the number of nodes dry running the preemption,
+	// the same as the defaults of the default preemption
+	minCandidateNodesPercentage = 10
+	minCandidateNodesAbsolute   = 100
+)
+
+var _ framework.PostFilterPlugin = &TemporalUtilization{}
+var _ preemption.Interface = &TemporalUtilization{}
+
+// temporalPreemption is the listers used by the preemption, they are created with the plugin
+// so that their informers are started with the scheduler
+type temporalPreemption struct {
+	podLister corelisters.PodLister
+	pdbLister policylisters.PodDisruptionBudgetLister
+}
+
+func newTemporalPreemption(handle framework.Handle) *temporalPreemption {
+	return &temporalPreemption{
+		podLister: handle.SharedInformerFactory().Core().V1().Pods().Lister(),
+		pdbLister: handle.SharedInformerFactory().Policy().V1().PodDisruptionBudgets().Lister(),
+	}
+}
+
+// PostFilter preempts the lower priority or BestEffort pods contributing the most to the forecast peaks
+// of a node the pod does not fit by temporal usages, and nominates the node
+func (pl *TemporalUtilization) PostFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, m framework.NodeToStatusMap) (*framework.PostFilterResult, *framework.Status) {
+	if pl.preemption == nil {
+		return nil, framework.NewStatus(framework.Unschedulable, "temporal preemption is disabled")
+	}
+
+	pe := preemption.Evaluator{
+		PluginName: Name,
+		Handler:    pl.handle,
+		PodLister:  pl.preemption.podLister,
+		PdbLister:  pl.preemption.pdbLister,
+		State:      state,
+		Interface:  pl,
+	}
+
+	result, status := pe.Preempt(ctx, pod, m)
+	if status.Message() != "" {
+		return result, framework.NewStatus(status.Code(), "temporal preemption: "+status.Message())
+	}
+	return result, status
+}
+
+// GetOffsetAndNumCandidates chooses a random offset and the number of nodes dry running the preemption
+func (pl *TemporalUtilization) GetOffsetAndNumCandidates(numNodes int32) (int32, int32) {
+	n := numNodes * minCandidateNodesPercentage / 100
+	if n < minCandidateNodesAbsolute {
+		n = minCandidateNodesAbsolute
+	}
+	if n > numNodes {
+		n = numNodes
+	}
+	return rand.Int31n(numNodes), n
+}
+
+// CandidatesToVictimsMap builds the victims by node of the candidates
+func (pl *TemporalUtilization) CandidatesToVictimsMap(candidates []preemption.Candidate) map[string]*extenderv1.Victims {
+	m := make(map[string]*extenderv1.Victims, len(candidates))
+	for _, c := range candidates {
+		m[c.Name()] = c.Victims()
+	}
+	return m
+}
+
+// PodEligibleToPreemptOthers returns whether the pod can preempt others, it cannot with a Never preemption policy
+// or while victims it preempted are terminating on its nominated node
+func (pl *TemporalUtilization) PodEligibleToPreemptOthers(pod *v1.Pod, nominatedNodeStatus *framework.Status) (bool, string) {
+	if pod.Spec.PreemptionPolicy != nil && *pod.Spec.PreemptionPolicy == v1.PreemptNever {
+		return false, "not eligible due to preemptionPolicy=Never."
+	}
+
+	nomNodeName := pod.Status.NominatedNodeName
+	if len(nomNodeName) == 0 || nominatedNodeStatus.Code() == framework.UnschedulableAndUnresolvable {
+		return true, ""
+	}
+
+	if nodeInfo, _ := pl.handle.SnapshotSharedLister().NodeInfos().Get(nomNodeName); nodeInfo != nil {
+		for _, p := range nodeInfo.Pods {
+			if p.Pod.DeletionTimestamp != nil && isPreemptible(pod, p.Pod) {
+				return false, "not eligible due to a terminating pod on the nominated node."
+			}
+		}
+	}
+	return true, ""
+}
+
+// isPreemptible returns whether the pod can be a victim of the preemptor, i.e. it has a lower priority,
+// or it is a BestEffort pod of the same priority and the preemptor is not
+func isPreemptible(preemptor, pod *v1.Pod) bool {
+	podPriority, preemptorPriority := corev1helpers.PodPriority(pod), corev1helpers.PodPriority(preemptor)
+	if podPriority != preemptorPriority {
+		return podPriority < preemptorPriority
+	}
+	return v1qos.GetPodQOS(pod) == v1.PodQOSBestEffort && v1qos.GetPodQOS(preemptor) != v1.PodQOSBestEffort
+}
+
+// SelectVictimsOnNode finds the pods on the node to preempt for the pod to fit. All the preemptible pods are removed,
+// then reprieved from the least to the most contributing to the hours the pod does not fit, so the victims are
+// the pods contributing the most to the forecast peaks
+func (pl *TemporalUtilization) SelectVictimsOnNode(
+	ctx context.Context,
+	state *framework.CycleState,
+	pod *v1.Pod,
+	nodeInfo *framework.NodeInfo,
+	pdbs []*policy.PodDisruptionBudget) ([]*v1.Pod, int, *framework.Status) {
+	removePod := func(rpi *framework.PodInfo) error {
+		if err := nodeInfo.RemovePod(rpi.Pod); err != nil {
+			return err
+		}
+		return pl.handle.RunPreFilterExtensionRemovePod(ctx, state, pod, rpi, nodeInfo).AsError()
+	}
+	addPod := func(api *framework.PodInfo) error {
+		nodeInfo.AddPodInfo(api)
+		return pl.handle.RunPreFilterExtensionAddPod(ctx, state, pod, api, nodeInfo).AsError()
+	}
+
+	var potentialVictims []*framework.PodInfo
+	for _, pi := range nodeInfo.Pods {
+		if isPreemptible(pod, pi.Pod) {
+			potentialVictims = append(potentialVictims, pi)
+		}
+	}
+	if len(potentialVictims) == 0 {
+		return nil, 0, framework.NewStatus(framework.UnschedulableAndUnresolvable, "No preemption victims found for incoming pod")
+	}
+
+	// the contributions to the peaks are computed before any pod is removed
+	contributions, err := pl.peakContributions(state, pod, nodeInfo, potentialVictims)
+	if err != nil {
+		return nil, 0, framework.AsStatus(err)
+	}
+
+	for _, pi := range potentialVictims {
+		if err := removePod(pi); err != nil {
+			return nil, 0, framework.AsStatus(err)
+		}
+	}
+
+	// the pod does not fit even without all the preemptible pods, the node is not suitable
+	if status := pl.handle.RunFilterPluginsWithNominatedPods(ctx, state, pod, nodeInfo); !status.IsSuccess() {
+		return nil, 0, status
+	}
+
+	sort.SliceStable(potentialVictims, func(i, j int) bool {
+		ci, cj := contributions[potentialVictims[i].Pod.UID], contributions[potentialVictims[j].Pod.UID]
+		if ci != cj {
+			return ci < cj
+		}
+		return util.MoreImportantPod(potentialVictims[i].Pod, potentialVictims[j].Pod)
+	})
+
+	var victims []*v1.Pod
+	numViolatingVictim := 0
+	reprievePod := func(pi *framework.PodInfo) (bool, error) {
+		if err := addPod(pi); err != nil {
+			return false, err
+		}
+		fits := pl.handle.RunFilterPluginsWithNominatedPods(ctx, state, pod, nodeInfo).IsSuccess()
+		if !fits {
+			if err := removePod(pi); err != nil {
+				return false, err
+			}
+			victims = append(victims, pi.Pod)
+			klog.V(5).InfoS("Pod is a potential temporal preemption victim on node", "pod", klog.KObj(pi.Pod),
+				"node", klog.KObj(nodeInfo.Node()), "contribution", contributions[pi.Pod.UID])
+		}
+		return fits, nil
+	}
+
+	// the PDB violating victims are reprieved first, then the others
+	violatingVictims, nonViolatingVictims := filterPodsWithPDBViolation(potentialVictims, pdbs)
+	for _, pi := range violatingVictims {
+		fits, err := reprievePod(pi)
+		if err != nil {
+			return nil, 0, framework.AsStatus(err)
+		}
+		if !fits {
+			numViolatingVictim++
+		}
+	}
+	for _, pi := range nonViolatingVictims {
+		if _, err := reprievePod(pi); err != nil {
+			return nil, 0, framework.AsStatus(err)
+		}
+	}
+
+	return victims, numViolatingVictim, framework.NewStatus(framework.Success)
+}
+
+// peakContributions returns by pod uid how much the pods contribute to the hours the forecasts of the node exceed
+// its capacity with the pod, as the sum of their usages at these hours relative to the capacity
+func (pl *TemporalUtilization) peakContributions(state *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo, pods []*framework.PodInfo) (map[types.UID]float64, error) {
+	podUsages, err := pl.getPodUsagesState(state, pod)
+	if err != nil {
+		return nil, fmt.Errorf("unable to obtain usage template for pod: %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+
+	nodeName := nodeInfo.Node().Name
+	forecasts, _, err := obtainForecasts(pl.utMgr, nodeInfo, nodeName, podUsages.usages, pl.SupportedTargetResources())
+	if err != nil {
+		return nil, err
+	}
+	adjustments, err := getNodeAdjustmentsState(state)
+	if err != nil {
+		return nil, err
+	}
+	if adjustments != nil {
+		adjustments.apply(forecasts, nodeName)
+	}
+
+	// the hours exceeding the capacity by resource
+	peaks := make(map[string][]hourSlot, len(forecasts))
+	capacities := make(map[string]int64, len(forecasts))
+	for resource, forecast := range forecasts {
+		_, capacity, ok := temporalCapacity(nodeInfo, resource)
+		if !ok || capacity <= 0 || forecast == nil {
+			continue
+		}
+		capacities[resource] = capacity
+		for _, slot := range pl.Horizon.filterSlots(time.Now(), podUsages.lifetimeHours) {
+			if value, ok := forecast.valueAt(slot); ok && int64(math.Round(float64(value))) > capacity {
+				peaks[resource] = append(peaks[resource], slot)
+			}
+		}
+	}
+
+	contributions := make(map[types.UID]float64, len(pods))
+	for _, pi := range pods {
+		usages, err := getPodUsages(pl.utMgr, pi.Pod, pl.SupportedTargetResources())
+		if err != nil {
+			return nil, fmt.Errorf("unable to obtain usage template for pod: %s/%s: %v", pi.Pod.Namespace, pi.Pod.Name, err)
+		}
+
+		for resource, slots := range peaks {
+			usage, ok := usages[resource]
+			if !ok || usage == nil {
+				continue
+			}
+			for _, slot := range slots {
+				if value, ok := usage.valueAt(slot); ok {
+					contributions[pi.Pod.UID] += float64(value) / float64(capacities[resource])
+				}
+			}
+		}
+	}
+
+	return contributions, nil
+}
+
+// filterPodsWithPDBViolation groups the pods by whether their PDBs are violated if they are preempted,
+// keeping their order, as the default preemption does
+func filterPodsWithPDBViolation(podInfos []*framework.PodInfo, pdbs []*policy.PodDisruptionBudget) (violatingPodInfos, nonViolatingPodInfos []*framework.PodInfo) {
+	pdbsAllowed := make([]int32, len(pdbs))
+	for i, pdb := range pdbs {
+		pdbsAllowed[i] = pdb.Status.DisruptionsAllowed
+	}
+
+	for _, podInfo := range podInfos {
+		pod := podInfo.Pod
+		pdbForPodIsViolated := false
+		// a pod with no labels does not match any PDB
+		if len(pod.Labels) != 0 {
+			for i, pdb := range pdbs {
+				if pdb.Namespace != pod.Namespace {
+					continue
+				}
+				selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+				if err != nil {
+					continue
+				}
+				// a PDB with a nil or empty selector matches nothing
+				if selector.Empty() || !selector.Matches(labels.Set(pod.Labels)) {
+					continue
+				}
+				// the pods already disrupted are processed by the API server
+				if _, exist := pdb.Status.DisruptedPods[pod.Name]; exist {
+					continue
+				}
+				pdbsAllowed[i]--
+				if pdbsAllowed[i] < 0 {
+					pdbForPodIsViolated = true
+				}
+			}
+		}
+		if pdbForPodIsViolated {
+			violatingPodInfos = append(violatingPodInfos, podInfo)
+		} else {
+			nonViolatingPodInfos = append(nonViolatingPodInfos, podInfo)
+		}
+	}
+	return violatingPodInfos, nonViolatingPodInfos
+}
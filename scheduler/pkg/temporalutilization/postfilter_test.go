@@ -0,0 +1,121 @@
+package temporalutilization
+
+import (
+	"context"
+	"testing"
+
+	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	testutils "gitee.com/openeuler/paws/scheduler/pkg/test/util"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+)
+
+// testPodNominator is a pod nominator without nominated pods
+type testPodNominator struct{}
+
+func (testPodNominator) AddNominatedPod(*framework.PodInfo, *framework.NominatingInfo) {}
+func (testPodNominator) DeleteNominatedPodIfExists(*v1.Pod)                            {}
+func (testPodNominator) UpdateNominatedPod(*v1.Pod, *framework.PodInfo)                {}
+func (testPodNominator) NominatedPodsForNode(string) []*framework.PodInfo              { return nil }
+
+func TestIsPreemptible(t *testing.T) {
+	guaranteed := st.MakePod().Name("online").Priority(100).Req(map[v1.ResourceName]string{v1.ResourceCPU: "100m"}).Obj()
+	bestEffort := st.MakePod().Name("batch").Priority(100).Obj()
+
+	assert.True(t, isPreemptible(guaranteed, st.MakePod().Priority(10).Req(map[v1.ResourceName]string{v1.ResourceCPU: "100m"}).Obj()))
+	assert.True(t, isPreemptible(guaranteed, st.MakePod().Priority(100).Obj()))
+	assert.False(t, isPreemptible(guaranteed, st.MakePod().Priority(200).Obj()))
+	assert.False(t, isPreemptible(guaranteed, st.MakePod().Priority(100).Req(map[v1.ResourceName]string{v1.ResourceCPU: "100m"}).Obj()))
+	assert.False(t, isPreemptible(bestEffort, st.MakePod().Priority(100).Obj()))
+	assert.True(t, isPreemptible(bestEffort, st.MakePod().Priority(10).Obj()))
+}
+
+func TestTemporalUtilizationSelectVictimsOnNode(t *testing.T) {
+	node := st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{
+		v1.ResourceCPU: "1000m",
+	}).Obj()
+	onlineLabels := map[string]string{v1alpha1.UsageTemplateLabelIdentifier: "online"}
+	heavyLabels := map[string]string{v1alpha1.UsageTemplateLabelIdentifier: "batch-heavy"}
+	lightLabels := map[string]string{v1alpha1.UsageTemplateLabelIdentifier: "batch-light"}
+	usageTemplate := func(name string, usage float32) *v1alpha1.UsageTemplate {
+		return testutils.MakeUsageTemplate(name, "default", true, "BestEffort",
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(usage)},
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(usage)}, true)
+	}
+
+	pod := st.MakePod().Namespace("default").Name("online-2").Labels(onlineLabels).
+		Req(map[v1.ResourceName]string{v1.ResourceCPU: "100m"}).Obj()
+	online := st.MakePod().Namespace("default").Name("online-1").UID("online-1").Node("node-1").Labels(onlineLabels).
+		Req(map[v1.ResourceName]string{v1.ResourceCPU: "100m"}).Obj()
+	heavy := st.MakePod().Namespace("default").Name("batch-heavy-1").UID("batch-heavy-1").Node("node-1").Labels(heavyLabels).Obj()
+	light1 := st.MakePod().Namespace("default").Name("batch-light-1").UID("batch-light-1").Node("node-1").Labels(lightLabels).Obj()
+	light2 := st.MakePod().Namespace("default").Name("batch-light-2").UID("batch-light-2").Node("node-1").Labels(lightLabels).Obj()
+	existing := []*v1.Pod{online, heavy, light1, light2}
+
+	mgr := newTestUsageEvaluationManager([]*v1.Node{node}, pod, existing, []*v1alpha1.UsageTemplate{
+		usageTemplate("online", 300),
+		usageTemplate("batch-heavy", 400),
+		usageTemplate("batch-light", 100),
+	})
+	for _, p := range existing {
+		mgr.OnAdd(p)
+	}
+
+	pl := &TemporalUtilization{
+		FilterByTemporalUsages: true,
+		utMgr:                  mgr,
+	}
+	registry := frameworkruntime.Registry{
+		Name: func(_ runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+			pl.handle = handle
+			return pl, nil
+		},
+		queuesort.Name:     queuesort.New,
+		defaultbinder.Name: defaultbinder.New,
+	}
+	profile := &config.KubeSchedulerProfile{
+		SchedulerName: "paws-temporal",
+		Plugins: &config.Plugins{
+			QueueSort: config.PluginSet{Enabled: []config.Plugin{{Name: queuesort.Name}}},
+			Bind:      config.PluginSet{Enabled: []config.Plugin{{Name: defaultbinder.Name}}},
+			PreFilter: config.PluginSet{Enabled: []config.Plugin{{Name: Name}}},
+			Filter:    config.PluginSet{Enabled: []config.Plugin{{Name: Name}}},
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := frameworkruntime.NewFramework(registry, profile, ctx.Done(), frameworkruntime.WithPodNominator(testPodNominator{}))
+	assert.Nil(t, err)
+
+	state := framework.NewCycleState()
+	_, status := pl.preFilter(ctx, state, pod)
+	assert.Nil(t, status)
+
+	nodeInfo := framework.NewNodeInfo(existing...)
+	nodeInfo.SetNode(node)
+
+	// 300 + 300 + 400 + 2 * 100 does not fit, preempting either the heavy pod or both light ones makes room
+	assert.Equal(t, framework.Unschedulable, pl.Filter(ctx, state, pod, nodeInfo).Code())
+
+	// the BestEffort pod contributing the most to the peak is preempted rather than the two light ones,
+	// the pod of the same priority and QoS is not
+	victims, numViolatingVictim, status := pl.SelectVictimsOnNode(ctx, state.Clone(), pod, nodeInfo.Clone(), nil)
+	assert.True(t, status.IsSuccess())
+	assert.Equal(t, 0, numViolatingVictim)
+	assert.Equal(t, []*v1.Pod{heavy}, victims)
+
+	// a BestEffort pod cannot preempt the BestEffort pods of the same priority
+	batch := st.MakePod().Namespace("default").Name("batch-light-3").Labels(lightLabels).Obj()
+	batchState := framework.NewCycleState()
+	_, status = pl.preFilter(ctx, batchState, batch)
+	assert.Nil(t, status)
+	_, _, status = pl.SelectVictimsOnNode(ctx, batchState, batch, nodeInfo.Clone(), nil)
+	assert.Equal(t, framework.UnschedulableAndUnresolvable, status.Code())
+}
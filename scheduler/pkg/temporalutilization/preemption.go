@@ -0,0 +1,158 @@
+package temporalutilization
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// nodeAdjustmentsStateKey is the key in CycleState to the pods added to or removed from the nodes by the framework
+const nodeAdjustmentsStateKey = "PreFilterExtensions" + Name
+
+var _ framework.PreFilterExtensions = &TemporalUtilization{}
+
+// nodeAdjustmentsState is the usages of the pods the framework added to or removed from the nodes during the cycle,
+// e.g. the nominated pods and the victims of a preemption, which the node forecasts do not know about.
+// They are applied to the forecasts of the node when filtering by the temporal usages
+type nodeAdjustmentsState struct {
+	added   map[string]map[NamespacedPod]map[string]*UsageTemplate
+	removed map[string]map[NamespacedPod]map[string]*UsageTemplate
+}
+
+func newNodeAdjustmentsState() *nodeAdjustmentsState {
+	return &nodeAdjustmentsState{
+		added:   make(map[string]map[NamespacedPod]map[string]*UsageTemplate),
+		removed: make(map[string]map[NamespacedPod]map[string]*UsageTemplate),
+	}
+}
+
+// Clone the node adjustments state, the preemption simulates the victims of every node on its own clone.
+// The usages of a pod are never modified, only the maps are copied
+func (s *nodeAdjustmentsState) Clone() framework.StateData {
+	clone := newNodeAdjustmentsState()
+	for nodeName, pods := range s.added {
+		clone.added[nodeName] = make(map[NamespacedPod]map[string]*UsageTemplate, len(pods))
+		for pod, usages := range pods {
+			clone.added[nodeName][pod] = usages
+		}
+	}
+	for nodeName, pods := range s.removed {
+		clone.removed[nodeName] = make(map[NamespacedPod]map[string]*UsageTemplate, len(pods))
+		for pod, usages := range pods {
+			clone.removed[nodeName][pod] = usages
+		}
+	}
+	return clone
+}
+
+// move records the pod in the to adjustments of the node, unless it is in the from adjustments,
+// e.g. a victim removed then added back is no longer an adjustment
+func move(from, to map[string]map[NamespacedPod]map[string]*UsageTemplate, nodeName string, pod NamespacedPod, usages map[string]*UsageTemplate) {
+	if _, ok := from[nodeName][pod]; ok {
+		delete(from[nodeName], pod)
+		return
+	}
+
+	if _, ok := to[nodeName]; !ok {
+		to[nodeName] = make(map[NamespacedPod]map[string]*UsageTemplate)
+	}
+	to[nodeName][pod] = usages
+}
+
+// apply adds the usages of the pods added to the node to the forecasts and subtracts the ones of the pods removed from it
+func (s *nodeAdjustmentsState) apply(forecasts map[string]*UsageTemplate, nodeName string) {
+	for _, usages := range s.added[nodeName] {
+		addUsages(forecasts, usages)
+	}
+	for _, usages := range s.removed[nodeName] {
+		subtractUsages(forecasts, usages)
+	}
+}
+
+// subtractUsages subtracts the usages from the results by hour, the results do not go below zero
+func subtractUsages(results map[string]*UsageTemplate, usages map[string]*UsageTemplate) {
+	for resource, usageTemplate := range usages {
+		result, ok := results[resource]
+		if usageTemplate == nil || !ok {
+			continue
+		}
+
+		for hour, value := range usageTemplate.weekDayHour {
+			if v, ok := result.weekDayHour[hour]; ok {
+				result.weekDayHour[hour] = max32(v-value, 0)
+			}
+		}
+
+		for hour, value := range usageTemplate.weekendHour {
+			if v, ok := result.weekendHour[hour]; ok {
+				result.weekendHour[hour] = max32(v-value, 0)
+			}
+		}
+	}
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// getNodeAdjustmentsState reads the node adjustments state, nil when the framework did not add or remove any pod
+func getNodeAdjustmentsState(cycleState *framework.CycleState) (*nodeAdjustmentsState, error) {
+	c, err := cycleState.Read(nodeAdjustmentsStateKey)
+	if err != nil {
+		return nil, nil
+	}
+
+	s, ok := c.(*nodeAdjustmentsState)
+	if !ok {
+		return nil, fmt.Errorf("%+v  convert to TemporalUtilization.nodeAdjustmentsState error", c)
+	}
+	return s, nil
+}
+
+// adjustNode records the usages of the pod added to or removed from the node in the cycle state
+func (pl *TemporalUtilization) adjustNode(cycleState *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo, added bool) *framework.Status {
+	if nodeInfo.Node() == nil {
+		return framework.NewStatus(framework.Error, "node not found")
+	}
+
+	s, err := getNodeAdjustmentsState(cycleState)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	if s == nil {
+		s = newNodeAdjustmentsState()
+		cycleState.Write(nodeAdjustmentsStateKey, s)
+	}
+
+	usages, err := getPodUsages(pl.utMgr, pod, pl.SupportedTargetResources())
+	if err != nil {
+		return framework.NewStatus(framework.Error, fmt.Sprintf("unable to obtain usage template for pod: %s/%s", pod.Namespace, pod.Name))
+	}
+
+	nodeName := nodeInfo.Node().Name
+	namespacedPod := NamespacedPod{Namespace: pod.Namespace, Name: pod.Name}
+	if added {
+		move(s.removed, s.added, nodeName, namespacedPod, usages)
+	} else {
+		move(s.added, s.removed, nodeName, namespacedPod, usages)
+	}
+
+	klog.V(5).InfoS("Adjusted the node forecast", "pod", klog.KObj(pod), "node", nodeName, "added", added)
+	return nil
+}
+
+// AddPod adds the usages of the pod to the forecasts of the node when filtering, e.g. a nominated pod
+func (pl *TemporalUtilization) AddPod(ctx context.Context, cycleState *framework.CycleState, podToSchedule *v1.Pod, podInfoToAdd *framework.PodInfo, nodeInfo *framework.NodeInfo) *framework.Status {
+	return pl.adjustNode(cycleState, podInfoToAdd.Pod, nodeInfo, true)
+}
+
+// RemovePod subtracts the usages of the pod from the forecasts of the node when filtering, e.g. a victim of a preemption
+func (pl *TemporalUtilization) RemovePod(ctx context.Context, cycleState *framework.CycleState, podToSchedule *v1.Pod, podInfoToRemove *framework.PodInfo, nodeInfo *framework.NodeInfo) *framework.Status {
+	return pl.adjustNode(cycleState, podInfoToRemove.Pod, nodeInfo, false)
+}
@@ -0,0 +1,97 @@
+package temporalutilization
+
+import (
+	"context"
+	"testing"
+
+	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
+	testutils "gitee.com/openeuler/paws/scheduler/pkg/test/util"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+)
+
+func TestTemporalUtilizationFilterWithPreemptionVictims(t *testing.T) {
+	node := st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{
+		v1.ResourceCPU: "1000m",
+	}).Obj()
+	labels := map[string]string{v1alpha1.UsageTemplateLabelIdentifier: "test-crd-1"}
+	pod := st.MakePod().Namespace("default").Name("pod-1").Labels(labels).Obj()
+	victim := st.MakePod().Namespace("default").Name("victim-1").UID("victim-1").Node("node-1").Labels(labels).Obj()
+	other := st.MakePod().Namespace("default").Name("other-1").UID("other-1").Node("node-1").Labels(labels).Obj()
+	usageTemplates := []*v1alpha1.UsageTemplate{
+		testutils.MakeUsageTemplate("test-crd-1", "default", true, "BestEffort",
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(400)},
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(400)}, true),
+	}
+
+	mgr := newTestUsageEvaluationManager([]*v1.Node{node}, pod, []*v1.Pod{victim, other}, usageTemplates)
+	mgr.OnAdd(victim)
+	mgr.OnAdd(other)
+
+	pl := &TemporalUtilization{
+		FilterByTemporalUsages: true,
+		utMgr:                  mgr,
+	}
+	assert.NotNil(t, pl.PreFilterExtensions())
+
+	ctx := context.Background()
+	state := framework.NewCycleState()
+	_, status := pl.preFilter(ctx, state, pod)
+	assert.Nil(t, status)
+
+	nodeInfo := framework.NewNodeInfo(victim, other)
+	nodeInfo.SetNode(node)
+
+	// 3 * 400m does not fit
+	status = pl.Filter(ctx, state, pod, nodeInfo)
+	assert.Equal(t, framework.Unschedulable, status.Code())
+
+	// the preemption removes the victim from a clone of the cycle state
+	victimState := state.Clone()
+	victimInfo := nodeInfo.Clone()
+	victimPodInfo, _ := framework.NewPodInfo(victim)
+	assert.Nil(t, victimInfo.RemovePod(victim))
+	assert.True(t, pl.RemovePod(ctx, victimState, pod, victimPodInfo, victimInfo).IsSuccess())
+	assert.True(t, pl.Filter(ctx, victimState, pod, victimInfo).IsSuccess())
+
+	// the original cycle state is unchanged
+	assert.Equal(t, framework.Unschedulable, pl.Filter(ctx, state, pod, nodeInfo).Code())
+
+	// the victim added back, e.g. reprieved, does not fit again
+	victimInfo.AddPodInfo(victimPodInfo)
+	assert.True(t, pl.AddPod(ctx, victimState, pod, victimPodInfo, victimInfo).IsSuccess())
+	assert.Equal(t, framework.Unschedulable, pl.Filter(ctx, victimState, pod, victimInfo).Code())
+
+	// a nominated pod added to the node is counted too
+	nominated := st.MakePod().Namespace("default").Name("nominated-1").UID("nominated-1").Labels(labels).Obj()
+	nominatedInfo, _ := framework.NewPodInfo(nominated)
+	nominatedState := victimState.Clone()
+	assert.True(t, pl.RemovePod(ctx, nominatedState, pod, victimPodInfo, victimInfo).IsSuccess())
+	assert.True(t, pl.Filter(ctx, nominatedState, pod, victimInfo).IsSuccess())
+	assert.True(t, pl.AddPod(ctx, nominatedState, pod, nominatedInfo, victimInfo).IsSuccess())
+	assert.Equal(t, framework.Unschedulable, pl.Filter(ctx, nominatedState, pod, victimInfo).Code())
+}
+
+func TestSubtractUsages(t *testing.T) {
+	forecasts := map[string]*UsageTemplate{
+		v1.ResourceCPU.String(): {
+			weekDayHour: map[int16]float32{1: 500, 2: 100},
+			weekendHour: map[int16]float32{1: 500},
+		},
+	}
+	subtractUsages(forecasts, map[string]*UsageTemplate{
+		v1.ResourceCPU.String(): {
+			weekDayHour: map[int16]float32{1: 200, 2: 300, 3: 100},
+			weekendHour: map[int16]float32{},
+		},
+		v1.ResourceMemory.String(): {
+			weekDayHour: map[int16]float32{1: 200},
+		},
+	})
+
+	assert.Equal(t, map[int16]float32{1: 300, 2: 0}, forecasts[v1.ResourceCPU.String()].weekDayHour)
+	assert.Equal(t, map[int16]float32{1: 500}, forecasts[v1.ResourceCPU.String()].weekendHour)
+	assert.NotContains(t, forecasts, v1.ResourceMemory.String())
+}
@@ -31,37 +31,41 @@ func calculateHourScore(usagePercent float64, thresholdPercent float64, isHardCo
 			(float64(framework.MaxNodeScore) - thresholdPercent)
 	}
 	// 如果小于阈值，按比例计算得分
-	return usagePercent/thresholdPercent*float64(framework.MaxNodeScore-thresholdPercent) + thresholdPercent
+	return usagePercent/thresholdPercent*(float64(framework.MaxNodeScore)-thresholdPercent) + thresholdPercent
 }
 
-// scoreOverHours 计算给定时间段内的节点得分
-func scoreOverHours(hoursValue map[int16]float32, nodeCapacityMilli int64, hotspotThresholdPercent int64, isHardConstraint bool) int64 {
+// scoreOverHours 计算给定时间段内的节点得分, each hour is weighed by the slot weight
+func scoreOverHours(forecast *UsageTemplate, slots []hourSlot, nodeCapacityMilli int64, hotspotThresholdPercent int64, isHardConstraint bool) int64 {
 	total := 0.0
-	for _, millivalue := range hoursValue {
+	for _, slot := range slots {
+		millivalue, ok := forecast.valueAt(slot)
+		if !ok {
+			continue
+		}
 		usagePercent := float64(millivalue) / float64(nodeCapacityMilli) * 100.0
 		hourScore := calculateHourScore(usagePercent, float64(hotspotThresholdPercent), isHardConstraint)
-		total += math.Round(hourScore)
+		total += math.Round(hourScore) * slot.weight
 	}
 	return int64(math.Round(total))
 }
 
-// getTrimaranScore 计算周内与周末的平均得分
-func getTrimaranScore(nodeCapacityMilli int64, forecast *UsageTemplate, hotspotThresholdPercent int64, isHardConstraint bool) int64 {
-	weekdayScore := scoreOverHours(forecast.weekDayHour, nodeCapacityMilli, hotspotThresholdPercent, isHardConstraint)
-	weekendScore := scoreOverHours(forecast.weekendHour, nodeCapacityMilli, hotspotThresholdPercent, isHardConstraint)
-	return int64(math.Round(float64(weekdayScore + weekendScore)))
+// getTrimaranScore 计算周内与周末在给定时间段内的得分
+func getTrimaranScore(nodeCapacityMilli int64, forecast *UsageTemplate, slots []hourSlot, hotspotThresholdPercent int64, isHardConstraint bool) int64 {
+	return scoreOverHours(forecast, slots, nodeCapacityMilli, hotspotThresholdPercent, isHardConstraint)
 }
 
 // scorer 是打分函数，根据节点的资源使用情况和预测返回最终分数
-func scorer(nodeInfo *framework.NodeInfo, forecasts map[string]*UsageTemplate, hotspotThreshold int32, isHardConstraint bool) (int64, *framework.Status) {
-	nodeCPUMilli := nodeInfo.Node().Status.Capacity.Cpu().MilliValue()
+// the hotspot threshold is the one of the node pool policy of the node,
+// the node capacity is scaled by the node performance factor as the forecasts are in reference cores
+func scorer(nodeInfo *framework.NodeInfo, forecasts map[string]*UsageTemplate, slots []hourSlot, policy HotSpotPolicy) (int64, *framework.Status) {
+	nodeCPUMilli := scaleByPerformanceFactor(nodeInfo.Node(), v1.ResourceCPU.String(), nodeInfo.Node().Status.Capacity.Cpu().MilliValue())
 	totalScore := int64(0)
 	resourceCount := 0
 
 	// 目前仅支持CPU
 	for resource, template := range forecasts {
 		if resource == v1.ResourceCPU.String() {
-			perResourceScore := getTrimaranScore(nodeCPUMilli, template, int64(hotspotThreshold), isHardConstraint)
+			perResourceScore := getTrimaranScore(nodeCPUMilli, template, slots, int64(policy.HotSpotThreshold), policy.HardThreshold)
 			totalScore += perResourceScore
 			resourceCount++
 		} else {
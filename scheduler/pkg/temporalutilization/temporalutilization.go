@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sync"
+	"time"
 
 	pluginConfig "gitee.com/openeuler/paws/scheduler/apis/config"
 	schedv1alpha1 "gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
@@ -30,15 +32,20 @@ const (
 	CPUResource       = v1.ResourceCPU
 
 	// DefaultHotSpotThreshold sets the hotspot threshold
-	// Use the whole machine as default
-	// TODO: Per machine type hotspot
+	// Use the whole machine as default, it can be overridden per node pool
 	DefaultHotSpotThreshold = 100
 
 	NumHoursInADay = 24
 
+	// DefaultNodePodsCacheResyncPeriod is how often the node pods cache is reconciled with the pod informer
+	DefaultNodePodsCacheResyncPeriod = 5 * time.Minute
+
 	// preFilterStateKey is the key in CycleState to NodeResourcesFit pre-computed data.
 	// Using the name of the plugin will likely help us avoid collisions with other plugins.
 	preFilterStateKey = "PreFilter" + Name
+
+	// podUsagesStateKey is the key in CycleState to the temporal usages of the pod being scheduled
+	podUsagesStateKey = "PreScore" + Name
 )
 
 var DefaultResourceUnitMap = map[string]string{
@@ -56,20 +63,129 @@ func (s *preFilterState) Clone() framework.StateData {
 	return s
 }
 
+// podUsagesState is the temporal usages of the pod being scheduled, resolved from the pod object
+// once per cycle at PreFilter or PreScore and used at Filter and Score.
+type podUsagesState struct {
+	usages map[string]*UsageTemplate
+	// usable is whether the pod has a usable usage template for all the target resources
+	usable bool
+	// lifetimeHours is the expected lifetime of the pod, 0 when not inferred
+	lifetimeHours int
+	// memoryUsages is the memory usages of the pod when the memory forecasts are maintained
+	memoryUsages map[string]*UsageTemplate
+	// correlations is the correlation of the CPU usages of the pod and of the nodes scored, by node name,
+	// when the anti-correlation is enabled
+	correlations sync.Map
+}
+
+// Clone the pod usages state.
+func (s *podUsagesState) Clone() framework.StateData {
+	return s
+}
+
 type TemporalUtilization struct {
 	FitPlugin *noderesources.Fit
 
 	HotSpotThreshold       int32
 	HardThreshold          bool
+	NodePoolPolicies       []NodePoolPolicy
 	EnableOvercommit       bool
+	MemoryOOMMargin        float64
 	FilterByTemporalUsages bool
+	Horizon                Horizon
 	utMgr                  *UsageTemplateManager
+	// live is the live node utilization blended in scoring, nil when disabled
+	live *liveUtilization
+	// AntiCorrelationWeight is the weight (0-1) of the anti-correlation of the pod and node usages blended in scoring
+	AntiCorrelationWeight float64
+	// preemption is the listers of the temporal preemption, nil when disabled
+	preemption *temporalPreemption
+	// deferrals is the deferrable pods waiting at Permit for a valley of their node
+	deferrals *podDeferrals
+	handle    framework.Handle
 }
 
 var _ framework.PreFilterPlugin = &TemporalUtilization{}
 var _ framework.FilterPlugin = &TemporalUtilization{}
+var _ framework.PreScorePlugin = &TemporalUtilization{}
 var _ framework.ScorePlugin = &TemporalUtilization{}
 var _ framework.ReservePlugin = &TemporalUtilization{}
+var _ framework.EnqueueExtensions = &TemporalUtilization{}
+
+// usageTemplatesGVK is the usage templates in the <kind in plural>.<version>.<group> form of the events of the custom resources
+var usageTemplatesGVK = framework.GVK("usagetemplates." + schedv1alpha1.SchemeGroupVersion.Version + "." + schedv1alpha1.SchemeGroupVersion.Group)
+
+// getCoverageThresholds validates the minimum coverage args, the invalid ones are disabled
+func getCoverageThresholds(args *pluginConfig.TemporalUtilizationArgs) CoverageThresholds {
+	thresholds := CoverageThresholds{}
+
+	// MinConfidence must be between 0 and 100
+	if args.MinConfidence >= 0 && args.MinConfidence <= 100 {
+		thresholds.MinConfidence = args.MinConfidence
+	} else {
+		err := fmt.Errorf("must be between zero and a hundred")
+		klog.ErrorS(err, "Disabling minimum confidence, expected between zero and a hundred, got", "minConfidence", args.MinConfidence)
+	}
+
+	if args.MinSamplesPerHour >= 0 {
+		thresholds.MinSamplesPerHour = args.MinSamplesPerHour
+	} else {
+		err := fmt.Errorf("must not be negative")
+		klog.ErrorS(err, "Disabling minimum samples per hour, expected non negative, got", "minSamplesPerHour", args.MinSamplesPerHour)
+	}
+
+	return thresholds
+}
+
+// getFallbackPolicy validates the fallback args, an invalid policy falls back to ByClass
+func getFallbackPolicy(args *pluginConfig.TemporalUtilizationArgs) FallbackPolicy {
+	policy := FallbackPolicy{
+		Mode:             FallbackMode(args.FallbackPolicy),
+		LimitsPercentage: args.FallbackLimitsPercentage,
+		FilterByRequests: args.FilterPodsWithoutTemplateByRequests,
+	}
+
+	if len(policy.Mode) == 0 {
+		policy.Mode = ByClassFallback
+	}
+
+	if err := ValidateFallbackPolicy(policy.Mode, policy.LimitsPercentage); err != nil {
+		klog.ErrorS(err, "Using default fallback policy", "fallbackPolicy", ByClassFallback)
+		policy.Mode = ByClassFallback
+	}
+
+	return policy
+}
+
+// getHorizon validates the horizon args, an invalid horizon scores all the hours equally
+func getHorizon(args *pluginConfig.TemporalUtilizationArgs) Horizon {
+	h := Horizon{
+		ScoringHours:       int(args.ScoringHorizonHours),
+		Decay:              DecayCurve(args.ScoringDecay),
+		DecayHalfLifeHours: int(args.ScoringDecayHalfLifeHours),
+		InferFromTemplate:  args.InferHorizonFromTemplate,
+	}
+
+	if len(h.Decay) == 0 {
+		h.Decay = NoDecay
+	}
+
+	if err := validateHorizon(h); err != nil {
+		klog.ErrorS(err, "Scoring all the hours equally")
+		h.ScoringHours = 0
+		h.Decay = NoDecay
+	}
+
+	return h
+}
+
+// podLifetimeHours returns the expected lifetime of the pod when the horizon is inferred from the usage templates, 0 otherwise
+func (pl *TemporalUtilization) podLifetimeHours(pod *v1.Pod) int {
+	if !pl.Horizon.InferFromTemplate {
+		return 0
+	}
+	return podLifetimeHours(pl.utMgr, pod, pl.SupportedTargetResources())
+}
 
 func NewFitPlugin(handle framework.Handle) (*noderesources.Fit, error) {
 	fArgs := config.NodeResourcesFitArgs{
@@ -95,6 +211,7 @@ func New(obj runtime.Object, handle framework.Handle) (framework.Plugin, error)
 	}
 
 	ctx := context.TODO()
+	RegisterMetrics()
 
 	// setup informers for the usage template CRDs
 	pawsClient := pawsclientset.NewForConfigOrDie(handle.KubeConfig())
@@ -103,6 +220,9 @@ func New(obj runtime.Object, handle framework.Handle) (framework.Plugin, error)
 	podInformer := handle.SharedInformerFactory().Core().V1().Pods()
 
 	handler := NewUsageTemplateManager(pawsClient, handle.SnapshotSharedLister(), utInformer, podInformer)
+	if args.EnableOvercommit {
+		handler.enableMemoryForecasts()
+	}
 
 	pawsInformerFactory.Start(ctx.Done())
 
@@ -115,6 +235,12 @@ func New(obj runtime.Object, handle framework.Handle) (framework.Plugin, error)
 		klog.ErrorS(err, "Using default hotspot threshold as 100, Expected between one and a hundred, got", "threshold", args.HotSpotThreshold)
 	}
 
+	handler.MinCoverage = getCoverageThresholds(args)
+	handler.Fallback = getFallbackPolicy(args)
+	handler.nsLister = handle.SharedInformerFactory().Core().V1().Namespaces().Lister()
+	handler.AddNodeEventHandler(handle.SharedInformerFactory().Core().V1().Nodes().Informer())
+	handler.templates.Recorder = handle.EventRecorder()
+
 	enableOvercommit := args.EnableOvercommit
 
 	f, err := NewFitPlugin(handle)
@@ -127,10 +253,32 @@ func New(obj runtime.Object, handle framework.Handle) (framework.Plugin, error)
 		HotSpotThreshold:       hotspotThreshold,
 		HardThreshold:          args.HardThreshold,
 		EnableOvercommit:       enableOvercommit,
+		MemoryOOMMargin:        getMemoryOOMMargin(args),
 		FilterByTemporalUsages: args.FilterByTemporalUsages,
+		Horizon:                getHorizon(args),
 		utMgr:                  handler,
 		FitPlugin:              f,
+		live:                   getLiveUtilization(args),
+		AntiCorrelationWeight:  getAntiCorrelationWeight(args),
+		handle:                 handle,
+		deferrals:              newPodDeferrals(handle),
 	}
+	pl.NodePoolPolicies = getNodePoolPolicies(args, pl.defaultHotSpotPolicy())
+
+	// the temporal preemption relies on the filtering by temporal usages to find the hours the pod does not fit
+	if args.EnableTemporalPreemption {
+		if args.FilterByTemporalUsages {
+			pl.preemption = newTemporalPreemption(handle)
+		} else {
+			klog.ErrorS(fmt.Errorf("filterByTemporalUsages is disabled"), "Disabling temporal preemption")
+		}
+	}
+
+	if pl.live != nil {
+		go pl.live.run(ctx)
+	}
+	go pl.runDeferrals(ctx)
+	go pl.utMgr.runResync(ctx)
 
 	if !cache.WaitForCacheSync(ctx.Done(), utInformer.Informer().HasSynced) {
 		err := fmt.Errorf("WaitForCacheSync failed")
@@ -157,6 +305,10 @@ func getArgs(obj runtime.Object) (*pluginConfig.TemporalUtilizationArgs, error)
 }
 
 func (pl *TemporalUtilization) SupportedTargetResources() []string {
+	return supportedTargetResources()
+}
+
+func supportedTargetResources() []string {
 	results := []string{}
 	for k, _ := range DefaultResourceUnitMap {
 		results = append(results, k)
@@ -165,13 +317,90 @@ func (pl *TemporalUtilization) SupportedTargetResources() []string {
 }
 
 // PreFilterExtensions returns prefilter extensions, pod add and remove.
+// They are only needed by the temporal filtering, the requests of the pods are in the node info
 func (pl *TemporalUtilization) PreFilterExtensions() framework.PreFilterExtensions {
-	return nil
+	if !pl.FilterByTemporalUsages {
+		return nil
+	}
+	return pl
+}
+
+// EventsToRegister returns the events that may make a pod rejected by the plugin schedulable.
+// The scheduler does not report the changes of the node annotations, e.g. the overcommit ratios,
+// they are seen with the next change of the node or when the unschedulable pods are flushed
+func (pl *TemporalUtilization) EventsToRegister() []framework.ClusterEvent {
+	return []framework.ClusterEvent{
+		// the usages of a pod deleted from a node are freed
+		{Resource: framework.Pod, ActionType: framework.Delete},
+		{Resource: framework.Node, ActionType: framework.Add | framework.Update},
+		// an evaluation updates the usages of the pods of the usage template, and releases the pods waiting for it
+		{Resource: usageTemplatesGVK, ActionType: framework.Add | framework.Update},
+	}
 }
 
 func (pl *TemporalUtilization) preFilter(ctx context.Context, cycleState *framework.CycleState, pod *v1.Pod) (*framework.PreFilterResult, *framework.Status) {
 	cycleState.Write(preFilterStateKey, computePodResourceRequest(pod))
-	return nil, nil
+
+	return nil, pl.writePodUsagesState(cycleState, pod)
+}
+
+// writePodUsagesState resolves the temporal usages of the pod once for all the nodes
+func (pl *TemporalUtilization) writePodUsagesState(cycleState *framework.CycleState, pod *v1.Pod) *framework.Status {
+	s, err := pl.computePodUsagesState(pod)
+	if err != nil {
+		return framework.NewStatus(framework.Error, fmt.Sprintf("unable to obtain usage template for pod: %s/%s", pod.Namespace, pod.Name))
+	}
+	cycleState.Write(podUsagesStateKey, s)
+
+	return nil
+}
+
+// computePodUsagesState resolves the temporal usages of the pod from the pod object,
+// so that pods not yet in the informer cache are handled too
+func (pl *TemporalUtilization) computePodUsagesState(pod *v1.Pod) (*podUsagesState, error) {
+	usages, err := getPodUsages(pl.utMgr, pod, pl.SupportedTargetResources())
+	if err != nil {
+		return nil, err
+	}
+
+	state := &podUsagesState{
+		usages:        usages,
+		usable:        pl.hasUsableUsageTemplates(pod),
+		lifetimeHours: pl.podLifetimeHours(pod),
+	}
+
+	if pl.utMgr.memoryForecasts != nil {
+		state.memoryUsages, err = getPodUsages(pl.utMgr, pod, []string{v1.ResourceMemory.String()})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return state, nil
+}
+
+// getPodUsagesState reads the pod usages state, or computes it when PreFilter and PreScore did not run
+func (pl *TemporalUtilization) getPodUsagesState(cycleState *framework.CycleState, pod *v1.Pod) (*podUsagesState, error) {
+	c, err := cycleState.Read(podUsagesStateKey)
+	if err != nil {
+		return pl.computePodUsagesState(pod)
+	}
+
+	s, ok := c.(*podUsagesState)
+	if !ok {
+		return nil, fmt.Errorf("%+v  convert to TemporalUtilization.podUsagesState error", c)
+	}
+	return s, nil
+}
+
+// PreScore invoked at the prescore extension point, it resolves the temporal usages of the pod once for all the nodes.
+func (pl *TemporalUtilization) PreScore(ctx context.Context, cycleState *framework.CycleState, pod *v1.Pod, nodes []*v1.Node) *framework.Status {
+	if _, err := cycleState.Read(podUsagesStateKey); err == nil {
+		// already resolved at PreFilter
+		return nil
+	}
+
+	return pl.writePodUsagesState(cycleState, pod)
 }
 
 // PreFilter invoked at the prefilter extension point.
@@ -184,21 +413,39 @@ func (pl *TemporalUtilization) PreFilter(ctx context.Context, cycleState *framew
 		return pl.preFilter(ctx, cycleState, pod)
 	}
 
+	// the overcommit schedules and the memory cap are evaluated over the usages of the pod
+	if status := pl.writePodUsagesState(cycleState, pod); !status.IsSuccess() {
+		return nil, status
+	}
+
 	return pl.FitPlugin.PreFilter(ctx, cycleState, pod)
 }
 
-func (pl *TemporalUtilization) prepareNodeInfoForFilter(nodeInfo *framework.NodeInfo) (*framework.NodeInfo, error) {
+// prepareNodeInfoForFilter adds the overcommitted resources of the node to the allocatable,
+// the overcommit schedules and the memory forecasts are evaluated over the hours the pod is expected to overlap
+func (pl *TemporalUtilization) prepareNodeInfoForFilter(nodeInfo *framework.NodeInfo, podUsages *podUsagesState, slots []hourSlot) (*framework.NodeInfo, error) {
 
 	cloneNode := nodeInfo.Clone()
 	if !pl.EnableOvercommit {
 		return cloneNode, nil
 	}
 
-	resourceList, err := oc.CalculateOvercommitResources(nodeInfo, schedv1alpha1.SupportedOvercommitResourceAnnotation)
+	hours := make([]oc.HourOfWeek, 0, len(slots))
+	for _, slot := range slots {
+		hours = append(hours, oc.HourOfWeek{IsWeekday: slot.isWeekday, Hour: slot.hour})
+	}
+
+	policy := pl.hotSpotPolicy(nodeInfo.Node())
+	resourceList, err := oc.CalculateScheduledOvercommitResources(nodeInfo, schedv1alpha1.SupportedOvercommitResourceAnnotation,
+		schedv1alpha1.SupportedOvercommitScheduleAnnotation, policy.MaxOvercommitRatio, hours)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := pl.capMemoryOvercommit(resourceList, nodeInfo, podUsages, slots); err != nil {
+		return nil, err
+	}
+
 	cloneNode.Allocatable.Add(resourceList)
 
 	klog.V(3).InfoS("Overcommitable", "Node", nodeInfo.Node().Name, "Allocatable", cloneNode.Allocatable)
@@ -206,7 +453,17 @@ func (pl *TemporalUtilization) prepareNodeInfoForFilter(nodeInfo *framework.Node
 }
 
 func (pl *TemporalUtilization) filterWithFit(ctx context.Context, cycleState *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
-	cloneNode, err := pl.prepareNodeInfoForFilter(nodeInfo)
+	if !pl.EnableOvercommit {
+		// the pod usages only cap the overcommit
+		return pl.FitPlugin.Filter(ctx, cycleState, pod, nodeInfo)
+	}
+
+	podUsages, err := pl.getPodUsagesState(cycleState, pod)
+	if err != nil {
+		return framework.NewStatus(framework.Error, fmt.Sprintf("unable to obtain usage template for pod: %s/%s", pod.Namespace, pod.Name))
+	}
+
+	cloneNode, err := pl.prepareNodeInfoForFilter(nodeInfo, podUsages, pl.Horizon.filterSlots(time.Now(), podUsages.lifetimeHours))
 	if err != nil {
 		return framework.NewStatus(framework.Error, fmt.Errorf("node %v: %v ", nodeInfo.Node().Name, err).Error())
 	}
@@ -224,7 +481,15 @@ func (pl *TemporalUtilization) filterWithTemporalUsages(ctx context.Context, cyc
 		return framework.AsStatus(err)
 	}
 
-	cloneNode, err := pl.prepareNodeInfoForFilter(nodeInfo)
+	podUsages, err := pl.getPodUsagesState(cycleState, pod)
+	if err != nil {
+		return framework.NewStatus(framework.Error, fmt.Sprintf("unable to obtain usage template for pod: %s/%s", pod.Namespace, pod.Name))
+	}
+
+	// only the hours the pod is expected to overlap
+	slots := pl.Horizon.filterSlots(time.Now(), podUsages.lifetimeHours)
+
+	cloneNode, err := pl.prepareNodeInfoForFilter(nodeInfo, podUsages, slots)
 	if err != nil {
 		return framework.NewStatus(framework.Error, fmt.Errorf("node %v: %v ", nodeInfo.Node().Name, err).Error())
 	}
@@ -238,23 +503,43 @@ func (pl *TemporalUtilization) filterWithTemporalUsages(ctx context.Context, cyc
 		return status
 	}
 
-	podTemporalUsages, err := getUsageTemplatesByPod(pl.utMgr, pod.Namespace, pod.Name, pl.SupportedTargetResources())
-	if err != nil {
-		return framework.NewStatus(framework.Error, fmt.Sprintf("unable to obtain usage template for pod: %s/%s", pod.Namespace, pod.Name))
+	if pl.utMgr.Fallback.FilterByRequests && !podUsages.usable {
+		// the pod does not take part in the temporal filtering, check its requests on the target resources too
+		return checkInsufficientResources(fitsRequest(podResourcesRequested, cloneNode, sets.NewString(), nil, nil))
 	}
 
 	// third, calculate forecasts on supported resources
-	forecasts, msg, err := obtainForecasts(pl.utMgr, cloneNode, cloneNode.Node().Name, pod, pl.SupportedTargetResources())
+	forecasts, msg, err := obtainForecasts(pl.utMgr, cloneNode, cloneNode.Node().Name, podUsages.usages, pl.SupportedTargetResources())
 	if err != nil {
 		return framework.NewStatus(framework.Error, msg)
 	}
 
+	// the pods added or removed in the cycle, e.g. the victims of a preemption
+	adjustments, err := getNodeAdjustmentsState(cycleState)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	if adjustments != nil {
+		adjustments.apply(forecasts, cloneNode.Node().Name)
+	}
+
 	// fourth, filter using the forecasts over time
-	insufficientResources = fitsRequestWithTemporal(podTemporalUsages, forecasts, cloneNode)
+	insufficientResources = fitsRequestWithTemporal(podUsages.usages, forecasts, cloneNode, slots)
 
 	return checkInsufficientResources(insufficientResources)
 }
 
+// hasUsableUsageTemplates checks whether the pod has a usable usage template for all the target resources
+func (pl *TemporalUtilization) hasUsableUsageTemplates(pod *v1.Pod) bool {
+	_, ut := pl.utMgr.GetUsageTemplate(pod)
+	for _, res := range pl.SupportedTargetResources() {
+		if !hasUsableUsageTemplate(pl.utMgr, ut, res) {
+			return false
+		}
+	}
+	return true
+}
+
 func (pl *TemporalUtilization) Filter(ctx context.Context, cycleState *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
 	if pl.FilterByTemporalUsages {
 		return pl.filterWithTemporalUsages(ctx, cycleState, pod, nodeInfo)
@@ -273,12 +558,38 @@ func (pl *TemporalUtilization) Score(ctx context.Context, cycleState *framework.
 			fmt.Sprintf("getting node %q from Snapshot: %v", nodeName, err))
 	}
 
-	forecasts, msg, err := obtainForecasts(pl.utMgr, nodeInfo, nodeName, pod, pl.SupportedTargetResources())
+	podUsages, err := pl.getPodUsagesState(cycleState, pod)
+	if err != nil {
+		return framework.MinNodeScore, framework.NewStatus(framework.Error,
+			fmt.Sprintf("unable to obtain usage template for pod: %s/%s", pod.Namespace, pod.Name))
+	}
+
+	forecasts, msg, err := obtainForecasts(pl.utMgr, nodeInfo, nodeName, podUsages.usages, pl.SupportedTargetResources())
 	if err != nil {
 		return framework.MinNodeScore, framework.NewStatus(framework.Error, msg)
 	}
 
-	finalScore, status := scorer(nodeInfo, forecasts, pl.HotSpotThreshold, pl.HardThreshold)
+	now := time.Now()
+	slots := pl.Horizon.scoreSlots(now, podUsages.lifetimeHours)
+
+	// the correlation is of the forecasts, before the live utilization is blended in the current hour
+	var correlationForecasts map[string]*UsageTemplate
+	if pl.AntiCorrelationWeight > 0 {
+		correlationForecasts = make(map[string]*UsageTemplate, len(forecasts))
+		addUsages(correlationForecasts, forecasts)
+	}
+
+	pl.blendLiveUtilization(forecasts, podUsages.usages, nodeInfo.Node(), now)
+
+	finalScore, status := scorer(nodeInfo, forecasts, slots, pl.hotSpotPolicy(nodeInfo.Node()))
+	if status.IsSuccess() && pl.AntiCorrelationWeight > 0 {
+		r, ok := usageCorrelation(podUsages.usages[v1.ResourceCPU.String()], correlationForecasts[v1.ResourceCPU.String()], slots)
+		if ok {
+			// observed at Reserve for the node the pod is placed on
+			podUsages.correlations.Store(nodeName, r)
+		}
+		finalScore = pl.blendAntiCorrelation(finalScore, r, ok, slots)
+	}
 
 	klog.V(6).InfoS("Temporal Score", "Score", finalScore, "Pod", klog.KObj(pod), "Node", klog.KObj(nodeInfo.Node()))
 
@@ -320,6 +631,7 @@ func (pl *TemporalUtilization) NormalizeScore(ctx context.Context, state *framew
 }
 
 func (pl *TemporalUtilization) Reserve(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) *framework.Status {
+	pl.observePlacementCorrelation(state, pod, nodeName)
 	pl.utMgr.addToCacheIfNotExists(pod, nodeName)
 	// cannot fail
 	return framework.NewStatus(framework.Success, "")
@@ -327,4 +639,6 @@ func (pl *TemporalUtilization) Reserve(ctx context.Context, state *framework.Cyc
 
 func (pl *TemporalUtilization) Unreserve(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) {
 	pl.utMgr.deleteFromCacheIfExists(pod, nodeName)
+	// a deferred pod is unreserved when its wait is rejected
+	pl.deferrals.remove(pod.UID, RejectedDeferralResult, time.Now())
 }
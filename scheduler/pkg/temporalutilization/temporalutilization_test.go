@@ -2,7 +2,9 @@ package temporalutilization
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	pluginConfig "gitee.com/openeuler/paws/scheduler/apis/config"
 	"gitee.com/openeuler/paws/scheduler/apis/config/v1beta3"
@@ -12,6 +14,8 @@ import (
 	testutils "gitee.com/openeuler/paws/scheduler/pkg/test/util"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/informers"
 	testClientSet "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
@@ -391,6 +395,9 @@ func TestTemporalUtilizationWithOvercommitFiltering(t *testing.T) {
 
 			assert.Nil(t, result)
 			assert.Nil(t, status)
+			// the usages of the pod are resolved once for all the nodes
+			_, err = state.Read(podUsagesStateKey)
+			assert.NoError(t, err)
 			nodeInfo := framework.NewNodeInfo(tt.scheduledPods...)
 
 			tt.node.Annotations = make(map[string]string)
@@ -414,6 +421,42 @@ func TestTemporalUtilizationWithOvercommitFiltering(t *testing.T) {
 	}
 }
 
+func TestTemporalUtilizationFilteringWithoutOvercommit(t *testing.T) {
+	node := st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{
+		v1.ResourceCPU: "1000m",
+	}).Obj()
+	pod := st.MakePod().Namespace("default").Name("pod-1").Containers([]v1.Container{
+		st.MakeContainer().Resources(map[v1.ResourceName]string{
+			v1.ResourceCPU: "1100m",
+		}).Obj(),
+	}).Obj()
+
+	fit, err := NewFitPlugin(nil)
+	assert.NoError(t, err)
+	// without the usage template manager, resolving the usages of the pod would panic
+	pl := &TemporalUtilization{
+		HotSpotThreshold: int32(v1beta3.DefaultHotSpotThreshold),
+		HardThreshold:    v1beta3.DefaultHardThresholdValue,
+		FitPlugin:        fit,
+	}
+
+	ctx := context.Background()
+	state := framework.NewCycleState()
+	_, status := pl.PreFilter(ctx, state, pod)
+	assert.Nil(t, status)
+	_, status = fit.PreFilter(ctx, state, pod)
+	assert.Nil(t, status)
+
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(node)
+	status = pl.Filter(ctx, state, pod, nodeInfo)
+	assert.Equal(t, framework.Unschedulable, status.Code())
+	assert.ElementsMatch(t, []string{"Insufficient cpu"}, status.Reasons())
+
+	_, err = state.Read(podUsagesStateKey)
+	assert.Error(t, err)
+}
+
 func TestTemporalUtilizationWithTemporalFiltering(t *testing.T) {
 	args := pluginConfig.TemporalUtilizationArgs{
 		HotSpotThreshold:       int32(v1beta3.DefaultHotSpotThreshold),
@@ -429,6 +472,7 @@ func TestTemporalUtilizationWithTemporalFiltering(t *testing.T) {
 		node            *v1.Node
 		usageTemplates  []*v1alpha1.UsageTemplate
 		overcommitRatio map[string]string
+		fallback        *FallbackPolicy
 		expected        *framework.Status
 	}{
 		{
@@ -483,12 +527,74 @@ func TestTemporalUtilizationWithTemporalFiltering(t *testing.T) {
 					}, true),
 			},
 		},
+		{
+			name: "Filter pod without usage template by temporal usages, ok",
+			pod: st.MakePod().Namespace("default").Name("pod-1").Containers([]v1.Container{
+				st.MakeContainer().Resources(map[v1.ResourceName]string{
+					v1.ResourceCPU: "500m",
+				}).Obj(),
+			}).Obj(),
+			scheduledPods: []*v1.Pod{
+				st.MakePod().Namespace("default").Name("pod-2").Node("node-1").Labels(map[string]string{
+					v1alpha1.UsageTemplateLabelIdentifier: "test-crd-2",
+				}).Containers([]v1.Container{
+					st.MakeContainer().Resources(map[v1.ResourceName]string{
+						v1.ResourceCPU: "800m",
+					}).Obj(),
+				}).Obj(),
+			},
+			node: st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{
+				v1.ResourceCPU: "1000m",
+			}).Obj(),
+			expected: nil,
+			usageTemplates: []*v1alpha1.UsageTemplate{
+				testutils.MakeUsageTemplate("test-crd-2", "default", true, "Burstable",
+					map[string]map[int]float32{
+						"cpu": testutils.SameUsageADay(100),
+					}, map[string]map[int]float32{
+						"cpu": testutils.SameUsageADay(100),
+					}, true),
+			},
+		},
+		{
+			name: "Filter pod without usage template by requests, Unschedulable",
+			pod: st.MakePod().Namespace("default").Name("pod-1").Containers([]v1.Container{
+				st.MakeContainer().Resources(map[v1.ResourceName]string{
+					v1.ResourceCPU: "500m",
+				}).Obj(),
+			}).Obj(),
+			scheduledPods: []*v1.Pod{
+				st.MakePod().Namespace("default").Name("pod-2").Node("node-1").Labels(map[string]string{
+					v1alpha1.UsageTemplateLabelIdentifier: "test-crd-2",
+				}).Containers([]v1.Container{
+					st.MakeContainer().Resources(map[v1.ResourceName]string{
+						v1.ResourceCPU: "800m",
+					}).Obj(),
+				}).Obj(),
+			},
+			node: st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{
+				v1.ResourceCPU: "1000m",
+			}).Obj(),
+			fallback: &FallbackPolicy{Mode: ByClassFallback, FilterByRequests: true},
+			expected: framework.NewStatus(framework.Unschedulable, "Insufficient cpu"),
+			usageTemplates: []*v1alpha1.UsageTemplate{
+				testutils.MakeUsageTemplate("test-crd-2", "default", true, "Burstable",
+					map[string]map[int]float32{
+						"cpu": testutils.SameUsageADay(100),
+					}, map[string]map[int]float32{
+						"cpu": testutils.SameUsageADay(100),
+					}, true),
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			state := framework.NewCycleState()
 			mgr := newTestUsageEvaluationManager([]*v1.Node{tt.node}, tt.pod, tt.scheduledPods, tt.usageTemplates)
+			if tt.fallback != nil {
+				mgr.Fallback = *tt.fallback
+			}
 
 			fit, err := NewFitPlugin(nil)
 			assert.NoError(t, err)
@@ -592,3 +698,420 @@ func newTestSharedLister(pods []*v1.Pod, nodes []*v1.Node) *testSharedLister {
 		nodeInfoMap: nodeInfoMap,
 	}
 }
+
+func TestTemporalUtilizationFilteringWithinHorizon(t *testing.T) {
+	now := time.Now().UTC()
+	peakHour := (now.Hour() + 20) % NumHoursInADay
+
+	// an existing long running pod peaking above the capacity 20 hours away
+	peakUsages := testutils.SameUsageADay(100)
+	peakUsages[peakHour] = 1100
+
+	tests := []struct {
+		name     string
+		horizon  Horizon
+		expected *framework.Status
+	}{
+		{
+			name:     "short-lived pod is filtered by all the hours, Unschedulable",
+			horizon:  Horizon{Decay: NoDecay},
+			expected: framework.NewStatus(framework.Unschedulable, fmt.Sprintf("Insufficient cpu at hour: %d", peakHour)),
+		},
+		{
+			name:     "short-lived pod is filtered by the hours it overlaps, ok",
+			horizon:  Horizon{Decay: NoDecay, InferFromTemplate: true},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := st.MakePod().Namespace("default").Name("pod-1").Labels(map[string]string{
+				v1alpha1.UsageTemplateLabelIdentifier: "test-crd-1",
+			}).Obj()
+			scheduledPod := st.MakePod().Namespace("default").Name("pod-2").Node("node-1").Labels(map[string]string{
+				v1alpha1.UsageTemplateLabelIdentifier: "test-crd-2",
+			}).Obj()
+			node := st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{
+				v1.ResourceCPU: "1000m",
+			}).Obj()
+			usageTemplates := []*v1alpha1.UsageTemplate{
+				// a two hours job
+				testutils.MakeUsageTemplate("test-crd-1", "default", true, "BestEffort",
+					map[string]map[int]float32{
+						"cpu": testutils.MakeUsageAcrossPeriods([][]float32{{0.0, 2.0, 300}}),
+					}, map[string]map[int]float32{}, false),
+				testutils.MakeUsageTemplate("test-crd-2", "default", true, "BestEffort",
+					map[string]map[int]float32{"cpu": peakUsages},
+					map[string]map[int]float32{"cpu": peakUsages}, true),
+			}
+
+			mgr := newTestUsageEvaluationManager([]*v1.Node{node}, pod, []*v1.Pod{scheduledPod}, usageTemplates)
+			mgr.OnAdd(scheduledPod)
+
+			pl := &TemporalUtilization{
+				FilterByTemporalUsages: true,
+				Horizon:                tt.horizon,
+				utMgr:                  mgr,
+			}
+
+			ctx := context.Background()
+			state := framework.NewCycleState()
+			_, status := pl.preFilter(ctx, state, pod)
+			assert.Nil(t, status)
+
+			nodeInfo := framework.NewNodeInfo(scheduledPod)
+			nodeInfo.SetNode(node)
+
+			status = pl.Filter(ctx, state, pod, nodeInfo)
+			if tt.expected == nil {
+				assert.Nil(t, status)
+			} else {
+				assert.NotNil(t, status)
+				assert.Equal(t, tt.expected.Code(), status.Code())
+				assert.Contains(t, status.Reasons(), tt.expected.Reasons()[0])
+			}
+		})
+	}
+}
+
+func TestHorizonSlots(t *testing.T) {
+	// a Friday evening, so that the horizon crosses into the weekend
+	now := time.Date(2024, 10, 18, 22, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		hours    int
+		decay    DecayCurve
+		halfLife int
+		expected []hourSlot
+	}{
+		{
+			name:  "linear decay within the horizon",
+			hours: 4,
+			decay: LinearDecay,
+			expected: []hourSlot{
+				{isWeekday: true, hour: 22, weight: 1},
+				{isWeekday: true, hour: 23, weight: 0.75},
+				{isWeekday: false, hour: 0, weight: 0.5},
+				{isWeekday: false, hour: 1, weight: 0.25},
+			},
+		},
+		{
+			name:     "exponential decay within the horizon",
+			hours:    3,
+			decay:    ExponentialDecay,
+			halfLife: 1,
+			expected: []hourSlot{
+				{isWeekday: true, hour: 22, weight: 1},
+				{isWeekday: true, hour: 23, weight: 0.5},
+				{isWeekday: false, hour: 0, weight: 0.25},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, horizonSlots(now, tt.hours, tt.decay, tt.halfLife))
+		})
+	}
+
+	// without a horizon, every weekday and weekend hour weighs the same
+	slots := horizonSlots(now, 0, NoDecay, 0)
+	assert.Len(t, slots, 2*NumHoursInADay)
+	for _, slot := range slots {
+		assert.Equal(t, float64(1), slot.weight)
+	}
+}
+
+func TestPodLifetimeHours(t *testing.T) {
+	labels := map[string]string{v1alpha1.UsageTemplateLabelIdentifier: "test-crd-1"}
+	pod := st.MakePod().Namespace("default").Name("pod-1").Labels(labels).Obj()
+	twoHours := map[string]map[int]float32{
+		"cpu": testutils.MakeUsageAcrossPeriods([][]float32{{0.0, 2.0, 300}}),
+	}
+
+	tests := []struct {
+		name     string
+		template *v1alpha1.UsageTemplate
+		expected int
+	}{
+		{
+			name: "short-lived usages evaluated on weekdays",
+			template: testutils.MakeUsageTemplate("test-crd-1", "default", true, "BestEffort",
+				twoHours, map[string]map[int]float32{}, false),
+			expected: 2,
+		},
+		{
+			// the weekend samples are the hours 24 to 47
+			name: "short-lived usages evaluated on weekdays and weekends",
+			template: testutils.MakeUsageTemplate("test-crd-1", "default", true, "BestEffort",
+				twoHours, twoHours, false),
+			expected: 2,
+		},
+		{
+			name: "long running usages",
+			template: testutils.MakeUsageTemplate("test-crd-1", "default", true, "BestEffort",
+				twoHours, twoHours, true),
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := newTestUsageEvaluationManager(nil, pod, nil, []*v1alpha1.UsageTemplate{tt.template})
+			assert.Equal(t, tt.expected, podLifetimeHours(mgr, pod, []string{"cpu"}))
+		})
+	}
+}
+
+func TestTemporalUtilizationPreScoreWithPodNotInInformer(t *testing.T) {
+	node := st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{
+		v1.ResourceCPU: "1000m",
+	}).Obj()
+	scheduledPod := st.MakePod().Namespace("default").Name("pod-1").Node("node-1").Labels(map[string]string{
+		v1alpha1.UsageTemplateLabelIdentifier: "test-crd-1",
+	}).Obj()
+	// a freshly created pod, not yet in the informer cache
+	pod := st.MakePod().Namespace("default").Name("pod-2").Labels(map[string]string{
+		v1alpha1.UsageTemplateLabelIdentifier: "test-crd-1",
+	}).Obj()
+	usageTemplates := []*v1alpha1.UsageTemplate{
+		testutils.MakeUsageTemplate("test-crd-1", "default", true, "BestEffort",
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(200)},
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(200)}, true),
+	}
+
+	mgr := newTestUsageEvaluationManager([]*v1.Node{node}, scheduledPod, nil, usageTemplates)
+	mgr.OnAdd(scheduledPod)
+	_, err := mgr.podLister.Pods(pod.Namespace).Get(pod.Name)
+	assert.NotNil(t, err)
+
+	pl := &TemporalUtilization{
+		HotSpotThreshold: int32(v1beta3.DefaultHotSpotThreshold),
+		HardThreshold:    v1beta3.DefaultHardThresholdValue,
+		utMgr:            mgr,
+	}
+
+	ctx := context.Background()
+	state := framework.NewCycleState()
+	status := pl.PreScore(ctx, state, pod, []*v1.Node{node})
+	assert.True(t, status.IsSuccess())
+
+	s, err := pl.getPodUsagesState(state, pod)
+	assert.Nil(t, err)
+	assert.True(t, s.usable)
+	assert.Equal(t, float32(200), s.usages["cpu"].weekDayHour[0])
+
+	_, status = pl.Score(ctx, state, pod, "node-1")
+	assert.True(t, status.IsSuccess())
+}
+
+func TestTemporalUtilizationScoringWithNodePoolPolicies(t *testing.T) {
+	maxOvercommit := int32(20)
+	args := &pluginConfig.TemporalUtilizationArgs{
+		NodePoolPolicies: []pluginConfig.NodePoolPolicy{
+			{
+				NodeSelector:            &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "latency"}},
+				HotSpotThreshold:        50,
+				HardThreshold:           true,
+				MaxOvercommitPercentage: &maxOvercommit,
+			},
+			{
+				NodeSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "batch"}},
+				HotSpotThreshold: 85,
+			},
+		},
+	}
+
+	nodeResources := map[v1.ResourceName]string{
+		v1.ResourceCPU: "1000m",
+	}
+	nodes := []*v1.Node{
+		st.MakeNode().Name("latency-1").Label("pool", "latency").Capacity(nodeResources).Obj(),
+		st.MakeNode().Name("batch-1").Label("pool", "batch").Capacity(nodeResources).Obj(),
+	}
+
+	// 70% usage on every node, 80% with the incoming best effort pod
+	pod := st.MakePod().Name("pod-1").Namespace("default").Obj()
+	var scheduledPods []*v1.Pod
+	for _, node := range nodes {
+		scheduledPods = append(scheduledPods, st.MakePod().Namespace("default").Name("pod-"+node.Name).Node(node.Name).Labels(map[string]string{
+			v1alpha1.UsageTemplateLabelIdentifier: "test-crd-1",
+		}).Obj())
+	}
+	usageTemplates := []*v1alpha1.UsageTemplate{
+		testutils.MakeUsageTemplate("test-crd-1", "default", true, "BestEffort",
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(700)},
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(700)}, true),
+	}
+
+	mgr := newTestUsageEvaluationManager(nodes, pod, scheduledPods, usageTemplates)
+	for _, sp := range scheduledPods {
+		mgr.OnAdd(sp)
+	}
+
+	pl := &TemporalUtilization{
+		HotSpotThreshold: int32(v1beta3.DefaultHotSpotThreshold),
+		utMgr:            mgr,
+	}
+	pl.NodePoolPolicies = getNodePoolPolicies(args, pl.defaultHotSpotPolicy())
+
+	assert.Equal(t, 0.2, pl.hotSpotPolicy(nodes[0]).MaxOvercommitRatio)
+	assert.Equal(t, pl.defaultHotSpotPolicy(), pl.hotSpotPolicy(st.MakeNode().Name("other-1").Obj()))
+
+	state := framework.NewCycleState()
+	latencyScore, status := pl.Score(context.Background(), state, pod, "latency-1")
+	assert.True(t, status.IsSuccess())
+	batchScore, status := pl.Score(context.Background(), state, pod, "batch-1")
+	assert.True(t, status.IsSuccess())
+
+	// above the hard threshold of the latency pool, below the threshold of the batch pool
+	assert.Equal(t, framework.MinNodeScore, latencyScore)
+	assert.Greater(t, batchScore, framework.MinNodeScore)
+}
+
+func TestTemporalUtilizationScoringWithNodePerformanceFactor(t *testing.T) {
+	nodeResources := map[v1.ResourceName]string{
+		v1.ResourceCPU: "1000m",
+	}
+	nodes := []*v1.Node{
+		st.MakeNode().Name("reference-1").Capacity(nodeResources).Obj(),
+		st.MakeNode().Name("fast-1").Capacity(nodeResources).Obj(),
+		st.MakeNode().Name("invalid-1").Capacity(nodeResources).Obj(),
+	}
+	nodes[1].Annotations = map[string]string{v1alpha1.NodeCPUPerformanceFactorAnnotation: "2"}
+	nodes[2].Annotations = map[string]string{v1alpha1.NodeCPUPerformanceFactorAnnotation: "-1"}
+
+	// the fast node does the work of 2000m reference cores
+	assert.Equal(t, int64(2000), scaleByPerformanceFactor(nodes[1], v1.ResourceCPU.String(), 1000))
+	assert.Equal(t, int64(1000), scaleByPerformanceFactor(nodes[1], v1.ResourceMemory.String(), 1000))
+	assert.Equal(t, int64(1000), scaleByPerformanceFactor(nodes[2], v1.ResourceCPU.String(), 1000))
+
+	// 70% usage of a reference node on every node
+	pod := st.MakePod().Name("pod-1").Namespace("default").Obj()
+	var scheduledPods []*v1.Pod
+	for _, node := range nodes {
+		scheduledPods = append(scheduledPods, st.MakePod().Namespace("default").Name("pod-"+node.Name).Node(node.Name).Labels(map[string]string{
+			v1alpha1.UsageTemplateLabelIdentifier: "test-crd-1",
+		}).Obj())
+	}
+	usageTemplates := []*v1alpha1.UsageTemplate{
+		testutils.MakeUsageTemplate("test-crd-1", "default", true, "BestEffort",
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(700)},
+			map[string]map[int]float32{"cpu": testutils.SameUsageADay(700)}, true),
+	}
+
+	mgr := newTestUsageEvaluationManager(nodes, pod, scheduledPods, usageTemplates)
+	for _, sp := range scheduledPods {
+		mgr.OnAdd(sp)
+	}
+
+	pl := &TemporalUtilization{
+		HotSpotThreshold: int32(v1beta3.DefaultHotSpotThreshold),
+		utMgr:            mgr,
+	}
+
+	state := framework.NewCycleState()
+	scores := make(map[string]int64)
+	for _, node := range nodes {
+		score, status := pl.Score(context.Background(), state, pod, node.Name)
+		assert.True(t, status.IsSuccess())
+		scores[node.Name] = score
+	}
+
+	assert.Greater(t, scores["fast-1"], scores["reference-1"])
+	assert.Equal(t, scores["reference-1"], scores["invalid-1"])
+}
+
+func TestTemporalUtilizationMemoryOvercommitCappedByForecast(t *testing.T) {
+	node := st.MakeNode().Name("node-1").Capacity(map[v1.ResourceName]string{
+		v1.ResourceCPU:              "1000m",
+		v1.ResourceMemory:           "10Gi",
+		v1.ResourceEphemeralStorage: "10Gi",
+	}).Obj()
+	node.Annotations = map[string]string{
+		v1alpha1.NodeMemoryOvercommitRatioAnnotation:           "0.5",
+		v1alpha1.NodeEphemeralStorageOvercommitRatioAnnotation: "0.5",
+	}
+
+	// a scheduled pod with a 6Gi working set and an incoming pod with a 1Gi working set
+	pod := st.MakePod().Name("pod-1").Namespace("default").Labels(map[string]string{
+		v1alpha1.UsageTemplateLabelIdentifier: "small",
+	}).Obj()
+	scheduledPods := []*v1.Pod{
+		st.MakePod().Name("pod-2").Namespace("default").Node("node-1").Labels(map[string]string{
+			v1alpha1.UsageTemplateLabelIdentifier: "large",
+		}).Obj(),
+	}
+	usageTemplates := []*v1alpha1.UsageTemplate{
+		testutils.MakeUsageTemplate("small", "default", true, "BestEffort",
+			map[string]map[int]float32{"memory": testutils.SameUsageADay(1 << 30)},
+			map[string]map[int]float32{"memory": testutils.SameUsageADay(1 << 30)}, true),
+		testutils.MakeUsageTemplate("large", "default", true, "BestEffort",
+			map[string]map[int]float32{"memory": testutils.SameUsageADay(6 << 30)},
+			map[string]map[int]float32{"memory": testutils.SameUsageADay(6 << 30)}, true),
+	}
+
+	tests := []struct {
+		name              string
+		memoryForecasts   bool
+		expectedMemory    int64
+		expectedEphemeral int64
+	}{
+		{
+			name:              "memory overcommit dropped without memory forecasts",
+			memoryForecasts:   false,
+			expectedMemory:    10 << 30,
+			expectedEphemeral: 15 << 30,
+		},
+		{
+			name:            "memory overcommit capped at the allocatable left at the forecast peak",
+			memoryForecasts: true,
+			// 10Gi less the 10% margin less the 7Gi peak, under the 5Gi overcommit
+			expectedMemory:    12 << 30,
+			expectedEphemeral: 15 << 30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := newTestUsageEvaluationManager([]*v1.Node{node}, pod, scheduledPods, usageTemplates)
+			if tt.memoryForecasts {
+				mgr.enableMemoryForecasts()
+			}
+			for _, sp := range scheduledPods {
+				mgr.OnAdd(sp)
+			}
+
+			pl := &TemporalUtilization{
+				EnableOvercommit: true,
+				MemoryOOMMargin:  float64(DefaultMemoryOOMMarginPercentage) / 100,
+				utMgr:            mgr,
+			}
+
+			podUsages, err := pl.computePodUsagesState(pod)
+			assert.NoError(t, err)
+
+			nodeInfo := framework.NewNodeInfo(scheduledPods...)
+			nodeInfo.SetNode(node)
+
+			cloneNode, err := pl.prepareNodeInfoForFilter(nodeInfo, podUsages, pl.Horizon.filterSlots(time.Now(), podUsages.lifetimeHours))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedMemory, cloneNode.Allocatable.Memory)
+			assert.Equal(t, tt.expectedEphemeral, cloneNode.Allocatable.EphemeralStorage)
+		})
+	}
+}
+
+func TestTemporalUtilizationEventsToRegister(t *testing.T) {
+	pl := &TemporalUtilization{}
+	events := pl.EventsToRegister()
+
+	assert.Contains(t, events, framework.ClusterEvent{Resource: framework.Pod, ActionType: framework.Delete})
+	assert.Contains(t, events, framework.ClusterEvent{Resource: usageTemplatesGVK, ActionType: framework.Add | framework.Update})
+
+	// the scheduler watches the usage templates with a dynamic informer parsed from the GVK
+	gvr, _ := schema.ParseResourceArg(string(usageTemplatesGVK))
+	assert.Equal(t, v1alpha1.SchemeGroupVersion.WithResource("usagetemplates"), *gvr)
+}
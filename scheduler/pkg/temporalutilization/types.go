@@ -37,6 +37,15 @@ func (u *UsageTemplate) MaxUsage() float32 {
 	return v
 }
 
+// CoverageThresholds is the minimum evaluation coverage for a usage template to drive scheduling,
+// a zero threshold is not checked
+type CoverageThresholds struct {
+	// MinConfidence is the minimum status.coverage.confidence between 0 and 100
+	MinConfidence int32
+	// MinSamplesPerHour is the minimum count of every hourly sample of a resource
+	MinSamplesPerHour int32
+}
+
 type NamespacedPod struct {
 	Namespace string
 	Name      string
@@ -14,7 +14,6 @@ package temporalutilization
 
 import (
 	"context"
-	"fmt"
 	"testing"
 
 	fakeclientset "gitee.com/openeuler/paws/scheduler/pkg/generated/clientset/versioned/fake"
@@ -23,6 +22,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/informers"
 	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	metricstestutil "k8s.io/component-base/metrics/testutil"
 	st "k8s.io/kubernetes/pkg/scheduler/testing"
 	testutil "sigs.k8s.io/scheduler-plugins/test/util"
 )
@@ -95,6 +95,38 @@ func TestUsageTemplateManagerCacheUpdateLogic(t *testing.T) {
 				testNode2: {"Pod-1"},
 			},
 		},
+		{
+			name: "OnUpdate removes succeeded pods",
+			nodePodsMap: map[string][]NamespacedPod{
+				testNode1: {{"default", "Pod-1"}, {"default", "Pod-2"}},
+			},
+			podsToUpdate: []*UpdateItem{
+				{old: st.MakePod().Name("Pod-1").Namespace("default").Node(testNode1).Obj(), new: st.MakePod().Name("Pod-1").Namespace("default").Node(testNode1).Phase(v1.PodSucceeded).Obj()},
+			},
+			expectedTotalCacheSize: 1,
+			expectedPerNodeCacheSize: map[string]int{
+				testNode1: 1,
+			},
+			expectedNodeCachePods: map[string][]string{
+				testNode1: {"Pod-2"},
+			},
+		},
+		{
+			name: "OnUpdate removes terminating pods",
+			nodePodsMap: map[string][]NamespacedPod{
+				testNode1: {{"default", "Pod-1"}, {"default", "Pod-2"}},
+			},
+			podsToUpdate: []*UpdateItem{
+				{old: st.MakePod().Name("Pod-2").Namespace("default").Node(testNode1).Obj(), new: st.MakePod().Name("Pod-2").Namespace("default").Node(testNode1).Terminating().Obj()},
+			},
+			expectedTotalCacheSize: 1,
+			expectedPerNodeCacheSize: map[string]int{
+				testNode1: 1,
+			},
+			expectedNodeCachePods: map[string][]string{
+				testNode1: {"Pod-1"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -145,3 +177,64 @@ func extractPodNames(pods []NamespacedPod) []string {
 	}
 	return podNames
 }
+
+func TestUsageTemplateManagerResync(t *testing.T) {
+	testNode1 := "node-1"
+	testNode2 := "node-2"
+
+	pods := []*v1.Pod{
+		st.MakePod().Name("Pod-1").Namespace("default").Node(testNode1).Obj(),
+		st.MakePod().Name("Pod-2").Namespace("default").Node(testNode2).Obj(),
+		st.MakePod().Name("Pod-3").Namespace("default").Node(testNode2).Terminating().Obj(),
+		st.MakePod().Name("Pod-4").Namespace("default").Node(testNode1).Obj(),
+		// reserved on node-1, being bound
+		st.MakePod().Name("Pod-6").Namespace("default").Obj(),
+	}
+
+	ctx := context.Background()
+	cs := fakeclientset.NewSimpleClientset()
+	pawsInformerFactory := pawsinformers.NewSharedInformerFactory(cs, 0)
+	utInformer := pawsInformerFactory.Scheduling().V1alpha1().UsageTemplates()
+	pawsInformerFactory.Start(ctx.Done())
+
+	informerFactory := informers.NewSharedInformerFactory(clientsetfake.NewSimpleClientset(), 0)
+	podInformer := informerFactory.Core().V1().Pods()
+	mgr := NewUsageTemplateManager(cs, nil, utInformer, podInformer)
+	informerFactory.Start(ctx.Done())
+	informerFactory.WaitForCacheSync(ctx.Done())
+	for _, pod := range pods {
+		// added to the store directly, as if the pod events were missed
+		podInformer.Informer().GetStore().Add(pod)
+	}
+
+	RegisterMetrics()
+	staleBefore, _ := metricstestutil.GetCounterMetricValue(nodePodsCacheDrift.WithLabelValues(StaleDrift))
+	missingBefore, _ := metricstestutil.GetCounterMetricValue(nodePodsCacheDrift.WithLabelValues(MissingDrift))
+
+	mgr.NodePodsCache = map[string][]NamespacedPod{
+		// Pod-5 finished while its events were missed, Pod-2 is missing
+		testNode1: {{"default", "Pod-1"}, {"default", "Pod-4"}, {"default", "Pod-5"}, {"default", "Pod-6"}},
+		testNode2: {{"default", "Pod-3"}},
+	}
+
+	mgr.resync()
+	assert.ElementsMatch(t, []string{"Pod-1", "Pod-4", "Pod-6"}, extractPodNames(mgr.NodePodsCache[testNode1]))
+	assert.ElementsMatch(t, []string{"Pod-2"}, extractPodNames(mgr.NodePodsCache[testNode2]))
+
+	size, _ := metricstestutil.GetGaugeMetricValue(nodePodsCacheSize)
+	assert.Equal(t, float64(4), size)
+	stale, _ := metricstestutil.GetCounterMetricValue(nodePodsCacheDrift.WithLabelValues(StaleDrift))
+	assert.Equal(t, float64(2), stale-staleBefore)
+	missing, _ := metricstestutil.GetCounterMetricValue(nodePodsCacheDrift.WithLabelValues(MissingDrift))
+	assert.Equal(t, float64(1), missing-missingBefore)
+
+	// Pod-7 was deleted after the pods were listed, Pod-1 moved to another node
+	mgr.reconcile(map[string]map[NamespacedPod]bool{
+		testNode1: {{"default", "Pod-1"}: true, {"default", "Pod-4"}: true},
+		testNode2: {{"default", "Pod-1"}: true, {"default", "Pod-2"}: true, {"default", "Pod-7"}: true},
+	})
+	assert.ElementsMatch(t, []string{"Pod-1", "Pod-4", "Pod-6"}, extractPodNames(mgr.NodePodsCache[testNode1]))
+	assert.ElementsMatch(t, []string{"Pod-2"}, extractPodNames(mgr.NodePodsCache[testNode2]))
+	missing, _ = metricstestutil.GetCounterMetricValue(nodePodsCacheDrift.WithLabelValues(MissingDrift))
+	assert.Equal(t, float64(1), missing-missingBefore)
+}
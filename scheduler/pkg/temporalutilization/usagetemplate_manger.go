@@ -1,16 +1,21 @@
 package temporalutilization
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
 	pawsclientset "gitee.com/openeuler/paws/scheduler/pkg/generated/clientset/versioned"
 	pawsInformer "gitee.com/openeuler/paws/scheduler/pkg/generated/informers/externalversions/scheduling/v1alpha1"
 	pawslister "gitee.com/openeuler/paws/scheduler/pkg/generated/listers/scheduling/v1alpha1"
+	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/cache"
 	"gitee.com/openeuler/paws/scheduler/pkg/temporalutilization/utils"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	informerv1 "k8s.io/client-go/informers/core/v1"
 	listerv1 "k8s.io/client-go/listers/core/v1"
 	clientcache "k8s.io/client-go/tools/cache"
@@ -30,6 +35,26 @@ type UsageTemplateManager struct {
 
 	// NodePodsCache stores pods that are scheduled/reserved on the corresponding node
 	NodePodsCache map[string][]NamespacedPod
+	// MinCoverage is the minimum evaluation coverage for the historical usages of a usage template to be used,
+	// otherwise the usages are assumed by the pod requests
+	MinCoverage CoverageThresholds
+	// Fallback decides the usages assumed for pods without a usable usage template
+	Fallback FallbackPolicy
+	// nsLister is a namespace lister for the namespace level fallback policy, it is optional
+	nsLister listerv1.NamespaceLister
+	// forecasts caches the aggregated usages of the pods in NodePodsCache
+	forecasts *forecastCache
+	// memoryForecasts caches the aggregated memory usages of the pods in NodePodsCache for the memory overcommit,
+	// it is nil unless enabled, memory is not a target resource of the scoring
+	memoryForecasts *forecastCache
+	// templates caches the parsed usage templates
+	templates *cache.UsageTemplateStore
+	// baselines caches the parsed baseline usages of the nodes
+	baselines nodeBaselines
+	// ResyncPeriod is how often NodePodsCache is reconciled with the pod informer, 0 disables it
+	ResyncPeriod time.Duration
+	// podsSynced reports whether the pod informer has synced, the resync waits for it
+	podsSynced clientcache.InformerSynced
 	sync.RWMutex
 }
 
@@ -41,13 +66,32 @@ func NewUsageTemplateManager(pawsclient pawsclientset.Interface, snapshotSharedL
 		utLister:             utInformer.Lister(),
 		podLister:            podInformer.Lister(),
 		NodePodsCache:        make(map[string][]NamespacedPod),
+		Fallback:             FallbackPolicy{Mode: ByClassFallback},
+		ResyncPeriod:         DefaultNodePodsCacheResyncPeriod,
+		podsSynced:           podInformer.Informer().HasSynced,
 	}
+	utMgr.forecasts = newForecastCache(utMgr, supportedTargetResources())
+	utMgr.templates = cache.NewUsageTemplateStore()
 
 	utMgr.AddEventHandler(podInformer.Informer())
+	utMgr.AddUsageTemplateEventHandler(utInformer.Informer())
 
 	return utMgr
 }
 
+// enableMemoryForecasts maintains the memory forecasts of the nodes, it must be called before the informers start
+func (utMgr *UsageTemplateManager) enableMemoryForecasts() {
+	utMgr.memoryForecasts = newForecastCache(utMgr, []string{corev1.ResourceMemory.String()})
+}
+
+// forecastCaches returns the forecast caches maintained along NodePodsCache
+func (utMgr *UsageTemplateManager) forecastCaches() []*forecastCache {
+	if utMgr.memoryForecasts == nil {
+		return []*forecastCache{utMgr.forecasts}
+	}
+	return []*forecastCache{utMgr.forecasts, utMgr.memoryForecasts}
+}
+
 // GetUsageTemplate returns the Usage Template that a pod belongs to.
 func (utMgr *UsageTemplateManager) GetUsageTemplate(pod *corev1.Pod) (string, *v1alpha1.UsageTemplate) {
 	utName := utils.GetUsageTemplateLabel(pod)
@@ -70,16 +114,40 @@ func (utMgr *UsageTemplateManager) GetUsageTemplate(pod *corev1.Pod) (string, *v
 
 func (utMgr *UsageTemplateManager) OnAdd(obj interface{}) {
 	pod := obj.(*corev1.Pod)
+	if isFinished(pod) {
+		return
+	}
 	utMgr.updateCache(nil, pod)
 }
 
 func (utMgr *UsageTemplateManager) OnDelete(obj interface{}) {
-	pod := obj.(*corev1.Pod)
+	var pod *corev1.Pod
+	switch t := obj.(type) {
+	case *corev1.Pod:
+		pod = t
+	case clientcache.DeletedFinalStateUnknown:
+		var ok bool
+		if pod, ok = t.Obj.(*corev1.Pod); !ok {
+			utilruntime.HandleError(fmt.Errorf("unable to convert object %T to *v1.Pod", t.Obj))
+			return
+		}
+	default:
+		utilruntime.HandleError(fmt.Errorf("unable to handle object: %T", obj))
+		return
+	}
+
 	nodeName := pod.Spec.NodeName
 	utMgr.deleteFromCacheIfExists(pod, nodeName)
 }
 
 func (utMgr *UsageTemplateManager) deleteFromCacheIfExists(pod *corev1.Pod, nodeName string) {
+	utMgr.deleteFromNodePodsCache(pod, nodeName)
+	for _, fc := range utMgr.forecastCaches() {
+		fc.deletePod(pod, nodeName)
+	}
+}
+
+func (utMgr *UsageTemplateManager) deleteFromNodePodsCache(pod *corev1.Pod, nodeName string) {
 	utMgr.Lock()
 	defer utMgr.Unlock()
 	if _, ok := utMgr.NodePodsCache[nodeName]; !ok {
@@ -92,6 +160,7 @@ func (utMgr *UsageTemplateManager) deleteFromCacheIfExists(pod *corev1.Pod, node
 	for i := 0; i < len(utMgr.NodePodsCache[nodeName]); i++ {
 		if utMgr.NodePodsCache[nodeName][i].Name == pod.Name && utMgr.NodePodsCache[nodeName][i].Namespace == pod.Namespace {
 			utMgr.NodePodsCache[nodeName] = append(utMgr.NodePodsCache[nodeName][:i], utMgr.NodePodsCache[nodeName][i+1:]...)
+			nodePodsCacheSize.Dec()
 		}
 	}
 
@@ -101,10 +170,18 @@ func (utMgr *UsageTemplateManager) deleteFromCacheIfExists(pod *corev1.Pod, node
 }
 
 func (utMgr *UsageTemplateManager) addToCacheIfNotExists(pod *corev1.Pod, nodeName string) {
+	if utMgr.addToNodePodsCache(pod, nodeName) {
+		for _, fc := range utMgr.forecastCaches() {
+			fc.addPod(pod, nodeName)
+		}
+	}
+}
+
+// addToNodePodsCache returns false when the pod is already in the cache
+func (utMgr *UsageTemplateManager) addToNodePodsCache(pod *corev1.Pod, nodeName string) bool {
 	utMgr.Lock()
 	defer utMgr.Unlock()
 	// store it in the nodePodsCache for scoring
-	// TODO: check whether memory leaks happens if pod swifted from node to node
 	if _, ok := utMgr.NodePodsCache[nodeName]; !ok {
 		utMgr.NodePodsCache[nodeName] = make([]NamespacedPod, 0)
 	}
@@ -112,11 +189,13 @@ func (utMgr *UsageTemplateManager) addToCacheIfNotExists(pod *corev1.Pod, nodeNa
 	// if the cache already contains the pod , safe to ignore
 	for _, namespacedPod := range utMgr.NodePodsCache[nodeName] {
 		if namespacedPod.Name == pod.Name && namespacedPod.Namespace == pod.Namespace {
-			return
+			return false
 		}
 	}
 
 	utMgr.NodePodsCache[nodeName] = append(utMgr.NodePodsCache[nodeName], NamespacedPod{Namespace: pod.Namespace, Name: pod.Name})
+	nodePodsCacheSize.Inc()
+	return true
 }
 
 // updateCache update the internal cache that track where pods are on which node
@@ -137,6 +216,12 @@ func (utMgr *UsageTemplateManager) OnUpdate(oldObj, newObj interface{}) {
 	oldPod := oldObj.(*corev1.Pod)
 	newPod := newObj.(*corev1.Pod)
 
+	// finished or terminating pods no longer count towards the node usages
+	if isFinished(newPod) {
+		utMgr.OnDelete(oldPod)
+		return
+	}
+
 	// if node name changes
 	if oldPod.Spec.NodeName != newPod.Spec.NodeName {
 		utMgr.updateCache(oldPod, newPod)
@@ -148,6 +233,97 @@ func (utMgr *UsageTemplateManager) OnUpdate(oldObj, newObj interface{}) {
 		utMgr.updateCache(oldPod, newPod)
 		return
 	}
+
+	// short-lived usages are anchored to the pod start time
+	if !oldPod.Status.StartTime.Equal(newPod.Status.StartTime) {
+		for _, fc := range utMgr.forecastCaches() {
+			fc.addPod(newPod, newPod.Spec.NodeName)
+		}
+	}
+}
+
+// onUsageTemplateChange parses the usage template and refreshes the forecasts of the pods belonging to it
+func (utMgr *UsageTemplateManager) onUsageTemplateChange(obj interface{}) {
+	ut, ok := obj.(*v1alpha1.UsageTemplate)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("unable to handle object: %T", obj))
+		return
+	}
+
+	utMgr.templates.Set(ut)
+	for _, fc := range utMgr.forecastCaches() {
+		fc.refreshTemplate(fmt.Sprintf("%v/%v", ut.Namespace, ut.Name))
+	}
+}
+
+// onUsageTemplateDelete drops the usage template and refreshes the forecasts of the pods belonging to it
+func (utMgr *UsageTemplateManager) onUsageTemplateDelete(obj interface{}) {
+	var ut *v1alpha1.UsageTemplate
+	switch t := obj.(type) {
+	case *v1alpha1.UsageTemplate:
+		ut = t
+	case clientcache.DeletedFinalStateUnknown:
+		var ok bool
+		if ut, ok = t.Obj.(*v1alpha1.UsageTemplate); !ok {
+			utilruntime.HandleError(fmt.Errorf("unable to convert object %T to *v1alpha1.UsageTemplate", t.Obj))
+			return
+		}
+	default:
+		utilruntime.HandleError(fmt.Errorf("unable to handle object: %T", obj))
+		return
+	}
+
+	utMgr.templates.Delete(ut)
+	for _, fc := range utMgr.forecastCaches() {
+		fc.refreshTemplate(fmt.Sprintf("%v/%v", ut.Namespace, ut.Name))
+	}
+}
+
+func (utMgr *UsageTemplateManager) AddUsageTemplateEventHandler(informer clientcache.SharedIndexInformer) {
+	informer.AddEventHandler(
+		clientcache.ResourceEventHandlerFuncs{
+			AddFunc: utMgr.onUsageTemplateChange,
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				oldUT, ok := oldObj.(*v1alpha1.UsageTemplate)
+				newUT, nok := newObj.(*v1alpha1.UsageTemplate)
+				// a resync does not change the usage template
+				if ok && nok && oldUT.ResourceVersion == newUT.ResourceVersion {
+					return
+				}
+				utMgr.onUsageTemplateChange(newObj)
+			},
+			DeleteFunc: utMgr.onUsageTemplateDelete,
+		},
+	)
+}
+
+// AddNodeEventHandler evicts the deleted nodes from the caches kept by node name
+func (utMgr *UsageTemplateManager) AddNodeEventHandler(informer clientcache.SharedIndexInformer) {
+	informer.AddEventHandler(
+		clientcache.ResourceEventHandlerFuncs{
+			DeleteFunc: utMgr.onNodeDelete,
+		},
+	)
+}
+
+// onNodeDelete drops the parsed baseline of the node, it would otherwise stay cached until a node of the same name is added
+func (utMgr *UsageTemplateManager) onNodeDelete(obj interface{}) {
+	var node *corev1.Node
+	switch t := obj.(type) {
+	case *corev1.Node:
+		node = t
+	case clientcache.DeletedFinalStateUnknown:
+		var ok bool
+		if node, ok = t.Obj.(*corev1.Node); !ok {
+			utilruntime.HandleError(fmt.Errorf("unable to convert object %T to *v1.Node", t.Obj))
+			return
+		}
+	default:
+		utilruntime.HandleError(fmt.Errorf("unable to handle object: %T", obj))
+		return
+	}
+
+	utMgr.baselines.Delete(node.Name)
 }
 
 func (utMgr *UsageTemplateManager) AddEventHandler(informer clientcache.SharedIndexInformer) {
@@ -178,7 +354,119 @@ func isAssigned(pod *corev1.Pod) bool {
 	return len(pod.Spec.NodeName) != 0
 }
 
+// isFinished checks whether the pod has terminated or is being deleted
+func isFinished(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed || pod.DeletionTimestamp != nil
+}
+
+// runResync reconciles NodePodsCache with the pod informer once every ResyncPeriod until the context is done
+func (utMgr *UsageTemplateManager) runResync(ctx context.Context) {
+	if utMgr.ResyncPeriod <= 0 {
+		return
+	}
+	wait.UntilWithContext(ctx, func(context.Context) { utMgr.resync() }, utMgr.ResyncPeriod)
+}
+
+// resync reconciles NodePodsCache with the pods bound according to the pod informer, which catches the pods
+// missed or left behind by the pod events, e.g. during informer relists
+func (utMgr *UsageTemplateManager) resync() {
+	if utMgr.podsSynced != nil && !utMgr.podsSynced() {
+		return
+	}
+
+	pods, err := utMgr.podLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "unable to list pods, skipping node pods cache resync")
+		return
+	}
+
+	expected := make(map[string]map[NamespacedPod]bool)
+	for _, pod := range pods {
+		if !isAssigned(pod) || isFinished(pod) {
+			continue
+		}
+		if expected[pod.Spec.NodeName] == nil {
+			expected[pod.Spec.NodeName] = make(map[NamespacedPod]bool)
+		}
+		expected[pod.Spec.NodeName][NamespacedPod{Namespace: pod.Namespace, Name: pod.Name}] = true
+	}
+
+	utMgr.reconcile(expected)
+}
+
+// reconcile replaces NodePodsCache with the expected pods of the nodes. The pods are checked against the pod lister
+// again under the lock, since the pod events keep updating NodePodsCache after the expected pods were listed.
+func (utMgr *UsageTemplateManager) reconcile(expected map[string]map[NamespacedPod]bool) {
+	utMgr.Lock()
+	drifted := make(map[string]bool)
+	stale, missing := 0, 0
+	nodePods := make(map[string][]NamespacedPod, len(expected))
+	for nodeName, pods := range utMgr.NodePodsCache {
+		for _, namespacedPod := range pods {
+			if utMgr.isOnNode(namespacedPod, nodeName) || utMgr.isReserved(namespacedPod) {
+				nodePods[nodeName] = append(nodePods[nodeName], namespacedPod)
+				delete(expected[nodeName], namespacedPod)
+				continue
+			}
+			stale++
+			drifted[nodeName] = true
+		}
+	}
+
+	for nodeName, pods := range expected {
+		for namespacedPod := range pods {
+			if !utMgr.isOnNode(namespacedPod, nodeName) {
+				// deleted or finished since listed, its events are handled on their own
+				continue
+			}
+			nodePods[nodeName] = append(nodePods[nodeName], namespacedPod)
+			missing++
+			drifted[nodeName] = true
+		}
+	}
+
+	utMgr.NodePodsCache = nodePods
+	nodePodsCacheSize.Set(float64(utMgr.cacheSize()))
+	utMgr.Unlock()
+
+	nodePodsCacheDrift.WithLabelValues(StaleDrift).Add(float64(stale))
+	nodePodsCacheDrift.WithLabelValues(MissingDrift).Add(float64(missing))
+	if len(drifted) > 0 {
+		klog.V(4).InfoS("Reconciled node pods cache with the pod informer", "stale", stale, "missing", missing, "nodes", len(drifted))
+	}
+
+	for nodeName := range drifted {
+		for _, fc := range utMgr.forecastCaches() {
+			fc.invalidateNode(nodeName)
+		}
+	}
+}
+
+// isOnNode checks whether the pod informer still sees the pod running on the node
+func (utMgr *UsageTemplateManager) isOnNode(namespacedPod NamespacedPod, nodeName string) bool {
+	pod, err := utMgr.podLister.Pods(namespacedPod.Namespace).Get(namespacedPod.Name)
+	if err != nil {
+		return false
+	}
+	return pod.Spec.NodeName == nodeName && !isFinished(pod)
+}
+
+// isReserved checks whether the pod informer still sees the pod pending, i.e. the pod is reserved and being bound
+func (utMgr *UsageTemplateManager) isReserved(namespacedPod NamespacedPod) bool {
+	pod, err := utMgr.podLister.Pods(namespacedPod.Namespace).Get(namespacedPod.Name)
+	if err != nil {
+		return false
+	}
+	return !isAssigned(pod) && !isFinished(pod)
+}
+
 func (utMgr *UsageTemplateManager) CacheSize() int {
+	utMgr.RLock()
+	defer utMgr.RUnlock()
+	return utMgr.cacheSize()
+}
+
+func (utMgr *UsageTemplateManager) cacheSize() int {
 	counts := 0
 	for _, v := range utMgr.NodePodsCache {
 		counts += len(v)
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	weekdayKey = "weekday"
+	weekendKey = "weekend"
+
+	hoursInADay = 24
+)
+
+// HourlyUsage is a usage by hour of the weekdays and of the weekends in UTC, an hour without a value is missing
+type HourlyUsage struct {
+	Weekday map[int16]float64
+	Weekend map[int16]float64
+}
+
+// NewHourlyUsage returns an empty HourlyUsage
+func NewHourlyUsage() HourlyUsage {
+	return HourlyUsage{
+		Weekday: make(map[int16]float64),
+		Weekend: make(map[int16]float64),
+	}
+}
+
+// FormatHourlyUsage formats the usage as "weekday=<24 values>;weekend=<24 values>",
+// the values are rounded and separated by commas, an hour without a value is left empty
+func FormatHourlyUsage(u HourlyUsage) string {
+	return fmt.Sprintf("%s=%s;%s=%s", weekdayKey, formatHours(u.Weekday), weekendKey, formatHours(u.Weekend))
+}
+
+func formatHours(hours map[int16]float64) string {
+	values := make([]string, hoursInADay)
+	for hour := int16(0); hour < hoursInADay; hour++ {
+		if v, ok := hours[hour]; ok {
+			values[hour] = strconv.FormatFloat(math.Round(v), 'f', -1, 64)
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+// ParseHourlyUsage parses a usage formatted by FormatHourlyUsage
+func ParseHourlyUsage(s string) (HourlyUsage, error) {
+	u := NewHourlyUsage()
+	for _, part := range strings.Split(s, ";") {
+		key, values, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return u, fmt.Errorf("invalid hourly usage %q, expect weekday=<values>;weekend=<values>", part)
+		}
+
+		var hours map[int16]float64
+		switch key {
+		case weekdayKey:
+			hours = u.Weekday
+		case weekendKey:
+			hours = u.Weekend
+		default:
+			return u, fmt.Errorf("invalid hourly usage %q, expect weekday or weekend", key)
+		}
+
+		fields := strings.Split(values, ",")
+		if len(fields) != hoursInADay {
+			return u, fmt.Errorf("invalid %s hourly usage, expect %d values, got %d", key, hoursInADay, len(fields))
+		}
+
+		for hour, field := range fields {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil || v < 0 || math.IsInf(v, 0) {
+				return u, fmt.Errorf("invalid %s usage of hour %d: %q", key, hour, field)
+			}
+			hours[int16(hour)] = v
+		}
+	}
+
+	return u, nil
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHourlyUsageFormatAndParse(t *testing.T) {
+	usage := NewHourlyUsage()
+	usage.Weekday[0] = 120.4
+	usage.Weekday[23] = 300
+	usage.Weekend[12] = 80.6
+
+	formatted := FormatHourlyUsage(usage)
+	assert.Equal(t, "weekday=120"+strings.Repeat(",", 23)+"300;weekend="+strings.Repeat(",", 12)+"81"+strings.Repeat(",", 11), formatted)
+
+	parsed, err := ParseHourlyUsage(formatted)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int16]float64{0: 120, 23: 300}, parsed.Weekday)
+	assert.Equal(t, map[int16]float64{12: 81}, parsed.Weekend)
+
+	for _, invalid := range []string{
+		"",
+		"weekday=1,2,3",
+		"monday=" + strings.Repeat(",", 23),
+		"weekday=-1" + strings.Repeat(",", 23),
+		"weekday=abc" + strings.Repeat(",", 23),
+	} {
+		_, err := ParseHourlyUsage(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"fmt"
+	"strconv"
 	"time"
 
 	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
@@ -25,3 +27,28 @@ func AfterUTC(a, b time.Time) bool {
 func GetUsageTemplateLabel(pod *v1.Pod) string {
 	return pod.Labels[v1alpha1.UsageTemplateLabelIdentifier]
 }
+
+// GetNodePerformanceFactor returns the performance factor of the node for the resource,
+// 1 when the node is not annotated
+func GetNodePerformanceFactor(node *v1.Node, resourceName string) (float64, error) {
+	annotation, ok := v1alpha1.SupportedPerformanceFactorAnnotation[resourceName]
+	if !ok || node == nil {
+		return 1, nil
+	}
+
+	v, ok := node.Annotations[annotation]
+	if !ok {
+		return 1, nil
+	}
+
+	factor, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 1, fmt.Errorf("failed to parse performance factor of node %v: %v", node.Name, err)
+	}
+
+	if factor <= 0 {
+		return 1, fmt.Errorf("invalid performance factor of node %v: must be greater than zero, got %v", node.Name, factor)
+	}
+
+	return factor, nil
+}
@@ -40,7 +40,7 @@ func SetStatusConditions(ctx context.Context, client runtimeclient.StatusClient,
 	patch := PatchTarget(ut)
 
 	if len(ut.Status.Conditions) == 0 {
-		ut.Status.Conditions = *v1alpha1.GetReadyConditions()
+		ut.Status.Conditions = *v1alpha1.GetReadyCondtions()
 	}
 
 	transform(&ut.Status.Conditions, status, reason, message)
@@ -16,7 +16,6 @@ package util
 import (
 	"sort"
 	"strconv"
-	"strings"
 
 	"gitee.com/openeuler/paws/scheduler/apis/scheduling/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -84,7 +83,7 @@ func SameUsageADay(usage float32) map[int]float32 {
 }
 
 // Convert resource usage data into the appropriate scheduling resource usage format
-func MakeResourceUsages(resourceUsages map[string]map[int]float32, resources map[string]bool, isWeekday bool) []v1alpha1.ResourceUsage {
+func MakeResourceUsages(resourceUsages map[string]map[int]float32, isWeekday bool) []v1alpha1.ResourceUsage {
     if resourceUsages == nil {
         return nil
     }
@@ -92,9 +91,6 @@ func MakeResourceUsages(resourceUsages map[string]map[int]float32, resources map
     schedResourceUsages := make([]v1alpha1.ResourceUsage, 0, len(resourceUsages))
     
     for res, usages := range resourceUsages {
-        // Track the resources being used
-        resources[res] = true
-        
         // Build sample data for each resource with error handling
         samples := buildUsageSamples(usages, isWeekday)
         
@@ -124,6 +120,11 @@ func buildUsageSamples(hourlyValues map[int]float32, isWeekday bool) []v1alpha1.
         // 格式化浮点数，确保精度
         valueStr := strconv.FormatFloat(float64(value), 'f', 2, 32)
         
+        // the evaluator stores the weekend hours as 24 to 47
+        if !isWeekday {
+            hour += 24
+        }
+
         samples = append(samples, v1alpha1.Sample{
             Hour:      int32(hour),
             Value:     valueStr,
@@ -138,7 +139,7 @@ func buildUsageSamples(hourlyValues map[int]float32, isWeekday bool) []v1alpha1.
 // Create a usage template for resources with their weekday and weekend usages
 func MakeUsageTemplate(name, namespace string, enabled bool, qosClass string,
     resourceWeekdayUsages, resourceWeekendUsages map[string]map[int]float32,
-    isLongRunning bool) (*v1alpha1.UsageTemplate, error) {
+    isLongRunning bool) *v1alpha1.UsageTemplate {
 
     // 收集所有资源名称
     resourceSet := make(map[string]struct{})
@@ -152,15 +153,8 @@ func MakeUsageTemplate(name, namespace string, enabled bool, qosClass string,
     }
 
     // 处理工作日和周末
-    weekdaySchedUsages, err := MakeResourceUsages(resourceWeekdayUsages, true)
-    if err != nil {
-        return nil, fmt.Errorf("failed to process weekday usage data: %w", err)
-    }
-
-    weekendSchedUsages, err := MakeResourceUsages(resourceWeekendUsages, false)
-    if err != nil {
-        return nil, fmt.Errorf("failed to process weekend usage data: %w", err)
-    }
+    weekdaySchedUsages := MakeResourceUsages(resourceWeekdayUsages, true)
+    weekendSchedUsages := MakeResourceUsages(resourceWeekendUsages, false)
 
     // 合并资源使用数据
     schedResourceUsages := append(weekdaySchedUsages, weekendSchedUsages...)
@@ -182,7 +176,7 @@ func MakeUsageTemplate(name, namespace string, enabled bool, qosClass string,
                 Items: schedResourceUsages,
             },
         },
-    }, nil
+    }
 }
 
 func collectResourceNames(usageData map[string]map[int]float32, resourceSet map[string]struct{}) {